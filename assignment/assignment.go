@@ -0,0 +1,440 @@
+package assignment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/LdDl/osm2gmns/demand"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/routing"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/utils"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoDemand = fmt.Errorf("No demand between zones attached to the network")
+)
+
+const (
+	// Number of bisection steps in the line search
+	lineSearchIterations = 30
+)
+
+// AssignmentConfig is the set of parameters for the traffic assignment
+type AssignmentConfig struct {
+	agentType types.AgentType
+	bpr       BPR
+	// Movements which are allowed at nodes. Nil value means that every turn is allowed
+	movements *movement.MovementsStorage
+	// Use penalties of movements as extra travel time of turns
+	turnPenalties bool
+	maxIterations int
+	// Relative gap which is considered as equilibrium
+	relativeGap float64
+	// Number of parallel workers. Non-positive value means number of CPUs
+	workers int
+}
+
+// NewAssignmentConfigDefault returns default parameters for the assignment: AGENT_AUTO, BPR with alpha = 0.15 and beta = 4, no movement prohibitions,
+// 100 iterations at most, relative gap 1e-4, all CPUs
+func NewAssignmentConfigDefault() *AssignmentConfig {
+	return &AssignmentConfig{
+		agentType:     types.AGENT_AUTO,
+		bpr:           NewBPRDefault(),
+		maxIterations: 100,
+		relativeGap:   1e-4,
+		workers:       0,
+	}
+}
+
+// WithAgentType sets agent type which demand is assigned for
+func WithAgentType(agentType types.AgentType) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.agentType = agentType
+	}
+}
+
+// WithBPR sets parameters of BPR volume-delay function
+func WithBPR(alpha, beta float64) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.bpr = BPR{Alpha: alpha, Beta: beta}
+	}
+}
+
+// WithMovements enables movement prohibitions (see routing.WithMovements). Volumes of movements are evaluated too
+func WithMovements(movements *movement.MovementsStorage) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.movements = movements
+	}
+}
+
+// WithTurnPenalties enables penalties of movements as extra travel time of turns. It takes effect only when movements are provided (see WithMovements)
+func WithTurnPenalties(turnPenalties bool) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.turnPenalties = turnPenalties
+	}
+}
+
+// WithMaxIterations sets max number of Frank-Wolfe iterations
+func WithMaxIterations(maxIterations int) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.maxIterations = maxIterations
+	}
+}
+
+// WithRelativeGap sets relative gap which is considered as equilibrium
+func WithRelativeGap(relativeGap float64) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.relativeGap = relativeGap
+	}
+}
+
+// WithWorkers sets number of workers which search for the shortest routes from origins in parallel at every iteration. Non-positive value means number of CPUs
+func WithWorkers(workers int) func(*AssignmentConfig) {
+	return func(cfg *AssignmentConfig) {
+		cfg.workers = workers
+	}
+}
+
+// LinkPerformance is the state of the link after the assignment
+type LinkPerformance struct {
+	LinkID gmns.LinkID
+	// Volume (veh/h)
+	Volume float64
+	// Congested travel time (seconds)
+	TravelTime float64
+	// Congested speed (km/h)
+	Speed float64
+	// Volume to capacity ratio. It is -1 when capacity of the link is unknown
+	VOC float64
+}
+
+// Result is the result of the assignment
+type Result struct {
+	Iterations  int
+	RelativeGap float64
+	// Performance of links which are passable for the agent type ordered by identifiers
+	Links []LinkPerformance
+	// Volumes (veh/h) of movements. It is filled only when movements are provided
+	MovementVolumes map[movement.MovementID]float64
+	// Demand which has not been assigned: zones are not attached to the network or there is no route between them
+	UnassignedVolume float64
+}
+
+// flows is the volume of links and turns (pair of incoming and outcoming links)
+type flows struct {
+	links map[gmns.LinkID]float64
+	turns map[[2]gmns.LinkID]float64
+}
+
+func newFlows() flows {
+	return flows{
+		links: make(map[gmns.LinkID]float64),
+		turns: make(map[[2]gmns.LinkID]float64),
+	}
+}
+
+// moveTowards moves flows towards auxiliary flows with given step: x = (1 - step) * x + step * y
+func (x flows) moveTowards(y flows, step float64) {
+	for linkID := range x.links {
+		x.links[linkID] *= 1 - step
+	}
+	for linkID, volume := range y.links {
+		x.links[linkID] += step * volume
+	}
+	for turn := range x.turns {
+		x.turns[turn] *= 1 - step
+	}
+	for turn, volume := range y.turns {
+		x.turns[turn] += step * volume
+	}
+}
+
+type destination struct {
+	nodeID gmns.NodeID
+	volume float64
+}
+
+// assigner holds the state of the assignment
+type assigner struct {
+	net *macro.Net
+	cfg *AssignmentConfig
+	// Free-flow travel time (seconds) and capacity (veh/h) of passable links
+	freeFlowTimes map[gmns.LinkID]float64
+	capacities    map[gmns.LinkID]float64
+	// Penalties (seconds) of turns. Empty when turn penalties are disabled
+	penalties    map[[2]gmns.LinkID]float64
+	origins      []gmns.NodeID
+	destinations map[gmns.NodeID][]destination
+}
+
+// Assign finds static user equilibrium (Frank-Wolfe algorithm) for the demand between zones.
+// Demand of zones without centroid nodes (see macro.Net.AttachedZones) is reported as unassigned. Intrazonal demand is ignored.
+// Volumes are written back to links of the network (and movements if they are provided)
+func Assign(net *macro.Net, zones zone.Zones, od demand.Demand, options ...func(*AssignmentConfig)) (*Result, error) {
+	cfg := NewAssignmentConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	result := &Result{}
+	a := &assigner{
+		net:           net,
+		cfg:           cfg,
+		freeFlowTimes: make(map[gmns.LinkID]float64),
+		capacities:    make(map[gmns.LinkID]float64),
+		penalties:     make(map[[2]gmns.LinkID]float64),
+		destinations:  make(map[gmns.NodeID][]destination),
+	}
+
+	attached, err := net.AttachedZones(zones)
+	if err != nil {
+		return nil, err
+	}
+	centroids := make(map[gmns.ZoneID]gmns.NodeID, len(attached))
+	for _, zn := range attached {
+		centroids[zn.ID] = zn.GetCentroidNodeID()
+	}
+	// Demand of zones which are not attached to the network
+	notAttached := 0.0
+	for _, pair := range od {
+		if pair.Origin == pair.Destination || pair.Volume <= 0 {
+			continue
+		}
+		originNodeID, okOrigin := centroids[pair.Origin]
+		destinationNodeID, okDestination := centroids[pair.Destination]
+		if !okOrigin || !okDestination {
+			notAttached += pair.Volume
+			continue
+		}
+		if _, ok := a.destinations[originNodeID]; !ok {
+			a.origins = append(a.origins, originNodeID)
+		}
+		a.destinations[originNodeID] = append(a.destinations[originNodeID], destination{nodeID: destinationNodeID, volume: pair.Volume})
+	}
+	if len(a.origins) == 0 {
+		return nil, ErrNoDemand
+	}
+	sort.Slice(a.origins, func(i, j int) bool {
+		return a.origins[i] < a.origins[j]
+	})
+
+	for linkID, link := range net.Links {
+		freeFlowTime := routing.FreeFlowTime(link)
+		if freeFlowTime < 0 {
+			continue
+		}
+		a.freeFlowTimes[linkID] = freeFlowTime
+		a.capacities[linkID] = linkCapacity(link)
+	}
+	if cfg.movements != nil && cfg.turnPenalties {
+		for _, mvmt := range cfg.movements.List() {
+			if penalty := turnPenalty(mvmt); penalty > 0 {
+				a.penalties[[2]gmns.LinkID{mvmt.IncomeMacroLinkID, mvmt.OutcomeMacroLinkID}] = penalty
+			}
+		}
+	}
+
+	// Router is prepared once: travel times of links are updated between iterations
+	router := a.newRouter()
+	// Initial solution is all-or-nothing assignment at free flow
+	x, _, _, err := a.allOrNothing(router)
+	if err != nil {
+		return nil, err
+	}
+	times := a.travelTimes(x)
+	for {
+		router.UpdateCosts(func(link *macro.Link) float64 {
+			return times[link.ID]
+		})
+		y, shortestTime, unassigned, err := a.allOrNothing(router)
+		if err != nil {
+			return nil, err
+		}
+		result.UnassignedVolume = notAttached + unassigned
+		totalTime := a.totalTime(x, times)
+		result.RelativeGap = 0
+		if totalTime > 0 {
+			result.RelativeGap = (totalTime - shortestTime) / totalTime
+		}
+		if result.RelativeGap <= cfg.relativeGap || result.Iterations >= cfg.maxIterations {
+			break
+		}
+		x.moveTowards(y, a.lineSearch(x, y))
+		times = a.travelTimes(x)
+		result.Iterations++
+	}
+
+	a.writeBack(x, times, result)
+	return result, nil
+}
+
+// newRouter prepares router which minimizes free-flow travel time (including penalties of turns when they are enabled)
+func (a *assigner) newRouter() *routing.Router {
+	routerOptions := []func(*routing.RouterConfig){
+		routing.WithAgentType(a.cfg.agentType),
+		routing.WithCustomCost(func(link *macro.Link) float64 {
+			if time, ok := a.freeFlowTimes[link.ID]; ok {
+				return time
+			}
+			return -1
+		}, nil),
+	}
+	if a.cfg.movements != nil {
+		routerOptions = append(routerOptions, routing.WithMovements(a.cfg.movements))
+		if a.cfg.turnPenalties {
+			routerOptions = append(routerOptions, routing.WithTurnCost(turnPenalty))
+		}
+	}
+	return routing.NewRouter(a.net, routerOptions...)
+}
+
+// allOrNothing loads the whole demand on the shortest routes for current costs of the router.
+// It returns flows, total travel time on the shortest routes and volume of unreachable demand
+func (a *assigner) allOrNothing(router *routing.Router) (flows, float64, float64, error) {
+	total := newFlows()
+	shortestTime, unassigned := 0.0, 0.0
+	originsErrors := make([]error, len(a.origins))
+	var mu sync.Mutex
+	utils.RunWorkers(len(a.origins), a.cfg.workers, func(jobs <-chan int) {
+		local := newFlows()
+		localTime, localUnassigned := 0.0, 0.0
+		for idx := range jobs {
+			originNodeID := a.origins[idx]
+			tree, err := router.ShortestTree(originNodeID)
+			if err != nil {
+				originsErrors[idx] = errors.Wrapf(err, "Can't find shortest routes from origin node with ID: '%d'", originNodeID)
+				continue
+			}
+			for _, dest := range a.destinations[originNodeID] {
+				linksIDs, ok := tree.Links(dest.nodeID)
+				if !ok {
+					localUnassigned += dest.volume
+					continue
+				}
+				localTime += tree.Costs[dest.nodeID] * dest.volume
+				for i, linkID := range linksIDs {
+					local.links[linkID] += dest.volume
+					if i > 0 && a.cfg.movements != nil {
+						local.turns[[2]gmns.LinkID{linksIDs[i-1], linkID}] += dest.volume
+					}
+				}
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for linkID, volume := range local.links {
+			total.links[linkID] += volume
+		}
+		for turn, volume := range local.turns {
+			total.turns[turn] += volume
+		}
+		shortestTime += localTime
+		unassigned += localUnassigned
+	})
+	for _, err := range originsErrors {
+		if err != nil {
+			return flows{}, 0, 0, err
+		}
+	}
+	return total, shortestTime, unassigned, nil
+}
+
+// travelTimes evaluates congested travel times (seconds) of links for given flows
+func (a *assigner) travelTimes(x flows) map[gmns.LinkID]float64 {
+	times := make(map[gmns.LinkID]float64, len(a.freeFlowTimes))
+	for linkID, freeFlowTime := range a.freeFlowTimes {
+		times[linkID] = a.cfg.bpr.TravelTime(freeFlowTime, x.links[linkID], a.capacities[linkID])
+	}
+	return times
+}
+
+// totalTime returns total travel time of the flows (including penalties of turns)
+func (a *assigner) totalTime(x flows, times map[gmns.LinkID]float64) float64 {
+	total := 0.0
+	for linkID, volume := range x.links {
+		total += volume * times[linkID]
+	}
+	for turn, penalty := range a.penalties {
+		total += x.turns[turn] * penalty
+	}
+	return total
+}
+
+// lineSearch finds step towards the auxiliary flows which minimizes Beckmann's objective (bisection on its derivative)
+func (a *assigner) lineSearch(x, y flows) float64 {
+	derivative := func(step float64) float64 {
+		value := 0.0
+		for linkID, freeFlowTime := range a.freeFlowTimes {
+			delta := y.links[linkID] - x.links[linkID]
+			if delta == 0 {
+				continue
+			}
+			value += delta * a.cfg.bpr.TravelTime(freeFlowTime, x.links[linkID]+step*delta, a.capacities[linkID])
+		}
+		for turn, penalty := range a.penalties {
+			value += (y.turns[turn] - x.turns[turn]) * penalty
+		}
+		return value
+	}
+	if derivative(1) <= 0 {
+		return 1
+	}
+	low, high := 0.0, 1.0
+	for i := 0; i < lineSearchIterations; i++ {
+		middle := (low + high) / 2
+		if derivative(middle) > 0 {
+			high = middle
+		} else {
+			low = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// writeBack sets volumes of links and movements and prepares performance of links
+func (a *assigner) writeBack(x flows, times map[gmns.LinkID]float64, result *Result) {
+	for linkID, link := range a.net.Links {
+		link.SetVolume(x.links[linkID])
+		if _, ok := a.freeFlowTimes[linkID]; !ok {
+			continue
+		}
+		performance := LinkPerformance{
+			LinkID:     linkID,
+			Volume:     x.links[linkID],
+			TravelTime: times[linkID],
+			Speed:      -1,
+			VOC:        -1,
+		}
+		if performance.TravelTime > 0 {
+			performance.Speed = link.GetLengthMeters() / performance.TravelTime * 3.6
+		}
+		if capacity := a.capacities[linkID]; capacity > 0 {
+			performance.VOC = performance.Volume / capacity
+		}
+		result.Links = append(result.Links, performance)
+	}
+	sort.Slice(result.Links, func(i, j int) bool {
+		return result.Links[i].LinkID < result.Links[j].LinkID
+	})
+
+	if a.cfg.movements == nil {
+		return
+	}
+	result.MovementVolumes = make(map[movement.MovementID]float64, a.cfg.movements.Len())
+	for _, mvmt := range a.cfg.movements.List() {
+		volume := x.turns[[2]gmns.LinkID{mvmt.IncomeMacroLinkID, mvmt.OutcomeMacroLinkID}]
+		mvmt.SetVolume(volume)
+		result.MovementVolumes[mvmt.ID] = volume
+	}
+}
+
+// turnPenalty returns penalty (seconds) of the movement. Unknown penalty is considered as zero
+func turnPenalty(mvmt *movement.Movement) float64 {
+	return math.Max(mvmt.Penalty(), 0)
+}
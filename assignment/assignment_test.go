@@ -0,0 +1,88 @@
+package assignment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/demand"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssign(t *testing.T) {
+	// Two two-way roads from crossing 1 to crossing 3: straight one (~223 meters) and the one with right turn at crossing 4 (~315 meters)
+	points := map[osm.NodeID]orb.Point{1: {0, 0}, 3: {0.002, 0}, 4: {0.001, 0.001}}
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM, len(points))
+	for nodeID, pt := range points {
+		nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}, IsCrossing: true}
+	}
+	ways := []*wrappers.WayOSM{
+		{ID: 1, Nodes: []osm.NodeID{1, 3}, Tags: wrappers.WayTags{MaxSpeed: 50}, FreeSpeed: -1, Capacity: -1, LinkType: types.LINK_PRIMARY, LinkClass: types.LINK_CLASS_HIGHWAY, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}},
+		{ID: 2, Nodes: []osm.NodeID{1, 4, 3}, Tags: wrappers.WayTags{MaxSpeed: 50}, FreeSpeed: -1, Capacity: -1, LinkType: types.LINK_PRIMARY, LinkClass: types.LINK_CLASS_HIGHWAY, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}},
+	}
+	net, err := macro.NewNetFromOSM(ways, nodesSet)
+	assert.NoError(t, err)
+	links := make(map[[2]orb.Point]*macro.Link, len(net.Links))
+	for _, link := range net.Links {
+		links[[2]orb.Point{net.Nodes[link.GetSourceNodeID()].GetGeom(), net.Nodes[link.GetTargetNodeID()].GetGeom()}] = link
+	}
+	short := links[[2]orb.Point{points[1], points[3]}]
+	long := []*macro.Link{links[[2]orb.Point{points[1], points[4]}], links[[2]orb.Point{points[4], points[3]}]}
+
+	square := func(center orb.Point) orb.MultiPolygon {
+		return orb.MultiPolygon{orb.Bound{Min: orb.Point{center[0] - 0.0003, center[1] - 0.0003}, Max: orb.Point{center[0] + 0.0003, center[1] + 0.0003}}.ToPolygon()}
+	}
+	zones := zone.Zones{zone.NewZone(0, square(points[1])), zone.NewZone(1, square(points[3]))}
+	od := demand.Demand{{Origin: 0, Destination: 1, Volume: 8000}, {Origin: 0, Destination: 5, Volume: 100}}
+	_, err = Assign(net, zones, od)
+	assert.ErrorIs(t, err, macro.ErrNoCentroids, "Zones should be attached first")
+	_, err = net.AttachZones(zones, macro.WithConnectorsNum(1), macro.WithMaxConnectorLength(1000))
+	assert.NoError(t, err)
+	_, err = Assign(net, zones, demand.Demand{{Origin: 0, Destination: 0, Volume: 100}})
+	assert.ErrorIs(t, err, ErrNoDemand, "Intrazonal demand should be ignored")
+	movements, err := net.GenerateMovements()
+	assert.NoError(t, err)
+
+	result, err := Assign(net, zones, od, WithMovements(movements), WithWorkers(2))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, result.RelativeGap, 1e-4, "Equilibrium should be reached")
+	assert.Equal(t, 100.0, result.UnassignedVolume, "Demand of unknown zone should not be assigned")
+	assert.InDelta(t, 8000.0, short.GetVolume()+long[0].GetVolume(), 1e-6, "Whole demand should be assigned")
+	assert.Greater(t, long[0].GetVolume(), 0.0, "Long road should be used when short one is congested")
+
+	times := make(map[gmns.LinkID]float64)
+	for _, performance := range result.Links {
+		times[performance.LinkID] = performance.TravelTime
+	}
+	shortTime, longTime := times[short.ID], times[long[0].ID]+times[long[1].ID]
+	assert.InDelta(t, shortTime, longTime, shortTime*0.01, "Used routes should have the same travel time")
+
+	incoming := 0.0
+	for mvmtID, volume := range result.MovementVolumes {
+		mvmt, _ := movements.Get(mvmtID)
+		if mvmt.IncomeMacroLinkID == short.ID {
+			incoming += volume
+		}
+	}
+	assert.InDelta(t, short.GetVolume(), incoming, 1e-6, "Volume of movements should match volume of incoming link")
+
+	// Turns of the long road have greater penalties
+	longVolume := long[0].GetVolume()
+	_, err = Assign(net, zones, od, WithMovements(movements), WithTurnPenalties(true))
+	assert.NoError(t, err)
+	assert.Less(t, long[0].GetVolume(), longVolume, "Turn penalties should be taken into account")
+
+	dir := t.TempDir()
+	assert.NoError(t, result.ExportToCSV(filepath.Join(dir, "test.csv")))
+	content, err := os.ReadFile(filepath.Join(dir, "test_link_performance.csv"))
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), len(result.Links)+1, "Every link should have performance")
+}
@@ -0,0 +1,35 @@
+package assignment
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/macro"
+)
+
+// BPR is the volume-delay function of Bureau of Public Roads: t = t0 * (1 + alpha * (v / c) ^ beta)
+type BPR struct {
+	Alpha float64
+	Beta  float64
+}
+
+// NewBPRDefault returns BPR function with classic parameters: alpha = 0.15, beta = 4
+func NewBPRDefault() BPR {
+	return BPR{Alpha: 0.15, Beta: 4}
+}
+
+// TravelTime returns congested travel time for given free-flow travel time, volume (veh/h) and capacity (veh/h).
+// Non-positive capacity means that the link is never congested
+func (bpr BPR) TravelTime(freeFlowTime, volume, capacity float64) float64 {
+	if capacity <= 0 || volume <= 0 {
+		return freeFlowTime
+	}
+	return freeFlowTime * (1 + bpr.Alpha*math.Pow(volume/capacity, bpr.Beta))
+}
+
+// linkCapacity returns capacity (veh/h) of the link for all lanes. It is -1 when capacity is unknown
+func linkCapacity(link *macro.Link) float64 {
+	if link.GetCapacity() <= 0 {
+		return -1
+	}
+	return float64(link.GetCapacity() * max(link.GetLanesNum(), 1))
+}
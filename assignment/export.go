@@ -0,0 +1,45 @@
+package assignment
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExportToCSV writes performance of links (GMNS link_performance.csv) to the file with `_link_performance.csv` suffix.
+// Travel time is in seconds, speed is in km/h
+func (result *Result) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnamePerformance := fmt.Sprintf(fnameParts[0] + "_link_performance.csv")
+
+	file, err := os.Create(fnamePerformance)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"link_id", "volume", "travel_time", "speed", "voc"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, performance := range result.Links {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", performance.LinkID),
+			fmt.Sprintf("%f", performance.Volume),
+			fmt.Sprintf("%f", performance.TravelTime),
+			fmt.Sprintf("%f", performance.Speed),
+			fmt.Sprintf("%f", performance.VOC),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write link performance")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package osm2gmns
+
+// BarrierMode defines how barrier nodes (`barrier=*`) affect macroscopic network
+type BarrierMode uint16
+
+const (
+	// Remove blocked agent types from the link which contains barrier node
+	BARRIER_MODE_RESTRICT_LINK = BarrierMode(iota)
+	// Split link at barrier node. Blocked agent types are removed from movements through such node
+	BARRIER_MODE_SPLIT_LINK
+)
+
+func (iotaIdx BarrierMode) String() string {
+	return [...]string{"restrict_link", "split_link"}[iotaIdx]
+}
@@ -0,0 +1,79 @@
+package osm2gmns
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBarrierBlockedAgentTypes(t *testing.T) {
+	assert.Empty(t, types.NewBarrierBlockedAgentTypes(types.BARRIER_NONE, "", "", "", "", "no"), "There is no barrier")
+	assert.Equal(t, []types.AgentType{types.AGENT_AUTO}, types.NewBarrierBlockedAgentTypes(types.BARRIER_BOLLARD, "", "", "", "", ""), "Bollard should block cars only")
+	assert.Empty(t, types.NewBarrierBlockedAgentTypes(types.BARRIER_GATE, "", "", "", "", ""), "Gate should block nobody by default")
+	assert.Equal(t, []types.AgentType{types.AGENT_AUTO, types.AGENT_BIKE, types.AGENT_WALK}, types.NewBarrierBlockedAgentTypes(types.BARRIER_GATE, "", "", "", "", "private"), "Private gate should block everyone")
+	assert.Equal(t, []types.AgentType{types.AGENT_BIKE, types.AGENT_WALK}, types.NewBarrierBlockedAgentTypes(types.BARRIER_LIFT_GATE, "yes", "", "", "", "no"), "Specific access tag should override generic one")
+	assert.Empty(t, types.NewBarrierBlockedAgentTypes(types.BARRIER_BOLLARD, "no", "yes", "", "", ""), "Tag 'motorcar' should override 'motor_vehicle'")
+	assert.Equal(t, []types.AgentType{types.AGENT_AUTO}, types.NewBarrierBlockedAgentTypes(types.BARRIER_GATE, "yes", "no", "", "", ""), "Tag 'motorcar' should override 'motor_vehicle'")
+}
+
+func TestBarrierModes(t *testing.T) {
+	// Two-way road 1-2-3 along the equator with bollard at node 2
+	prepareData := func() (map[osm.NodeID]*wrappers.NodeOSM, []*wrappers.WayOSM) {
+		nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+		for i := 1; i <= 3; i++ {
+			nodeID := osm.NodeID(i)
+			nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: float64(i) * 0.001, Lat: 0}, UseCount: 1, IsCrossing: i != 2}
+		}
+		nodesSet[2].Barrier = types.BARRIER_BOLLARD
+		nodesSet[2].BarrierBlockedAgentTypes = []types.AgentType{types.AGENT_AUTO}
+		way := &wrappers.WayOSM{
+			ID:                1,
+			Nodes:             []osm.NodeID{1, 2, 3},
+			FreeSpeed:         -1,
+			Capacity:          -1,
+			LinkType:          types.LINK_RESIDENTIAL,
+			LinkClass:         types.LINK_CLASS_HIGHWAY,
+			AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO, types.AGENT_BIKE, types.AGENT_WALK},
+		}
+		return nodesSet, []*wrappers.WayOSM{way}
+	}
+
+	nodesSet, ways := prepareData()
+	preparedNodes, err := prepareNodes(nodesSet, BARRIER_MODE_RESTRICT_LINK)
+	assert.NoError(t, err)
+	assert.False(t, preparedNodes[2].IsCrossing, "Barrier should not split the link")
+	net, err := macro.NewNetFromOSM(ways, preparedNodes)
+	assert.NoError(t, err)
+	assert.Len(t, net.Links, 2, "Road should be single link in both directions")
+	for _, link := range net.Links {
+		assert.Equal(t, []types.AgentType{types.AGENT_BIKE, types.AGENT_WALK}, link.GetAllowedAgentTypes(), "Blocked agent should be removed from the whole link")
+	}
+
+	nodesSet, ways = prepareData()
+	preparedNodes, err = prepareNodes(nodesSet, BARRIER_MODE_SPLIT_LINK)
+	assert.NoError(t, err)
+	assert.True(t, preparedNodes[2].IsCrossing, "Barrier should split the link")
+	assert.True(t, preparedNodes[2].IsBarrierSplit, "Split should be marked for QA")
+	net, err = macro.NewNetFromOSM(ways, preparedNodes)
+	assert.NoError(t, err)
+	assert.Len(t, net.Links, 4, "Road should be splitted at the barrier")
+	for _, link := range net.Links {
+		assert.Len(t, link.GetAllowedAgentTypes(), 3, "Links around the barrier should not be restricted")
+	}
+	movements, err := net.GenerateMovements()
+	assert.NoError(t, err)
+	throughBarrier := 0
+	for _, mvmt := range movements.List() {
+		if !net.Nodes[mvmt.MacroNodeID].GetGeom().Equal(orb.Point{0.002, 0}) {
+			continue
+		}
+		throughBarrier++
+		assert.Equal(t, []types.AgentType{types.AGENT_BIKE, types.AGENT_WALK}, mvmt.AllowedAgentTypes(), "Blocked agent should be removed from the movement through the barrier")
+	}
+	assert.Equal(t, 2, throughBarrier, "Wrong number of movements through the barrier")
+}
@@ -0,0 +1,45 @@
+package osm2gmns
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersectionsConsolidationOption(t *testing.T) {
+	// Road 1-2-3-4 along the equator with side roads at nodes 2 and 3 which are ~11 meters apart
+	prepareData := func(options ...func(*Parser)) *OSMWaysNodes {
+		points := map[osm.NodeID][2]float64{
+			1: {0, 0}, 2: {0.001, 0}, 3: {0.0011, 0}, 4: {0.002, 0}, 5: {0.001, 0.001}, 6: {0.0011, -0.001},
+		}
+		nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+		for nodeID, pt := range points {
+			nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}}
+		}
+		newWay := func(id osm.WayID, nodes ...osm.NodeID) *wrappers.WayOSM {
+			for _, nodeID := range nodes {
+				nodesSet[nodeID].UseCount++
+			}
+			return &wrappers.WayOSM{ID: id, Nodes: nodes, WayType: wrappers.WAY_TYPE_HIGHWAY, Tags: wrappers.WayTags{Highway: "residential"}, FreeSpeed: -1, Capacity: -1}
+		}
+		parser := NewParser("", options...)
+		return &OSMWaysNodes{
+			ways:              []*wrappers.WayOSM{newWay(1, 1, 2, 3, 4), newWay(2, 2, 5), newWay(3, 3, 6)},
+			nodes:             nodesSet,
+			allowedAgentTypes: []types.AgentType{types.AGENT_AUTO},
+			consolidation:     parser.consolidation,
+		}
+	}
+
+	macroNet, err := prepareData().GenerateMacroscopic(false)
+	assert.NoError(t, err)
+	assert.Len(t, macroNet.Nodes, 6, "Intersections should not be consolidated by default")
+
+	macroNet, err = prepareData(WithIntersectionsConsolidation(macro.WithCollapse(true))).GenerateMacroscopic(false)
+	assert.NoError(t, err)
+	assert.Len(t, macroNet.Nodes, 5, "Close intersections should be collapsed into the single node")
+}
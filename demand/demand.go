@@ -0,0 +1,15 @@
+package demand
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+)
+
+// OD is the number of trips between two zones
+type OD struct {
+	Origin      gmns.ZoneID
+	Destination gmns.ZoneID
+	Volume      float64
+}
+
+// Demand is the origin-destination matrix in the sparse form
+type Demand []OD
@@ -0,0 +1,49 @@
+package demand
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/poi"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGravity(t *testing.T) {
+	// Three zones along the equator: 0 and 1 are close to each other, 2 is far away
+	square := func(minLon float64) orb.MultiPolygon {
+		return orb.MultiPolygon{orb.Bound{Min: orb.Point{minLon, 0}, Max: orb.Point{minLon + 0.01, 0.01}}.ToPolygon()}
+	}
+	zones := zone.Zones{zone.NewZone(0, square(0)), zone.NewZone(1, square(0.01)), zone.NewZone(2, square(0.05))}
+
+	// ~100 square meters residential building in zone 0 and two ~100 square meters offices in zones 1 and 2
+	building := func(id int, lon float64, category string) *poi.POI {
+		geom := orb.Polygon{orb.Bound{Min: orb.Point{lon, 0.005}, Max: orb.Point{lon + 0.0000898, 0.0050898}}.ToPolygon()[0]}
+		return poi.NewPOI(id, 0, geom, poi.WithTags(category, "", ""))
+	}
+	pois := poi.POIs{building(0, 0.005, "residential"), building(1, 0.015, "office"), building(2, 0.055, "office")}
+	trips := GenerateTrips(pois, zones, NewTripRatesDefault(WithTripRate("office", 0, 1)))
+	assert.Len(t, trips, 3, "Every zone should have trips")
+	assert.InDelta(t, 1.0, trips[0].Production, 0.01, "Wrong production of residential zone")
+	assert.InDelta(t, 1.0, trips[1].Attraction, 0.01, "Wrong attraction of office zone")
+	assert.Equal(t, 0.0, trips[2].Production, "Office zone should not produce trips")
+
+	demand := Gravity(trips, StraightLineDistance(zones))
+	volumes := make(map[[2]gmns.ZoneID]float64)
+	total := 0.0
+	for _, od := range demand {
+		volumes[[2]gmns.ZoneID{od.Origin, od.Destination}] = od.Volume
+		total += od.Volume
+	}
+	assert.InDelta(t, trips[0].Production+trips[1].Production, total, 1e-9, "Productions should be distributed completely")
+	assert.Greater(t, volumes[[2]gmns.ZoneID{0, 1}], volumes[[2]gmns.ZoneID{0, 2}], "Closer zone should attract more trips")
+	assert.Zero(t, volumes[[2]gmns.ZoneID{0, 0}], "Intrazonal trips should be omitted")
+
+	demand = Gravity(trips, StraightLineDistance(zones), WithFurnessIterations(50))
+	attracted := make(map[gmns.ZoneID]float64)
+	for _, od := range demand {
+		attracted[od.Destination] += od.Volume
+	}
+	assert.InDelta(t, attracted[1], attracted[2], 0.01, "Balanced matrix should match equal attractions")
+}
@@ -0,0 +1,63 @@
+package demand
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/routing"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb/geo"
+)
+
+// DistanceFunc returns distance (meters) between zones. Negative value means that zones are not connected
+type DistanceFunc func(origin, destination gmns.ZoneID) float64
+
+// StraightLineDistance returns distance function which evaluates great-circle distance between zones' centroids
+func StraightLineDistance(zones zone.Zones) DistanceFunc {
+	centroids := make(map[gmns.ZoneID]*zone.Zone, len(zones))
+	for _, zn := range zones {
+		centroids[zn.ID] = zn
+	}
+	return func(origin, destination gmns.ZoneID) float64 {
+		from, okFrom := centroids[origin]
+		to, okTo := centroids[destination]
+		if !okFrom || !okTo {
+			return -1
+		}
+		return geo.Distance(from.GetCentroid(), to.GetCentroid())
+	}
+}
+
+// NetworkDistance returns distance function which uses the shortest path length between centroid nodes of zones.
+// Zones without centroid nodes are not connected with any other zone. Paths do not pass through other centroids.
+// Routes are searched by routing.Router: options could set agent type or movement prohibitions, default cost is the length of links
+func NetworkDistance(net *macro.Net, zones zone.Zones, options ...func(*routing.RouterConfig)) DistanceFunc {
+	router := routing.NewRouter(net, append([]func(*routing.RouterConfig){routing.WithCostType(routing.COST_LENGTH)}, options...)...)
+	distances := make(map[gmns.ZoneID]map[gmns.ZoneID]float64, len(zones))
+	zonesByCentroid := make(map[gmns.NodeID]gmns.ZoneID, len(zones))
+	for _, zn := range zones {
+		if zn.GetCentroidNodeID() >= 0 {
+			zonesByCentroid[zn.GetCentroidNodeID()] = zn.ID
+		}
+	}
+	for _, zn := range zones {
+		if zn.GetCentroidNodeID() < 0 {
+			continue
+		}
+		costs, err := router.ShortestCosts(zn.GetCentroidNodeID())
+		if err != nil {
+			continue
+		}
+		distances[zn.ID] = make(map[gmns.ZoneID]float64)
+		for nodeID, distance := range costs {
+			if zoneID, ok := zonesByCentroid[nodeID]; ok {
+				distances[zn.ID][zoneID] = distance
+			}
+		}
+	}
+	return func(origin, destination gmns.ZoneID) float64 {
+		if distance, ok := distances[origin][destination]; ok {
+			return distance
+		}
+		return -1
+	}
+}
@@ -0,0 +1,47 @@
+package demand
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/routing"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkDistance(t *testing.T) {
+	// Two-way road along the equator which passes two grid cells ~111 meters wide
+	points := map[osm.NodeID]orb.Point{1: {0.0001, 0.0005}, 2: {0.001, 0.0005}, 3: {0.0019, 0.0005}}
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM, len(points))
+	for nodeID, pt := range points {
+		nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}, IsCrossing: true}
+	}
+	ways := []*wrappers.WayOSM{{
+		ID:                1,
+		Nodes:             []osm.NodeID{1, 2, 3},
+		FreeSpeed:         -1,
+		LinkType:          types.LINK_PRIMARY,
+		LinkClass:         types.LINK_CLASS_HIGHWAY,
+		AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO},
+	}}
+	net, err := macro.NewNetFromOSM(ways, nodesSet)
+	assert.NoError(t, err)
+	zones := zone.NewGrid(orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{0.002, 0.001}}, 111.32)
+	_, err = net.AttachZones(zones, macro.WithConnectorsNum(1))
+	assert.NoError(t, err)
+
+	distance := NetworkDistance(net, zones)
+	straight := StraightLineDistance(zones)
+	origin, destination := zones[0].ID, zones[1].ID
+	assert.Greater(t, distance(origin, destination), 0.0, "Zones should be connected")
+	assert.GreaterOrEqual(t, distance(origin, destination), straight(origin, destination), "Network distance should not be shorter than straight line")
+	assert.InDelta(t, distance(origin, destination), distance(destination, origin), 1e-6, "Two-way road should give symmetric distances")
+
+	// Road does not allow bicycles, so zones are connected for cars only
+	distance = NetworkDistance(net, zones, routing.WithAgentType(types.AGENT_BIKE))
+	assert.Equal(t, -1.0, distance(origin, destination), "Zones should not be connected for bicycles")
+}
@@ -0,0 +1,163 @@
+package demand
+
+import (
+	"math"
+)
+
+// DeterrenceType defines how the attractiveness of destination decreases with the distance
+type DeterrenceType uint16
+
+const (
+	// f(d) = d^(-beta)
+	DETERRENCE_POWER = DeterrenceType(iota)
+	// f(d) = exp(-beta * d)
+	DETERRENCE_EXPONENTIAL
+)
+
+func (iotaIdx DeterrenceType) String() string {
+	return [...]string{"power", "exponential"}[iotaIdx]
+}
+
+const (
+	betaDefault = 2.0
+	// Distances (kilometers) are clamped to this value to keep the power deterrence finite
+	minDistanceKm = 0.1
+)
+
+// GravityConfig is the set of parameters for the gravity model
+type GravityConfig struct {
+	deterrence DeterrenceType
+	// Deterrence parameter. Distances are in kilometers
+	beta float64
+	// Allow trips inside the zone
+	intrazonal bool
+	// Number of Furness (balancing) iterations. Zero value means production-constrained model
+	furnessIterations int
+}
+
+// NewGravityConfigDefault returns default parameters for the gravity model: power deterrence with beta = 2, no intrazonal trips, production-constrained
+func NewGravityConfigDefault() *GravityConfig {
+	return &GravityConfig{
+		deterrence:        DETERRENCE_POWER,
+		beta:              betaDefault,
+		intrazonal:        false,
+		furnessIterations: 0,
+	}
+}
+
+// WithDeterrence sets deterrence function and its parameter (distances are in kilometers)
+func WithDeterrence(deterrence DeterrenceType, beta float64) func(*GravityConfig) {
+	return func(cfg *GravityConfig) {
+		cfg.deterrence = deterrence
+		cfg.beta = beta
+	}
+}
+
+// WithIntrazonal sets whether trips inside the zone are allowed
+func WithIntrazonal(intrazonal bool) func(*GravityConfig) {
+	return func(cfg *GravityConfig) {
+		cfg.intrazonal = intrazonal
+	}
+}
+
+// WithFurnessIterations sets number of Furness iterations to match both productions and attractions (doubly-constrained model)
+func WithFurnessIterations(furnessIterations int) func(*GravityConfig) {
+	return func(cfg *GravityConfig) {
+		cfg.furnessIterations = furnessIterations
+	}
+}
+
+func (cfg *GravityConfig) deterrenceValue(distanceMeters float64) float64 {
+	if distanceMeters < 0 {
+		// Zones are not connected
+		return 0
+	}
+	distanceKm := max(distanceMeters/1000.0, minDistanceKm)
+	switch cfg.deterrence {
+	case DETERRENCE_EXPONENTIAL:
+		return math.Exp(-cfg.beta * distanceKm)
+	default:
+		return math.Pow(distanceKm, -cfg.beta)
+	}
+}
+
+// Gravity distributes produced trips between zones proportionally to attractions weighted by deterrence of the distance:
+// T(i,j) = P(i) * A(j) * f(d(i,j)) / sum_k(A(k) * f(d(i,k))).
+// Zero volumes are omitted from the result
+func Gravity(trips []ZoneTrips, distance DistanceFunc, options ...func(*GravityConfig)) Demand {
+	cfg := NewGravityConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	n := len(trips)
+	matrix := make([][]float64, n)
+	for i := range trips {
+		matrix[i] = make([]float64, n)
+		total := 0.0
+		for j := range trips {
+			if i == j && !cfg.intrazonal {
+				continue
+			}
+			matrix[i][j] = trips[j].Attraction * cfg.deterrenceValue(distance(trips[i].ZoneID, trips[j].ZoneID))
+			total += matrix[i][j]
+		}
+		for j := range trips {
+			if total > 0 {
+				matrix[i][j] *= trips[i].Production / total
+			} else {
+				matrix[i][j] = 0
+			}
+		}
+	}
+	balance(matrix, trips, cfg.furnessIterations)
+
+	demand := Demand{}
+	for i := range trips {
+		for j := range trips {
+			if matrix[i][j] > 0 {
+				demand = append(demand, OD{Origin: trips[i].ZoneID, Destination: trips[j].ZoneID, Volume: matrix[i][j]})
+			}
+		}
+	}
+	return demand
+}
+
+// balance scales columns and rows of the matrix alternately to match attractions (normalized to total production) and productions
+func balance(matrix [][]float64, trips []ZoneTrips, iterations int) {
+	totalProduction, totalAttraction := 0.0, 0.0
+	for _, zoneTrips := range trips {
+		totalProduction += zoneTrips.Production
+		totalAttraction += zoneTrips.Attraction
+	}
+	if totalAttraction <= 0 {
+		return
+	}
+	for iteration := 0; iteration < iterations; iteration++ {
+		for j := range trips {
+			column := 0.0
+			for i := range trips {
+				column += matrix[i][j]
+			}
+			if column <= 0 {
+				continue
+			}
+			factor := trips[j].Attraction * totalProduction / totalAttraction / column
+			for i := range trips {
+				matrix[i][j] *= factor
+			}
+		}
+		for i := range trips {
+			row := 0.0
+			for j := range trips {
+				row += matrix[i][j]
+			}
+			if row <= 0 {
+				continue
+			}
+			factor := trips[i].Production / row
+			for j := range trips {
+				matrix[i][j] *= factor
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package demand
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExportToCSV writes OD matrix (GMNS demand.csv) to the file with `_demand.csv` suffix
+func (demand Demand) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameDemand := fmt.Sprintf(fnameParts[0] + "_demand.csv")
+
+	file, err := os.Create(fnameDemand)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"o_zone_id", "d_zone_id", "volume"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, od := range demand {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", od.Origin),
+			fmt.Sprintf("%d", od.Destination),
+			fmt.Sprintf("%f", od.Volume),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write OD pair")
+		}
+	}
+	return nil
+}
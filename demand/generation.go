@@ -0,0 +1,40 @@
+package demand
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/poi"
+	"github.com/LdDl/osm2gmns/zone"
+)
+
+// ZoneTrips is the number of trips produced and attracted by the zone
+type ZoneTrips struct {
+	ZoneID     gmns.ZoneID
+	Production float64
+	Attraction float64
+}
+
+// GenerateTrips evaluates productions and attractions of every zone from POIs inside of it (by the POI centroid).
+// Default trip rates are used if rates are not provided. Result follows the order of zones
+func GenerateTrips(pois poi.POIs, zones zone.Zones, rates *TripRates) []ZoneTrips {
+	if rates == nil {
+		rates = NewTripRatesDefault()
+	}
+	trips := make([]ZoneTrips, len(zones))
+	zonesIdx := make(map[gmns.ZoneID]int, len(zones))
+	for idx, zn := range zones {
+		trips[idx].ZoneID = zn.ID
+		zonesIdx[zn.ID] = idx
+	}
+	for _, item := range pois {
+		zn := zones.Find(item.GetCentroid())
+		if zn == nil {
+			continue
+		}
+		rate := rates.Rate(item.Category())
+		units := item.GetAreaSqMeters() / rateAreaUnit
+		idx := zonesIdx[zn.ID]
+		trips[idx].Production += rate.Production * units
+		trips[idx].Attraction += rate.Attraction * units
+	}
+	return trips
+}
@@ -0,0 +1,82 @@
+package demand
+
+const (
+	// Area (square meters) which trip rates are given for
+	rateAreaUnit = 100.0
+)
+
+// TripRate is the number of produced and attracted trips per 100 square meters of POI area
+type TripRate struct {
+	Production float64
+	Attraction float64
+}
+
+// TripRates is the table of trip rates by POI category (value of `building`, `amenity` or `leisure` tag)
+type TripRates struct {
+	rates map[string]TripRate
+	// Rate for categories which are not in the table
+	fallback TripRate
+}
+
+// NewTripRatesDefault returns trip rates table with the rough rates for common categories: residential POIs mostly produce trips, the rest mostly attract them
+// Options could be used to override or extend the table
+func NewTripRatesDefault(options ...func(*TripRates)) *TripRates {
+	residential := TripRate{Production: 1.0, Attraction: 0.2}
+	commercial := TripRate{Production: 0.3, Attraction: 1.5}
+	education := TripRate{Production: 0.1, Attraction: 1.0}
+	industrial := TripRate{Production: 0.2, Attraction: 0.6}
+	leisure := TripRate{Production: 0.1, Attraction: 0.8}
+	rates := &TripRates{
+		rates: map[string]TripRate{
+			"residential":   residential,
+			"apartments":    residential,
+			"house":         residential,
+			"detached":      residential,
+			"terrace":       residential,
+			"dormitory":     residential,
+			"commercial":    commercial,
+			"retail":        commercial,
+			"supermarket":   commercial,
+			"office":        commercial,
+			"marketplace":   commercial,
+			"restaurant":    commercial,
+			"hospital":      commercial,
+			"school":        education,
+			"kindergarten":  education,
+			"college":       education,
+			"university":    education,
+			"industrial":    industrial,
+			"warehouse":     industrial,
+			"park":          leisure,
+			"sports_centre": leisure,
+			"stadium":       leisure,
+		},
+		fallback: TripRate{Production: 0.1, Attraction: 0.1},
+	}
+	for _, option := range options {
+		option(rates)
+	}
+	return rates
+}
+
+// WithTripRate sets trip rate for given POI category
+func WithTripRate(category string, production, attraction float64) func(*TripRates) {
+	return func(rates *TripRates) {
+		rates.rates[category] = TripRate{Production: production, Attraction: attraction}
+	}
+}
+
+// WithFallbackTripRate sets trip rate for categories which are not in the table
+func WithFallbackTripRate(production, attraction float64) func(*TripRates) {
+	return func(rates *TripRates) {
+		rates.fallback = TripRate{Production: production, Attraction: attraction}
+	}
+}
+
+// Rate returns trip rate for given POI category
+func (rates *TripRates) Rate(category string) TripRate {
+	if rate, ok := rates.rates[category]; ok {
+		return rate
+	}
+	return rates.fallback
+}
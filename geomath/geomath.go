@@ -51,6 +51,12 @@ func LineToSpherical(line orb.LineString) orb.LineString {
 	return newLine
 }
 
+// EuclideanScale returns number of EPSG:3857 units in one meter at given latitude (degrees).
+// Web Mercator stretches distances by 1/cos(latitude), so distances in meters should be scaled before being used in Euclidean space
+func EuclideanScale(lat float64) float64 {
+	return 1.0 / math.Cos(lat*math.Pi/180.0)
+}
+
 // AngleBetweenLines returs angle between two lines
 //
 // Note: panics if number of points in any line is less than 2
@@ -69,12 +75,15 @@ func AngleBetweenLines(l1 orb.LineString, l2 orb.LineString) float64 {
 
 // Returns a line segment between specified distances along the given line
 // using DistanceHaversine for more accurate results
-// @TODO: Handle edge-cases such as:
-// 1. negative values for distances
-// 2. startDist > endDist
-// 3. startDist > totalLengthMeters
-// 4. endDist > totalLengthMeters
+// Distances are clamped to [0; line length]. If startDist > endDist then they are swapped
 func SubstringHaversine(line orb.LineString, startDist float64, endDist float64) orb.LineString {
+	if startDist > endDist {
+		startDist, endDist = endDist, startDist
+	}
+	lineLengthMeters := geo.LengthHaversine(line)
+	startDist = math.Min(math.Max(startDist, 0), lineLengthMeters)
+	endDist = math.Min(math.Max(endDist, 0), lineLengthMeters)
+
 	var substring orb.LineString
 	totalLengthMeters := 0.0
 	for i := 1; i < len(line); i++ {
@@ -152,7 +161,10 @@ func intersection(p1, p2, p3, p4 orb.Point) (orb.Point, error) {
 	return orb.Point{x, y}, nil
 }
 
-func offsetCurve(line orb.LineString, distance float64) orb.LineString {
+// OffsetCurve returns line which is parallel to the given one at the specified distance
+// Positive distance is for the left side, negative distance is for the right side
+// Note: Euclidean space
+func OffsetCurve(line orb.LineString, distance float64) orb.LineString {
 	// Initialize result list and segment list
 	var result orb.LineString
 	var segments [][2]orb.Point
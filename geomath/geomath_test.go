@@ -8,6 +8,7 @@ import (
 
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,8 +24,8 @@ func TestOffset(t *testing.T) {
 	line := orb.LineString{{10.0, 10.0}, {15.0, 10.0}, {18.0, 15.0}, {18.0, 20.0}, {15.0, 24.0}, {12.0, 24.0}, {10.0, 18.0}, {10.0, 15.0}, {13.0, 12.0}, {15.0, 16.0}}
 	distance := 1.0
 
-	leftL := lineAsString(offsetCurve(line, distance))
-	rightL := lineAsString(offsetCurve(line, -distance))
+	leftL := lineAsString(OffsetCurve(line, distance))
+	rightL := lineAsString(OffsetCurve(line, -distance))
 
 	correctLeft := "[[10.000000, 11.000000],[14.433810, 11.000000],[17.000000, 15.276984],[17.000000, 19.666667],[14.500000, 23.000000],[12.720759, 23.000000],[11.000000, 17.837722],[11.000000, 15.414214],[12.726049, 13.688165],[14.105573, 16.447214]]"
 	if leftL != correctLeft {
@@ -102,3 +103,32 @@ func TestEPSGConverter(t *testing.T) {
 		assert.InDelta(t, givenLine4326[i][1], pt[1], precision, fmt.Sprintf("Wrong latitude (Y) in EPSG:4326 at pos #%d", i))
 	}
 }
+
+func TestLineSubstringClamp(t *testing.T) {
+	line := orb.LineString{{37.56319128200903, 55.78357465483572}, {37.565235359279626, 55.78497472894253}, {37.565822487858156, 55.785421030200496}}
+	lengthMeters := geo.LengthHaversine(line)
+
+	// Distances out of range
+	newline := SubstringHaversine(line, -10, lengthMeters+10)
+	assert.Equal(t, len(line), len(newline), "Whole line should be returned")
+	assert.InDelta(t, lengthMeters, geo.LengthHaversine(newline), 10e-3, "Whole line should be returned")
+
+	// Swapped distances
+	newline = SubstringHaversine(line, 100, 50)
+	assert.InDelta(t, 50.0, geo.LengthHaversine(newline), 10e-3, "Distances should be swapped")
+
+	// Start beyond the line
+	newline = SubstringHaversine(line, lengthMeters+10, lengthMeters+20)
+	assert.Equal(t, 2, len(newline), "Degenerated line should be returned")
+	assert.InDelta(t, 0.0, geo.LengthHaversine(newline), 10e-3, "Degenerated line should be returned")
+}
+
+func TestEuclideanScale(t *testing.T) {
+	// ~100 meters along the meridian at different latitudes
+	for _, lat := range []float64{0, 45, 60} {
+		start := orb.Point{37.0, lat}
+		end := geo.PointAtBearingAndDistance(start, 0, 100)
+		euclidean := findDist(PointToEuclidean(start), PointToEuclidean(end))
+		assert.InDelta(t, euclidean, 100*EuclideanScale(lat), 0.1, "Wrong scale at latitude %f", lat)
+	}
+}
@@ -0,0 +1,3 @@
+package gmns
+
+type ZoneID int
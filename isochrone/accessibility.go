@@ -0,0 +1,86 @@
+package isochrone
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/poi"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/utils"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/pkg/errors"
+)
+
+// Accessibility is the number of POIs which are reachable from the centroid of the zone within the time budget
+type Accessibility struct {
+	ZoneID    gmns.ZoneID
+	AgentType types.AgentType
+	// Time budget (seconds)
+	Budget  float64
+	POIsNum int
+	// Number of reachable POIs by category (see poi.POI.Category)
+	Categories map[string]int
+}
+
+// Accessibilities is the accessibility of every zone
+type Accessibilities []*Accessibility
+
+// EvaluateAccessibility counts POIs which are reachable from every zone within given time budget (seconds).
+// POIs are the ones prepared during macroscopic network generation (see osm2gmns.WithPreparePOI and OSMWaysNodes.POIs).
+// Every POI is snapped to the nearest node (by its centroid) which is accessible for the agent type; POIs which are too far from the network are skipped.
+// Result has an entry per every zone which is selected by macro.Net.AttachedZones in the same order
+func EvaluateAccessibility(net *macro.Net, zones zone.Zones, pois poi.POIs, budget float64, options ...func(*IsochroneConfig)) (Accessibilities, error) {
+	cfg := NewIsochroneConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+
+	attached, err := net.AttachedZones(zones)
+	if err != nil {
+		return nil, err
+	}
+
+	index := newNodeIndex(net, cfg.agentType)
+	nodesPOIs := make(map[gmns.NodeID][]*poi.POI)
+	for _, item := range pois {
+		node, ok := index.nearest(item.GetCentroid(), cfg.maxSnapDistance)
+		if !ok {
+			continue
+		}
+		nodesPOIs[node.ID] = append(nodesPOIs[node.ID], item)
+	}
+
+	router := cfg.newRouter(net, BUDGET_TIME)
+	accessibilities := make(Accessibilities, len(attached))
+	zonesErrors := make([]error, len(attached))
+	utils.RunWorkers(len(attached), cfg.workers, func(jobs <-chan int) {
+		for idx := range jobs {
+			accessibility := &Accessibility{
+				ZoneID:     attached[idx].ID,
+				AgentType:  cfg.agentType,
+				Budget:     budget,
+				Categories: make(map[string]int),
+			}
+			accessibilities[idx] = accessibility
+			tree, err := router.ShortestTreeWithin(attached[idx].GetCentroidNodeID(), budget)
+			if err != nil {
+				zonesErrors[idx] = errors.Wrapf(err, "Can't evaluate accessibility of zone with ID: '%d'", attached[idx].ID)
+				continue
+			}
+			for nodeID, cost := range tree.Costs {
+				if cost > budget {
+					continue
+				}
+				for _, item := range nodesPOIs[nodeID] {
+					accessibility.POIsNum++
+					accessibility.Categories[item.Category()]++
+				}
+			}
+		}
+	})
+	for _, err := range zonesErrors {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accessibilities, nil
+}
@@ -0,0 +1,85 @@
+package isochrone
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/pkg/errors"
+)
+
+// ExportToGeoJSON writes polygons of isochrones to the GeoJSON file as feature collection.
+// Properties of every feature are source node, agent type, budget and identifiers of reachable nodes and links
+func (isochrones Isochrones) ExportToGeoJSON(fname string) error {
+	collection := geojson.NewFeatureCollection()
+	for _, isochrone := range isochrones {
+		feature := geojson.NewFeature(isochrone.Geom)
+		linksIDs := make([]int, len(isochrone.Links))
+		for i, reached := range isochrone.Links {
+			linksIDs[i] = int(reached.LinkID)
+		}
+		nodesIDs := make([]int, len(isochrone.Nodes))
+		for i, nodeID := range isochrone.Nodes {
+			nodesIDs[i] = int(nodeID)
+		}
+		feature.Properties["source_node"] = isochrone.SourceNodeID
+		feature.Properties["agent_type"] = isochrone.AgentType.String()
+		feature.Properties["budget_type"] = isochrone.BudgetType.String()
+		feature.Properties["budget"] = isochrone.Budget
+		feature.Properties["nodes"] = nodesIDs
+		feature.Properties["links"] = linksIDs
+		collection.Append(feature)
+	}
+	data, err := collection.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err, "Can't marshal isochrones")
+	}
+	err = os.WriteFile(fname, data, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Can't write file")
+	}
+	return nil
+}
+
+// ExportToCSV writes accessibility of zones to the file with `_accessibility.csv` suffix.
+// Categories are written as comma-separated `category:number` pairs
+func (accessibilities Accessibilities) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameAccessibility := fmt.Sprintf(fnameParts[0] + "_accessibility.csv")
+
+	file, err := os.Create(fnameAccessibility)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"zone_id", "agent_type", "budget", "pois_num", "categories"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, accessibility := range accessibilities {
+		categories := make([]string, 0, len(accessibility.Categories))
+		for category, num := range accessibility.Categories {
+			categories = append(categories, fmt.Sprintf("%s:%d", category, num))
+		}
+		sort.Strings(categories)
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", accessibility.ZoneID),
+			accessibility.AgentType.String(),
+			fmt.Sprintf("%f", accessibility.Budget),
+			fmt.Sprintf("%d", accessibility.POIsNum),
+			strings.Join(categories, ","),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write accessibility")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package isochrone
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/routing"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoBudgets     = fmt.Errorf("No budgets provided")
+	ErrNoNearestNode = fmt.Errorf("No node of the network near the point")
+)
+
+const (
+	bufferDefault          = 50.0
+	maxSnapDistanceDefault = 500.0
+)
+
+// BudgetType defines what is limited by the budget of isochrone
+type BudgetType uint16
+
+const (
+	// Free-flow travel time (seconds)
+	BUDGET_TIME = BudgetType(iota)
+	// Length (meters) of the route
+	BUDGET_DISTANCE
+)
+
+func (iotaIdx BudgetType) String() string {
+	return [...]string{"time", "distance"}[iotaIdx]
+}
+
+// IsochroneConfig is the set of parameters for isochrones and accessibility evaluation
+type IsochroneConfig struct {
+	agentType types.AgentType
+	// Max speed (km/h) of the agent. Non-positive value means default speed of the agent type
+	agentSpeed float64
+	// Movements which are allowed at nodes. Nil value means that every turn is allowed
+	movements *movement.MovementsStorage
+	// Buffer (meters) around reachable links which forms polygon of isochrone
+	buffer float64
+	// Max distance (meters) between the point and the nearest node of the network
+	maxSnapDistance float64
+	// Number of parallel workers. Non-positive value means number of CPUs
+	workers int
+}
+
+// NewIsochroneConfigDefault returns default parameters: AGENT_AUTO with default speed, no movement prohibitions, 50 meters buffer,
+// 500 meters max distance to the nearest node, all CPUs
+func NewIsochroneConfigDefault() *IsochroneConfig {
+	return &IsochroneConfig{
+		agentType:       types.AGENT_AUTO,
+		agentSpeed:      -1,
+		buffer:          bufferDefault,
+		maxSnapDistance: maxSnapDistanceDefault,
+		workers:         0,
+	}
+}
+
+// WithAgentType sets agent type which isochrones are evaluated for
+func WithAgentType(agentType types.AgentType) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.agentType = agentType
+	}
+}
+
+// WithAgentSpeed sets max speed (km/h) of the agent. Non-positive value means default speed of the agent type (see types.NewAgentSpeedDefault)
+func WithAgentSpeed(speed float64) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.agentSpeed = speed
+	}
+}
+
+// WithMovements sets movements which are allowed at nodes, so reachable area does not extend through prohibited turns (see routing.WithMovements)
+func WithMovements(movements *movement.MovementsStorage) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.movements = movements
+	}
+}
+
+// WithBuffer sets buffer (meters) around reachable links which forms polygon of isochrone
+func WithBuffer(buffer float64) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.buffer = buffer
+	}
+}
+
+// WithMaxSnapDistance sets max distance (meters) between the point (or POI) and the nearest node of the network
+func WithMaxSnapDistance(maxSnapDistance float64) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.maxSnapDistance = maxSnapDistance
+	}
+}
+
+// WithWorkers sets number of workers which evaluate accessibility of zones in parallel (see EvaluateAccessibility). Non-positive value means number of CPUs
+func WithWorkers(workers int) func(*IsochroneConfig) {
+	return func(cfg *IsochroneConfig) {
+		cfg.workers = workers
+	}
+}
+
+// newRouter prepares router which minimizes cost of given budget type
+func (cfg *IsochroneConfig) newRouter(net *macro.Net, budgetType BudgetType) *routing.Router {
+	options := []func(*routing.RouterConfig){routing.WithAgentType(cfg.agentType)}
+	switch budgetType {
+	case BUDGET_DISTANCE:
+		options = append(options, routing.WithCostType(routing.COST_LENGTH))
+	default:
+		agentSpeed := cfg.agentSpeed
+		if agentSpeed <= 0 {
+			agentSpeed = types.NewAgentSpeedDefault(cfg.agentType)
+		}
+		options = append(options, routing.WithCustomCost(routing.AgentFreeFlowTime(agentSpeed), nil))
+	}
+	if cfg.movements != nil {
+		options = append(options, routing.WithMovements(cfg.movements))
+	}
+	return routing.NewRouter(net, options...)
+}
+
+// ReachedLink is the link which is reachable within the budget
+type ReachedLink struct {
+	LinkID gmns.LinkID
+	// Part of the link (from its start) which is reachable. It is 1 for fully reachable links
+	Fraction float64
+	// Geometry (EPSG:4326) of the reachable part
+	Geom orb.LineString
+}
+
+// Isochrone is the area which is reachable from the source node within the budget
+type Isochrone struct {
+	SourceNodeID gmns.NodeID
+	AgentType    types.AgentType
+	BudgetType   BudgetType
+	Budget       float64
+	// Reachable nodes and links ordered by identifiers
+	Nodes []gmns.NodeID
+	Links []ReachedLink
+	// Reachable links buffered with distance from the config (EPSG:4326)
+	Geom orb.MultiPolygon
+}
+
+// Isochrones is the set of isochrones
+type Isochrones []*Isochrone
+
+// FromNode evaluates isochrone for every budget (seconds for BUDGET_TIME, meters for BUDGET_DISTANCE) from given node.
+// Single search is done for the greatest budget. Result follows the order of budgets
+func FromNode(net *macro.Net, sourceNodeID gmns.NodeID, budgetType BudgetType, budgets []float64, options ...func(*IsochroneConfig)) (Isochrones, error) {
+	cfg := NewIsochroneConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	return fromNode(net, cfg, sourceNodeID, budgetType, budgets)
+}
+
+// FromPoint evaluates isochrones (see FromNode) from the nearest node of the network which is accessible for the agent type
+func FromPoint(net *macro.Net, pt orb.Point, budgetType BudgetType, budgets []float64, options ...func(*IsochroneConfig)) (Isochrones, error) {
+	cfg := NewIsochroneConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	node, ok := newNodeIndex(net, cfg.agentType).nearest(pt, cfg.maxSnapDistance)
+	if !ok {
+		return nil, errors.Wrapf(ErrNoNearestNode, "Point %v", pt)
+	}
+	return fromNode(net, cfg, node.ID, budgetType, budgets)
+}
+
+// fromNode evaluates isochrones for prepared config. Reachable parts of links are cut for every budget from the single limited tree
+func fromNode(net *macro.Net, cfg *IsochroneConfig, sourceNodeID gmns.NodeID, budgetType BudgetType, budgets []float64) (Isochrones, error) {
+	if len(budgets) == 0 {
+		return nil, ErrNoBudgets
+	}
+	router := cfg.newRouter(net, budgetType)
+	tree, err := router.ShortestTreeWithin(sourceNodeID, slices.Max(budgets))
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't search for reachable nodes")
+	}
+	isochrones := make(Isochrones, 0, len(budgets))
+	for _, budget := range budgets {
+		isochrone := &Isochrone{
+			SourceNodeID: sourceNodeID,
+			AgentType:    cfg.agentType,
+			BudgetType:   budgetType,
+			Budget:       budget,
+			Nodes:        make([]gmns.NodeID, 0),
+			Links:        make([]ReachedLink, 0),
+		}
+		for nodeID, cost := range tree.Costs {
+			if cost <= budget {
+				isochrone.Nodes = append(isochrone.Nodes, nodeID)
+			}
+		}
+		sort.Slice(isochrone.Nodes, func(i, j int) bool {
+			return isochrone.Nodes[i] < isochrone.Nodes[j]
+		})
+		lines := []orb.LineString{{net.Nodes[sourceNodeID].GetGeom()}}
+		for linkID, cost := range tree.LinkCosts {
+			reached, ok := reachLink(net.Links[linkID], tree, cost, budget)
+			if !ok {
+				continue
+			}
+			isochrone.Links = append(isochrone.Links, reached)
+			lines = append(lines, reached.Geom)
+		}
+		sort.Slice(isochrone.Links, func(i, j int) bool {
+			return isochrone.Links[i].LinkID < isochrone.Links[j].LinkID
+		})
+		isochrone.Geom = bufferedArea(lines, cfg.buffer)
+		isochrones = append(isochrones, isochrone)
+	}
+	return isochrones, nil
+}
+
+// reachLink returns reachable part of the link. Cost is assumed to be spread uniformly along the link
+func reachLink(link *macro.Link, tree *routing.Tree, cost, budget float64) (ReachedLink, bool) {
+	entryCost, ok := tree.LinkEntryCost(link.ID)
+	if !ok || entryCost >= budget {
+		return ReachedLink{}, false
+	}
+	if cost <= budget {
+		return ReachedLink{LinkID: link.ID, Fraction: 1, Geom: link.GetGeom()}, true
+	}
+	fraction := (budget - entryCost) / (cost - entryCost)
+	return ReachedLink{
+		LinkID:   link.ID,
+		Fraction: fraction,
+		Geom:     geomath.SubstringHaversine(link.GetGeom(), 0, fraction*link.GetLengthMeters()),
+	}, true
+}
@@ -0,0 +1,150 @@
+package isochrone
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/poi"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareNet returns two-way road along the equator (50 km/h, ~555 meters) with nodes every ~111 meters
+func prepareNet(t *testing.T) *macro.Net {
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+	wayNodes := make([]osm.NodeID, 0)
+	for i := 0; i <= 5; i++ {
+		nodeID := osm.NodeID(i + 1)
+		nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: float64(i) * 0.001, Lat: 0.0005}, IsCrossing: true}
+		wayNodes = append(wayNodes, nodeID)
+	}
+	way := &wrappers.WayOSM{
+		ID:                1,
+		Nodes:             wayNodes,
+		Tags:              wrappers.WayTags{MaxSpeed: 50},
+		FreeSpeed:         -1,
+		LinkType:          types.LINK_PRIMARY,
+		LinkClass:         types.LINK_CLASS_HIGHWAY,
+		AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO, types.AGENT_WALK},
+	}
+	net, err := macro.NewNetFromOSM([]*wrappers.WayOSM{way}, nodesSet)
+	assert.NoError(t, err)
+	return net
+}
+
+func findNode(net *macro.Net, pt orb.Point) gmns.NodeID {
+	for nodeID, node := range net.Nodes {
+		if node.GetGeom().Equal(pt) {
+			return nodeID
+		}
+	}
+	return -1
+}
+
+func TestIsochrone(t *testing.T) {
+	net := prepareNet(t)
+	source := findNode(net, orb.Point{0, 0.0005})
+
+	// Pedestrian walks ~167 and ~417 meters
+	isochrones, err := FromNode(net, source, BUDGET_TIME, []float64{120, 300}, WithAgentType(types.AGENT_WALK))
+	assert.NoError(t, err)
+	assert.Len(t, isochrones, 2)
+	assert.Len(t, isochrones[0].Nodes, 2, "Wrong number of reachable nodes")
+	assert.Len(t, isochrones[1].Nodes, 4, "Wrong number of reachable nodes")
+	partial := 0
+	for _, reached := range isochrones[0].Links {
+		if reached.Fraction < 1 {
+			partial++
+			assert.InDelta(t, 0.5, reached.Fraction, 0.05, "Half of the link should be reachable")
+		}
+	}
+	assert.Equal(t, 2, partial, "Link ahead and link back to the source should be reached partially")
+	assert.Len(t, isochrones[0].Geom, 1, "Area should be solid")
+	assert.True(t, planar.MultiPolygonContains(isochrones[0].Geom, orb.Point{0.0015, 0.0005}), "Reachable part of the link should be covered")
+	assert.False(t, planar.MultiPolygonContains(isochrones[0].Geom, orb.Point{0.003, 0.0005}), "Unreachable part of the road should not be covered")
+	assert.True(t, planar.MultiPolygonContains(isochrones[1].Geom, orb.Point{0.003, 0.0005}), "Greater budget should cover greater area")
+
+	isochrones, err = FromPoint(net, orb.Point{0.0021, 0.0008}, BUDGET_DISTANCE, []float64{250})
+	assert.NoError(t, err)
+	assert.Equal(t, findNode(net, orb.Point{0.002, 0.0005}), isochrones[0].SourceNodeID, "Point should be snapped to the nearest node")
+	assert.Len(t, isochrones[0].Nodes, 5, "Wrong number of reachable nodes")
+	_, err = FromPoint(net, orb.Point{1, 1}, BUDGET_DISTANCE, []float64{250})
+	assert.ErrorIs(t, err, ErrNoNearestNode)
+
+	fname := filepath.Join(t.TempDir(), "isochrones.geojson")
+	assert.NoError(t, isochrones.ExportToGeoJSON(fname))
+	data, err := os.ReadFile(fname)
+	assert.NoError(t, err)
+	collection, err := geojson.UnmarshalFeatureCollection(data)
+	assert.NoError(t, err)
+	assert.Len(t, collection.Features, 1)
+	assert.Equal(t, "distance", collection.Features[0].Properties["budget_type"])
+}
+
+func TestBufferedArea(t *testing.T) {
+	// Ring of cells with empty cell in the middle
+	cells := make(map[gridVertex]struct{})
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if i != 1 || j != 1 {
+				cells[gridVertex{i, j}] = struct{}{}
+			}
+		}
+	}
+	outers, holes := traceCells(cells)
+	assert.Equal(t, [][]gridVertex{{{0, 0}, {3, 0}, {3, 3}, {0, 3}}}, outers, "Wrong outer ring")
+	assert.Equal(t, [][]gridVertex{{{1, 1}, {1, 2}, {2, 2}, {2, 1}}}, holes, "Wrong hole")
+
+	// Cells which touch by corner only
+	outers, holes = traceCells(map[gridVertex]struct{}{{0, 0}: {}, {1, 1}: {}})
+	assert.Len(t, outers, 2, "Cells should not be merged")
+	assert.Len(t, holes, 0)
+
+	area := bufferedArea([]orb.LineString{{{0, 0}}, {{0.01, 0}}}, 50)
+	assert.Len(t, area, 2, "Distant points should be buffered separately")
+}
+
+func TestAccessibility(t *testing.T) {
+	net := prepareNet(t)
+	// Two cells (~278 meters) with centroids on the road near the second and the fifth nodes
+	zones := zone.NewGrid(orb.Bound{Min: orb.Point{0, -0.00075}, Max: orb.Point{0.0049, 0.0017}}, 278.3)
+	building := func(id int, lon float64, amenity string) *poi.POI {
+		geom := orb.Bound{Min: orb.Point{lon, 0.0007}, Max: orb.Point{lon + 0.0001, 0.0008}}.ToPolygon()
+		return poi.NewPOI(id, osm.WayID(id), geom, poi.WithTags("yes", amenity, ""))
+	}
+	pois := poi.POIs{building(0, 0.0011, "school"), building(1, 0.0041, "cafe"), building(2, 0.0043, "cafe"), building(3, 0.5, "cafe")}
+
+	_, err := EvaluateAccessibility(net, zones, pois, 300)
+	assert.ErrorIs(t, err, macro.ErrNoCentroids, "Zones should be attached first")
+	_, err = net.AttachZones(zones, macro.WithConnectorsNum(1))
+	assert.NoError(t, err)
+
+	// Pedestrian walks ~167 meters
+	accessibilities, err := EvaluateAccessibility(net, zones, pois, 120, WithAgentType(types.AGENT_WALK), WithWorkers(2))
+	assert.NoError(t, err)
+	assert.Len(t, accessibilities, 2)
+	assert.Equal(t, 1, accessibilities[0].POIsNum, "Only the nearest POI should be reachable")
+	assert.Equal(t, map[string]int{"school": 1}, accessibilities[0].Categories)
+	assert.Equal(t, 2, accessibilities[1].POIsNum, "Only the nearest POIs should be reachable")
+
+	accessibilities, err = EvaluateAccessibility(net, zones, pois, 300, WithAgentType(types.AGENT_WALK))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, accessibilities[0].POIsNum, "POI far from the network should be skipped")
+
+	dir := t.TempDir()
+	assert.NoError(t, accessibilities.ExportToCSV(filepath.Join(dir, "test.csv")))
+	content, err := os.ReadFile(filepath.Join(dir, "test_accessibility.csv"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "cafe:2,school:1")
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 3)
+}
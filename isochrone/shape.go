@@ -0,0 +1,188 @@
+package isochrone
+
+import (
+	"math"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// gridVertex is the corner of the grid cell. Cell (i, j) has corners (i, j) and (i+1, j+1)
+type gridVertex [2]int
+
+// bufferedArea returns area (EPSG:4326) covered by lines (EPSG:4326) buffered with given distance (meters).
+// Lines are rasterized onto the grid with cell of the half of the buffer and border of covered cells is traced,
+// so the area is approximated with precision of the cell. Single-point lines are buffered points
+func bufferedArea(lines []orb.LineString, buffer float64) orb.MultiPolygon {
+	area := orb.MultiPolygon{}
+	if len(lines) == 0 || len(lines[0]) == 0 || buffer <= 0 {
+		return area
+	}
+	radius := buffer * geomath.EuclideanScale(lines[0][0].Lat())
+	cellSize := radius / 2
+
+	cells := make(map[gridVertex]struct{})
+	for _, line := range lines {
+		euclidean := geomath.LineToEuclidean(line)
+		if len(euclidean) == 1 {
+			markSegment(cells, euclidean[0], euclidean[0], radius, cellSize)
+		}
+		for i := 1; i < len(euclidean); i++ {
+			markSegment(cells, euclidean[i-1], euclidean[i], radius, cellSize)
+		}
+	}
+
+	outers, holes := traceCells(cells)
+	toSpherical := func(ring []gridVertex) orb.Ring {
+		converted := make(orb.Ring, 0, len(ring)+1)
+		for _, v := range ring {
+			converted = append(converted, geomath.PointToSpherical(orb.Point{float64(v[0]) * cellSize, float64(v[1]) * cellSize}))
+		}
+		return append(converted, converted[0])
+	}
+	for _, outer := range outers {
+		area = append(area, orb.Polygon{toSpherical(outer)})
+	}
+	for _, hole := range holes {
+		// Empty cell to the right of the first edge of the hole is inside of the hole
+		dx, dy := sign(hole[1][0]-hole[0][0]), sign(hole[1][1]-hole[0][1])
+		inside := orb.Point{float64(hole[0][0]) + 0.5*float64(dx+dy), float64(hole[0][1]) + 0.5*float64(dy-dx)}
+		best, bestArea := -1, math.Inf(1)
+		for idx, outer := range outers {
+			ring := gridRing(outer)
+			if size := math.Abs(planar.Area(ring)); planar.RingContains(ring, inside) && size < bestArea {
+				best, bestArea = idx, size
+			}
+		}
+		if best >= 0 {
+			area[best] = append(area[best], toSpherical(hole))
+		}
+	}
+	return area
+}
+
+// markSegment marks cells which centers are within the radius from the segment
+func markSegment(cells map[gridVertex]struct{}, a, b orb.Point, radius, cellSize float64) {
+	minI, maxI := int(math.Floor((math.Min(a[0], b[0])-radius)/cellSize)), int(math.Floor((math.Max(a[0], b[0])+radius)/cellSize))
+	minJ, maxJ := int(math.Floor((math.Min(a[1], b[1])-radius)/cellSize)), int(math.Floor((math.Max(a[1], b[1])+radius)/cellSize))
+	for i := minI; i <= maxI; i++ {
+		for j := minJ; j <= maxJ; j++ {
+			center := orb.Point{(float64(i) + 0.5) * cellSize, (float64(j) + 0.5) * cellSize}
+			if distanceToSegment(center, a, b) <= radius {
+				cells[gridVertex{i, j}] = struct{}{}
+			}
+		}
+	}
+}
+
+// traceCells traces borders of the covered cells. Outer rings are counter-clockwise, holes are clockwise.
+// Cells which touch each other by corner only are separated
+func traceCells(cells map[gridVertex]struct{}) ([][]gridVertex, [][]gridVertex) {
+	covered := func(i, j int) bool {
+		_, ok := cells[gridVertex{i, j}]
+		return ok
+	}
+	// Directed border edges: covered cell is on the left side
+	edges := make(map[gridVertex][]gridVertex)
+	for cell := range cells {
+		i, j := cell[0], cell[1]
+		if !covered(i, j-1) {
+			edges[gridVertex{i, j}] = append(edges[gridVertex{i, j}], gridVertex{i + 1, j})
+		}
+		if !covered(i+1, j) {
+			edges[gridVertex{i + 1, j}] = append(edges[gridVertex{i + 1, j}], gridVertex{i + 1, j + 1})
+		}
+		if !covered(i, j+1) {
+			edges[gridVertex{i + 1, j + 1}] = append(edges[gridVertex{i + 1, j + 1}], gridVertex{i, j + 1})
+		}
+		if !covered(i-1, j) {
+			edges[gridVertex{i, j + 1}] = append(edges[gridVertex{i, j + 1}], gridVertex{i, j})
+		}
+	}
+	starts := make([]gridVertex, 0, len(edges))
+	for v := range edges {
+		starts = append(starts, v)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		if starts[i][1] != starts[j][1] {
+			return starts[i][1] < starts[j][1]
+		}
+		return starts[i][0] < starts[j][0]
+	})
+
+	outers, holes := make([][]gridVertex, 0), make([][]gridVertex, 0)
+	for _, start := range starts {
+		for len(edges[start]) > 0 {
+			ring := make([]gridVertex, 0)
+			current, direction := start, gridVertex{0, 0}
+			for {
+				next := edges[current]
+				idx := 0
+				if len(next) > 1 {
+					// Left turn keeps cells which touch by corner in separate rings
+					for k, candidate := range next {
+						turn := direction[0]*(candidate[1]-current[1]) - direction[1]*(candidate[0]-current[0])
+						if turn > 0 {
+							idx = k
+						}
+					}
+				}
+				target := next[idx]
+				edges[current] = append(next[:idx], next[idx+1:]...)
+				nextDirection := gridVertex{target[0] - current[0], target[1] - current[1]}
+				if nextDirection != direction {
+					ring = append(ring, current)
+				}
+				current, direction = target, nextDirection
+				if current == start {
+					break
+				}
+			}
+			// Start vertex is redundant if the ring goes straight through it
+			if len(ring) > 1 {
+				first := gridVertex{ring[1][0] - ring[0][0], ring[1][1] - ring[0][1]}
+				if sign(first[0]) == sign(direction[0]) && sign(first[1]) == sign(direction[1]) {
+					ring = ring[1:]
+				}
+			}
+			if gridRing(ring).Orientation() == orb.CCW {
+				outers = append(outers, ring)
+			} else {
+				holes = append(holes, ring)
+			}
+		}
+	}
+	return outers, holes
+}
+
+// gridRing returns closed ring of grid vertices
+func gridRing(vertices []gridVertex) orb.Ring {
+	ring := make(orb.Ring, 0, len(vertices)+1)
+	for _, v := range vertices {
+		ring = append(ring, orb.Point{float64(v[0]), float64(v[1])})
+	}
+	return append(ring, ring[0])
+}
+
+// distanceToSegment returns planar distance between the point and the segment
+func distanceToSegment(pt, a, b orb.Point) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(pt[0]-a[0], pt[1]-a[1])
+	}
+	t := math.Max(0, math.Min(1, ((pt[0]-a[0])*dx+(pt[1]-a[1])*dy)/lengthSquared))
+	return math.Hypot(pt[0]-(a[0]+t*dx), pt[1]-(a[1]+t*dy))
+}
+
+func sign(value int) int {
+	switch {
+	case value > 0:
+		return 1
+	case value < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package isochrone
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+const (
+	// Size (degrees) of the cell of nodes index
+	indexCellSize = 0.005
+	// Approximate length (meters) of the degree of latitude
+	metersPerDegree = 111320.0
+)
+
+// nodeIndex is the grid index of nodes which are accessible for the agent type (centroids are skipped)
+type nodeIndex struct {
+	cells map[[2]int][]*macro.Node
+}
+
+func newNodeIndex(net *macro.Net, agentType types.AgentType) *nodeIndex {
+	index := &nodeIndex{
+		cells: make(map[[2]int][]*macro.Node),
+	}
+	for _, node := range net.Nodes {
+		if node.IsCentroid() || !accessible(net, node, agentType) {
+			continue
+		}
+		cell := indexCell(node.GetGeom())
+		index.cells[cell] = append(index.cells[cell], node)
+	}
+	return index
+}
+
+// nearest returns the nearest node within given distance (meters) from the point
+func (index *nodeIndex) nearest(pt orb.Point, maxDistance float64) (*macro.Node, bool) {
+	latRadius := maxDistance / metersPerDegree
+	lonRadius := latRadius / math.Max(math.Cos(pt.Lat()*math.Pi/180), 1e-6)
+	minCell := indexCell(orb.Point{pt.Lon() - lonRadius, pt.Lat() - latRadius})
+	maxCell := indexCell(orb.Point{pt.Lon() + lonRadius, pt.Lat() + latRadius})
+	var found *macro.Node
+	best := maxDistance
+	for i := minCell[0]; i <= maxCell[0]; i++ {
+		for j := minCell[1]; j <= maxCell[1]; j++ {
+			for _, node := range index.cells[[2]int{i, j}] {
+				distance := geo.Distance(pt, node.GetGeom())
+				if distance > best || (distance == best && found != nil && node.ID > found.ID) {
+					continue
+				}
+				found, best = node, distance
+			}
+		}
+	}
+	return found, found != nil
+}
+
+func indexCell(pt orb.Point) [2]int {
+	return [2]int{int(math.Floor(pt.Lon() / indexCellSize)), int(math.Floor(pt.Lat() / indexCellSize))}
+}
+
+// accessible checks if any link adjacent to the node allows the agent type
+func accessible(net *macro.Net, node *macro.Node, agentType types.AgentType) bool {
+	for _, linksIDs := range [][]gmns.LinkID{node.GetIncomingLinks(), node.GetOutcomingLinks()} {
+		for _, linkID := range linksIDs {
+			link, ok := net.Links[linkID]
+			if !ok {
+				continue
+			}
+			for _, allowed := range link.GetAllowedAgentTypes() {
+				if allowed == agentType {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -5,12 +5,13 @@ import (
 
 	"github.com/LdDl/osm2gmns/geomath"
 	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
 	"github.com/LdDl/osm2gmns/utils"
 )
 
-func getIntersectionsConnections(incomingLink *Link, outcomingLinks []*Link) [][]connectionPair {
+func getIntersectionsConnections(incomingLink *Link, outcomingLinks []*Link, drivingSide types.DrivingSide) [][]connectionPair {
 
-	// Sort outcoming links by angle in descending order (left to right)
+	// Sort links by angle from the inner side to the outer side (left to right for right-hand traffic)
 	angles := make([]float64, len(outcomingLinks))
 	for i, outLink := range outcomingLinks {
 		angles[i] = geomath.AngleBetweenLines(incomingLink.geomEuclidean, outLink.geomEuclidean)
@@ -25,6 +26,10 @@ func getIntersectionsConnections(incomingLink *Link, outcomingLinks []*Link) [][
 		indices[i] = i
 	}
 	sort.Slice(indices, func(i, j int) bool {
+		// Lanes are numbered from the inner side: from the left for right-hand traffic and from the right for left-hand traffic
+		if drivingSide == types.DRIVING_SIDE_LEFT {
+			return angles[indices[i]] < angles[indices[j]]
+		}
 		return angles[indices[i]] > angles[indices[j]]
 	})
 	outcomingLinksSorted := make([]*Link, len(outcomingLinks))
@@ -116,7 +121,8 @@ func getIntersectionsConnections(incomingLink *Link, outcomingLinks []*Link) [][
 					{middleLink.GetIncomingLanes() - 1, middleLink.GetIncomingLanes() - 1},
 				}
 			}
-			laneNumber++
+			// Loop leaves laneNumber at the last (outer) lane already: the remaining middle links share it.
+			// Incrementing it would point to the lane which does not exist
 			startLinkIndex := linkIndex + 1
 			for linkIndex = startLinkIndex; linkIndex < len(middleLinks); linkIndex++ {
 				middleLink := middleLinks[linkIndex]
@@ -0,0 +1,37 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersectionsConnectionsLackOfLanes(t *testing.T) {
+	// Two-lane approach 0-1 to the east and five exits from the left to the right: north (2), north-east (3), east (4), south-east (5) and south (6)
+	net := newTestNet(map[gmns.NodeID]orb.Point{
+		0: {-8 * testStep, 0},
+		1: {0, 0},
+		2: {0, testStep},
+		3: {testStep, testStep},
+		4: {testStep, 0},
+		5: {testStep, -testStep},
+		6: {0, -testStep},
+	})
+	approachID := net.addLink(0, 1, func(link *Link) {
+		link.lanesNum = 2
+	})
+	outcomingLinks := make([]*Link, 0, 5)
+	for nodeID := gmns.NodeID(2); nodeID <= 6; nodeID++ {
+		outcomingLinks = append(outcomingLinks, net.Links[net.addLink(1, nodeID)])
+	}
+	connections := getIntersectionsConnections(net.Links[approachID], outcomingLinks, types.DRIVING_SIDE_RIGHT)
+	// There are less lanes than middle links: the first middle link gets the inner lane, the remaining ones share the outer lane
+	expected := []connectionPair{{0, 0}, {0, 0}, {1, 1}, {1, 1}, {1, 1}}
+	for i := range outcomingLinks {
+		assert.Equal(t, expected[i], connections[i][0], "Wrong lanes of the approach to the link %d", outcomingLinks[i].ID)
+		assert.Less(t, connections[i][0].second, net.Links[approachID].GetOutcomingLanes(), "Lane of the approach to the link %d does not exist", outcomingLinks[i].ID)
+	}
+}
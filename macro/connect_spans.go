@@ -5,10 +5,11 @@ import (
 
 	"github.com/LdDl/osm2gmns/geomath"
 	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
 )
 
-func getSpansConnections(outcomingLink *Link, incomingLinksList []*Link) [][]connectionPair {
-	// Sort outcoming links by angle in descending order (left to right)
+func getSpansConnections(outcomingLink *Link, incomingLinksList []*Link, drivingSide types.DrivingSide) [][]connectionPair {
+	// Sort links by angle from the inner side to the outer side (left to right for right-hand traffic)
 	angles := make([]float64, len(incomingLinksList))
 	for i, inLink := range incomingLinksList {
 		angles[i] = geomath.AngleBetweenLines(inLink.geomEuclidean, outcomingLink.geomEuclidean)
@@ -23,6 +24,10 @@ func getSpansConnections(outcomingLink *Link, incomingLinksList []*Link) [][]con
 		indices[i] = i
 	}
 	sort.Slice(indices, func(i, j int) bool {
+		// Lanes are numbered from the inner side: from the left for right-hand traffic and from the right for left-hand traffic
+		if drivingSide == types.DRIVING_SIDE_LEFT {
+			return angles[indices[i]] < angles[indices[j]]
+		}
 		return angles[indices[i]] > angles[indices[j]]
 	})
 	incomingLinksSorted := make([]*Link, len(incomingLinksList))
@@ -0,0 +1,332 @@
+package macro
+
+import (
+	"sort"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/pkg/errors"
+)
+
+const (
+	consolidationToleranceDefault = 25.0
+)
+
+// ConsolidationConfig is the set of parameters for intersections consolidation
+type ConsolidationConfig struct {
+	// Max distance (meters) along the network between intersection nodes of the same cluster
+	tolerance float64
+	// Consolidate signalized intersections only
+	signalizedOnly bool
+	// Consolidate intersections which share at least one road name only
+	matchingNames bool
+	// Replace every cluster with the single node
+	collapse bool
+	// Movements which should be rebuilt for collapsed clusters (optional)
+	mvmtStorage *movement.MovementsStorage
+	mvmtOptions []func(*MovementsConfig)
+}
+
+// NewConsolidationConfigDefault returns default parameters for intersections consolidation: 25 meters, any intersections, no collapse
+func NewConsolidationConfigDefault() *ConsolidationConfig {
+	return &ConsolidationConfig{
+		tolerance:      consolidationToleranceDefault,
+		signalizedOnly: false,
+		matchingNames:  false,
+		collapse:       false,
+	}
+}
+
+// WithConsolidationTolerance sets max distance (meters) along the network between intersection nodes of the same cluster
+func WithConsolidationTolerance(tolerance float64) func(*ConsolidationConfig) {
+	return func(cfg *ConsolidationConfig) {
+		cfg.tolerance = tolerance
+	}
+}
+
+// WithSignalizedOnly sets whether only signalized intersections should be consolidated
+func WithSignalizedOnly(signalizedOnly bool) func(*ConsolidationConfig) {
+	return func(cfg *ConsolidationConfig) {
+		cfg.signalizedOnly = signalizedOnly
+	}
+}
+
+// WithMatchingNames sets whether only intersections which share at least one road name should be consolidated
+func WithMatchingNames(matchingNames bool) func(*ConsolidationConfig) {
+	return func(cfg *ConsolidationConfig) {
+		cfg.matchingNames = matchingNames
+	}
+}
+
+// WithCollapse sets whether every cluster should be replaced with the single node
+func WithCollapse(collapse bool) func(*ConsolidationConfig) {
+	return func(cfg *ConsolidationConfig) {
+		cfg.collapse = collapse
+	}
+}
+
+// WithRebuiltMovements sets storage of movements which have been generated before consolidation.
+// Movements of collapsed clusters are removed from the storage and movements of new nodes are added with given options.
+// Notice: pockets are not prepared again, so pockets of reattached links are the ones from the previous generation
+func WithRebuiltMovements(mvmtStorage *movement.MovementsStorage, options ...func(*MovementsConfig)) func(*ConsolidationConfig) {
+	return func(cfg *ConsolidationConfig) {
+		cfg.mvmtStorage = mvmtStorage
+		cfg.mvmtOptions = options
+	}
+}
+
+// ConsolidateIntersections clusters intersection nodes which are close to each other along the network (e.g. crossings of dual carriageways)
+// and assigns shared intersection identifier to every node of the cluster (including pass-through nodes between them).
+// When collapse is enabled every cluster is replaced with the single node placed at the centroid: links inside the cluster are removed
+// and links which cross cluster's border are reattached to the new node. Movements should be generated after consolidation
+// or rebuilt by consolidation itself (see WithRebuiltMovements).
+// Returns number of clusters.
+func (net *Net) ConsolidateIntersections(options ...func(*ConsolidationConfig)) (int, error) {
+	cfg := NewConsolidationConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	if cfg.tolerance <= 0 {
+		return 0, nil
+	}
+
+	nodesIDs := make([]gmns.NodeID, 0, len(net.Nodes))
+	for nodeID := range net.Nodes {
+		nodesIDs = append(nodesIDs, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+
+	union := newNodesUnion()
+	for _, nodeID := range nodesIDs {
+		node := net.Nodes[nodeID]
+		if !net.isConsolidationCandidate(node, cfg) {
+			continue
+		}
+		for _, outcomingLinkID := range node.outcomingLinks {
+			outcomingLink, ok := net.Links[outcomingLinkID]
+			if !ok {
+				continue
+			}
+			neighbour, passNodes := net.walkToIntersection(outcomingLink, cfg.tolerance)
+			if neighbour == nil || neighbour.ID == node.ID || !net.isConsolidationCandidate(neighbour, cfg) {
+				continue
+			}
+			if cfg.matchingNames && !net.shareRoadName(node, neighbour) {
+				continue
+			}
+			union.unite(node.ID, neighbour.ID)
+			for _, passNodeID := range passNodes {
+				union.unite(node.ID, passNodeID)
+			}
+		}
+	}
+
+	clusters := union.groups()
+	for intersectionID, cluster := range clusters {
+		for _, nodeID := range cluster {
+			net.Nodes[nodeID].intersectionID = intersectionID
+		}
+	}
+	if !cfg.collapse {
+		return len(clusters), nil
+	}
+	for _, cluster := range clusters {
+		net.collapseCluster(cluster)
+	}
+	err := net.genBoundaryAndActivityType()
+	if err != nil {
+		return len(clusters), err
+	}
+	if cfg.mvmtStorage == nil {
+		return len(clusters), nil
+	}
+	err = net.rebuildMovements(cfg.mvmtStorage, clusters, cfg.mvmtOptions...)
+	if err != nil {
+		return len(clusters), errors.Wrap(err, "Can't rebuild movements")
+	}
+	return len(clusters), nil
+}
+
+// rebuildMovements replaces movements of nodes of given collapsed clusters with movements of the kept nodes (the first ones of clusters)
+func (net *Net) rebuildMovements(mvmtStorage *movement.MovementsStorage, clusters [][]gmns.NodeID, options ...func(*MovementsConfig)) error {
+	cfg := NewMovementsConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	cfg.applyDrivingSide(net.drivingSide)
+
+	clustered := make(map[gmns.NodeID]struct{})
+	keptNodesIDs := make([]gmns.NodeID, 0, len(clusters))
+	for _, cluster := range clusters {
+		for _, nodeID := range cluster {
+			clustered[nodeID] = struct{}{}
+		}
+		keptNodesIDs = append(keptNodesIDs, cluster[0])
+	}
+	removed := make([]movement.MovementID, 0)
+	for _, mvmt := range mvmtStorage.List() {
+		if _, ok := clustered[mvmt.MacroNodeID]; ok {
+			removed = append(removed, mvmt.ID)
+		}
+	}
+	mvmtStorage.Remove(removed)
+
+	movements, err := net.findMovements(keptNodesIDs, cfg)
+	if err != nil {
+		return err
+	}
+	mvmtStorage.AddMovements(movements)
+	return nil
+}
+
+// isConsolidationCandidate checks if node could be a part of the cluster
+func (net *Net) isConsolidationCandidate(node *Node, cfg *ConsolidationConfig) bool {
+	if !net.isIntersection(node) {
+		return false
+	}
+	return !cfg.signalizedOnly || node.controlType == types.CONTROL_TYPE_IS_SIGNAL
+}
+
+// walkToIntersection walks links chain starting from given link until the first intersection node is found
+// Returns intersection node and pass-through nodes between. Node is nil when there is no intersection within maxDistance or chain forks before
+func (net *Net) walkToIntersection(startLink *Link, maxDistance float64) (*Node, []gmns.NodeID) {
+	passNodes := make([]gmns.NodeID, 0)
+	link := startLink
+	distance := 0.0
+	for {
+		distance += link.lengthMeters
+		if distance > maxDistance {
+			return nil, nil
+		}
+		targetNode, ok := net.Nodes[link.targetNodeID]
+		if !ok {
+			return nil, nil
+		}
+		if net.isIntersection(targetNode) {
+			return targetNode, passNodes
+		}
+		if targetNode.ID == startLink.sourceNodeID || len(passNodes) > len(net.Nodes) {
+			return nil, nil
+		}
+		passNodes = append(passNodes, targetNode.ID)
+		// Continue through pass-through nodes only
+		var nextLink *Link
+		for _, outcomingLinkID := range targetNode.outcomingLinks {
+			outcomingLink, ok := net.Links[outcomingLinkID]
+			if !ok || outcomingLink.targetNodeID == link.sourceNodeID { // Ignore reverse direction
+				continue
+			}
+			if nextLink != nil {
+				return nil, nil
+			}
+			nextLink = outcomingLink
+		}
+		if nextLink == nil {
+			return nil, nil
+		}
+		link = nextLink
+	}
+}
+
+// shareRoadName checks if nodes have at least one common non-empty name of adjacent links
+func (net *Net) shareRoadName(node, other *Node) bool {
+	names := net.roadNames(node)
+	for name := range net.roadNames(other) {
+		if _, ok := names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// roadNames returns non-empty names of links which are adjacent to the node
+func (net *Net) roadNames(node *Node) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, linkIDs := range [][]gmns.LinkID{node.incomingLinks, node.outcomingLinks} {
+		for _, linkID := range linkIDs {
+			if link, ok := net.Links[linkID]; ok && link.name != "" {
+				names[link.name] = struct{}{}
+			}
+		}
+	}
+	return names
+}
+
+// collapseCluster replaces nodes of the cluster with the first one placed at the centroid
+// Notice: nodes should be sorted by identifiers
+func (net *Net) collapseCluster(nodesIDs []gmns.NodeID) {
+	inCluster := make(map[gmns.NodeID]struct{}, len(nodesIDs))
+	for _, nodeID := range nodesIDs {
+		inCluster[nodeID] = struct{}{}
+	}
+	keptNode := net.Nodes[nodesIDs[0]]
+	centroid := orb.Point{0, 0}
+	for _, nodeID := range nodesIDs {
+		node := net.Nodes[nodeID]
+		centroid[0] += node.geom[0] / float64(len(nodesIDs))
+		centroid[1] += node.geom[1] / float64(len(nodesIDs))
+		keptNode.controlType = types.PriorControlType(keptNode.controlType, node.controlType)
+		if keptNode.name == "" {
+			keptNode.name = node.name
+		}
+	}
+	if keptNode.controlType == types.CONTROL_TYPE_IS_SIGNAL {
+		keptNode.controlDirection = types.CONTROL_DIRECTION_BOTH
+	}
+	keptNode.geom = centroid
+	keptNode.geomEuclidean = geomath.PointToEuclidean(centroid)
+
+	incomingLinks := make([]gmns.LinkID, 0)
+	outcomingLinks := make([]gmns.LinkID, 0)
+	for _, nodeID := range nodesIDs {
+		node := net.Nodes[nodeID]
+		for _, linkID := range node.outcomingLinks {
+			link, ok := net.Links[linkID]
+			if !ok {
+				continue
+			}
+			if _, ok := inCluster[link.targetNodeID]; ok {
+				delete(net.Links, linkID)
+				continue
+			}
+			link.sourceNodeID = keptNode.ID
+			link.sourceOsmNodeID = keptNode.osmNodeID
+			link.setGeom(append(orb.LineString{centroid}, link.geom...))
+			outcomingLinks = append(outcomingLinks, linkID)
+		}
+		for _, linkID := range node.incomingLinks {
+			link, ok := net.Links[linkID]
+			if !ok {
+				continue
+			}
+			if _, ok := inCluster[link.sourceNodeID]; ok {
+				delete(net.Links, linkID)
+				continue
+			}
+			link.targetNodeID = keptNode.ID
+			link.targetOsmNodeID = keptNode.osmNodeID
+			link.setGeom(append(link.geom, centroid))
+			incomingLinks = append(incomingLinks, linkID)
+		}
+		if nodeID != keptNode.ID {
+			delete(net.Nodes, nodeID)
+		}
+	}
+	keptNode.incomingLinks = incomingLinks
+	keptNode.outcomingLinks = outcomingLinks
+}
+
+// setGeom replaces geometry (EPSG:4326) of the link and updates dependent attributes. Turn pockets of the link are kept
+// Notice: it does not copy given geometry
+func (link *Link) setGeom(geom orb.LineString) {
+	link.geom = geom
+	link.geomEuclidean = geomath.LineToEuclidean(geom)
+	link.lengthMeters = geo.LengthHaversine(geom)
+	link.lanesInfo = newLanesInfoWithPockets(link, link.leftPockets, link.rightPockets, link.pocketLength)
+}
@@ -0,0 +1,93 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidateIntersections(t *testing.T) {
+	// Crossing of dual carriageways: four corner nodes ~15 meters apart and one arm per corner
+	prepareNet := func() *testNet {
+		net := newTestNet(map[gmns.NodeID]orb.Point{
+			0: {0, 0}, 1: {testStep, 0}, 2: {testStep, testStep}, 3: {0, testStep},
+			4: {-20 * testStep, 0}, 5: {20 * testStep, 0}, 6: {testStep, 20 * testStep}, 7: {0, 20 * testStep},
+		})
+		for _, node := range net.Nodes {
+			node.controlType = types.CONTROL_TYPE_IS_SIGNAL
+		}
+		for _, pair := range [][2]gmns.NodeID{{0, 1}, {1, 2}, {2, 3}, {3, 0}} {
+			net.addLink(pair[0], pair[1])
+		}
+		for _, pair := range [][2]gmns.NodeID{{0, 4}, {1, 5}, {2, 6}, {3, 7}} {
+			net.addTwoWayLink(pair[0], pair[1])
+		}
+		return net
+	}
+
+	net := prepareNet()
+	clustersNum, err := net.ConsolidateIntersections()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, clustersNum, "Corner nodes should form single cluster")
+	for nodeID := gmns.NodeID(0); nodeID < 4; nodeID++ {
+		assert.Equal(t, 0, net.Nodes[nodeID].intersectionID, "Corner node should be a part of the cluster")
+	}
+	assert.Equal(t, -1, net.Nodes[4].intersectionID, "Arm node should not be a part of the cluster")
+
+	net = prepareNet()
+	clustersNum, err = net.ConsolidateIntersections(WithCollapse(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, clustersNum, "Corner nodes should form single cluster")
+	assert.Len(t, net.Nodes, 5, "Corner nodes should be replaced with single node")
+	assert.Len(t, net.Links, 8, "Links inside the cluster should be removed")
+	collapsed := net.Nodes[0]
+	assert.Len(t, collapsed.incomingLinks, 4, "Wrong number of incoming links")
+	assert.Len(t, collapsed.outcomingLinks, 4, "Wrong number of outcoming links")
+	assert.InDelta(t, testStep/2, collapsed.geom.Lon(), 1e-9, "Collapsed node should be placed at the centroid")
+	for _, linkID := range collapsed.outcomingLinks {
+		assert.Equal(t, collapsed.geom, net.Links[linkID].geom[0], "Link should start at the collapsed node")
+	}
+
+	// Movements of corner nodes are replaced with movements of the collapsed node
+	net = prepareNet()
+	mvmtStorage, err := net.GenerateMovements()
+	assert.NoError(t, err)
+	lastID := mvmtStorage.List()[mvmtStorage.Len()-1].ID
+	_, err = net.ConsolidateIntersections(WithCollapse(true), WithRebuiltMovements(mvmtStorage))
+	assert.NoError(t, err)
+	collapsedMovements := 0
+	for _, mvmt := range mvmtStorage.List() {
+		assert.Contains(t, net.Nodes, mvmt.MacroNodeID, "Movement should refer to existing node")
+		assert.Contains(t, net.Links, mvmt.IncomeMacroLinkID, "Movement should refer to existing incoming link")
+		assert.Contains(t, net.Links, mvmt.OutcomeMacroLinkID, "Movement should refer to existing outcoming link")
+		if mvmt.MacroNodeID == 0 {
+			collapsedMovements++
+			assert.Greater(t, mvmt.ID, lastID, "Rebuilt movement should get new identifier")
+		}
+	}
+	assert.Equal(t, 12, collapsedMovements, "Every arm should have movements to the other arms")
+
+	// Turn pockets of reattached links are kept
+	net = prepareNet()
+	approachID := gmns.LinkID(-1)
+	for _, link := range net.Links {
+		if link.sourceNodeID == 4 {
+			approachID = link.ID
+			link.lanesNum = 3
+			link.turnLanes = parseTurnLanes("left|through|through")
+		}
+	}
+	net.preparePockets(40)
+	assert.Equal(t, [][2]int{{-1, 0}, {0, 0}}, net.Links[approachID].lanesInfo.LanesChange, "Approach should have left pocket")
+	_, err = net.ConsolidateIntersections(WithCollapse(true))
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int{{-1, 0}, {0, 0}}, net.Links[approachID].lanesInfo.LanesChange, "Reattached approach should keep left pocket")
+
+	// Tight tolerance keeps nodes apart
+	net = prepareNet()
+	clustersNum, _ = net.ConsolidateIntersections(WithConsolidationTolerance(5))
+	assert.Equal(t, 0, clustersNum, "Nodes should not be clustered")
+}
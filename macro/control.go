@@ -0,0 +1,63 @@
+package macro
+
+import (
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// controlAppliesToLink checks if control type of the node (which lies inside the segment) should be attributed to the link with given direction
+// distance - distance from the segment start to the node (in the way direction);
+// segmentLength - total length of the segment.
+func controlAppliesToLink(node *wrappers.NodeOSM, direction DirectionType, distance, segmentLength float64) bool {
+	switch node.ControlType {
+	case types.CONTROL_TYPE_NOT_SIGNAL:
+		return false
+	case types.CONTROL_TYPE_IS_SIGNAL:
+		// See ref.: https://wiki.openstreetmap.org/wiki/Key:traffic_signals:direction
+		return controlDirectionMatches(node.ControlDirection, direction)
+	case types.CONTROL_TYPE_LEVEL_CROSSING:
+		return true
+	default:
+		// Continue with approach control types
+	}
+	if node.ControlDirection != types.CONTROL_DIRECTION_BOTH {
+		return controlDirectionMatches(node.ControlDirection, direction)
+	}
+	// When there is no explicit direction the sign is applied to traffic approaching the nearest junction
+	// See ref.: https://wiki.openstreetmap.org/wiki/Key:direction#Tagging_for_highway=stop_and_highway=give_way
+	switch direction {
+	case DIRECTION_FORWARD:
+		return segmentLength-distance <= distance
+	case DIRECTION_BACKWARD:
+		return distance <= segmentLength-distance
+	default:
+		return false
+	}
+}
+
+// controlDirectionMatches checks if control direction (relative to the way) corresponds to the link direction
+func controlDirectionMatches(controlDirection types.ControlDirection, direction DirectionType) bool {
+	switch controlDirection {
+	case types.CONTROL_DIRECTION_BOTH:
+		return true
+	case types.CONTROL_DIRECTION_FORWARD:
+		return direction == DIRECTION_FORWARD
+	case types.CONTROL_DIRECTION_BACKWARD:
+		return direction == DIRECTION_BACKWARD
+	default:
+		return false
+	}
+}
+
+// cumulativeDistances returns distances (in meters) from the first node to every node of the segment
+func cumulativeDistances(segmentNodes []*wrappers.NodeOSM) []float64 {
+	distances := make([]float64, len(segmentNodes))
+	for i := 1; i < len(segmentNodes); i++ {
+		prev := orb.Point{segmentNodes[i-1].InnerNode.Lon, segmentNodes[i-1].InnerNode.Lat}
+		cur := orb.Point{segmentNodes[i].InnerNode.Lon, segmentNodes[i].InnerNode.Lat}
+		distances[i] = distances[i-1] + geo.DistanceHaversine(prev, cur)
+	}
+	return distances
+}
@@ -0,0 +1,93 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlDirection(t *testing.T) {
+	// Two-way road 1-2-3-4 along the equator: node 2 is closer to the source, node 3 is closer to the target
+	prepareLinks := func(controlNodeID osm.NodeID, controlType types.ControlType, controlDirection types.ControlDirection) map[DirectionType]*Link {
+		nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+		for i, lon := range []float64{0, 0.001, 0.004, 0.005} {
+			nodeID := osm.NodeID(i + 1)
+			nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: lon, Lat: 0}, IsCrossing: nodeID == 1 || nodeID == 4}
+		}
+		nodesSet[controlNodeID].ControlType = controlType
+		nodesSet[controlNodeID].ControlDirection = controlDirection
+		way := &wrappers.WayOSM{ID: 1, Nodes: []osm.NodeID{1, 2, 3, 4}, FreeSpeed: -1, Capacity: -1, LinkType: types.LINK_RESIDENTIAL, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}}
+		net, err := NewNetFromOSM([]*wrappers.WayOSM{way}, nodesSet)
+		assert.NoError(t, err)
+		links := make(map[DirectionType]*Link)
+		for _, link := range net.Links {
+			links[link.direction] = link
+		}
+		return links
+	}
+
+	tests := []struct {
+		name             string
+		controlNodeID    osm.NodeID
+		controlType      types.ControlType
+		controlDirection types.ControlDirection
+		forward          types.ControlType
+		backward         types.ControlType
+	}{
+		{"Stop sign closer to the target", 3, types.CONTROL_TYPE_STOP, types.CONTROL_DIRECTION_BOTH, types.CONTROL_TYPE_STOP, types.CONTROL_TYPE_NOT_SIGNAL},
+		{"Give way sign closer to the source", 2, types.CONTROL_TYPE_GIVE_WAY, types.CONTROL_DIRECTION_BOTH, types.CONTROL_TYPE_NOT_SIGNAL, types.CONTROL_TYPE_GIVE_WAY},
+		{"Explicit direction overrides the nearest junction", 3, types.CONTROL_TYPE_STOP, types.CONTROL_DIRECTION_BACKWARD, types.CONTROL_TYPE_NOT_SIGNAL, types.CONTROL_TYPE_STOP},
+		{"Signal without direction", 2, types.CONTROL_TYPE_IS_SIGNAL, types.CONTROL_DIRECTION_BOTH, types.CONTROL_TYPE_IS_SIGNAL, types.CONTROL_TYPE_IS_SIGNAL},
+		{"Signal for the forward direction only", 2, types.CONTROL_TYPE_IS_SIGNAL, types.CONTROL_DIRECTION_FORWARD, types.CONTROL_TYPE_IS_SIGNAL, types.CONTROL_TYPE_NOT_SIGNAL},
+		{"Level crossing has no direction", 3, types.CONTROL_TYPE_LEVEL_CROSSING, types.CONTROL_DIRECTION_FORWARD, types.CONTROL_TYPE_LEVEL_CROSSING, types.CONTROL_TYPE_LEVEL_CROSSING},
+		{"Directional sign on the junction", 4, types.CONTROL_TYPE_STOP, types.CONTROL_DIRECTION_FORWARD, types.CONTROL_TYPE_STOP, types.CONTROL_TYPE_NOT_SIGNAL},
+	}
+	for _, test := range tests {
+		links := prepareLinks(test.controlNodeID, test.controlType, test.controlDirection)
+		assert.Equal(t, test.forward, links[DIRECTION_FORWARD].controlType, "Wrong control type of the forward link: %s", test.name)
+		assert.Equal(t, test.backward, links[DIRECTION_BACKWARD].controlType, "Wrong control type of the backward link: %s", test.name)
+	}
+}
+
+func TestUndirectedStopOnJunction(t *testing.T) {
+	// T-junction: primary west-east road and residential road from the south. Stop sign without direction is on the junction node
+	prepareNet := func(southLinkType types.LinkType) (*testNet, gmns.LinkID, gmns.LinkID) {
+		net := newTestNet(map[gmns.NodeID]orb.Point{0: {0, 0}, 1: {-20 * testStep, 0}, 2: {20 * testStep, 0}, 3: {0, -20 * testStep}})
+		primary := func(link *Link) {
+			link.linkType = types.LINK_PRIMARY
+		}
+		west, _ := net.addTwoWayLink(1, 0, primary)
+		net.addTwoWayLink(2, 0, primary)
+		south, _ := net.addTwoWayLink(3, 0, func(link *Link) {
+			link.linkType = southLinkType
+		})
+		net.Nodes[0].controlType = types.CONTROL_TYPE_STOP
+		net.Nodes[0].controlDirection = types.CONTROL_DIRECTION_BOTH
+		return net, west, south
+	}
+	controlTypes := func(net *testNet) map[gmns.LinkID]types.ControlType {
+		movements, err := net.Nodes[0].FindMovements(net.Links, NewMovementsConfigDefault())
+		assert.NoError(t, err)
+		found := make(map[gmns.LinkID]types.ControlType)
+		for _, mvmt := range movements {
+			found[mvmt.IncomeMacroLinkID] = mvmt.ControlType()
+		}
+		return found
+	}
+
+	net, west, south := prepareNet(types.LINK_RESIDENTIAL)
+	found := controlTypes(net)
+	assert.Equal(t, types.CONTROL_TYPE_STOP, found[south], "Stop sign should be applied to the minor approach")
+	assert.Equal(t, types.CONTROL_TYPE_NOT_SIGNAL, found[west], "Stop sign should not be applied to the major approach")
+
+	// Equal approaches: there is no way to find out which one is controlled
+	net, west, south = prepareNet(types.LINK_PRIMARY)
+	found = controlTypes(net)
+	assert.Equal(t, types.CONTROL_TYPE_STOP, found[south], "Stop sign should be applied to every approach")
+	assert.Equal(t, types.CONTROL_TYPE_STOP, found[west], "Stop sign should be applied to every approach")
+}
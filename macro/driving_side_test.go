@@ -0,0 +1,93 @@
+package macro
+
+import (
+	"math"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCrossroad prepares three-lane approach 0-1 to the east and single-lane exits to the north (2), to the south (3) and to the east (4)
+func newTestCrossroad(drivingSide types.DrivingSide) (*testNet, gmns.LinkID) {
+	net := newTestNet(map[gmns.NodeID]orb.Point{
+		0: {-8 * testStep, 0},
+		1: {0, 0},
+		2: {0, testStep},
+		3: {0, -testStep},
+		4: {testStep, 0},
+	})
+	net.drivingSide = drivingSide
+	approachID := net.addLink(0, 1, func(link *Link) {
+		link.lanesNum = 3
+		link.turnLanes = parseTurnLanes("left|through|through")
+	})
+	net.addLink(1, 2)
+	net.addLink(1, 3)
+	net.addLink(1, 4)
+	return net, approachID
+}
+
+func TestDrivingSideConnections(t *testing.T) {
+	// Lanes are numbered from the inner side, so the inner lane leads to the left for right-hand traffic and to the right for left-hand traffic
+	tests := []struct {
+		drivingSide types.DrivingSide
+		north       connectionPair
+		south       connectionPair
+	}{
+		{types.DRIVING_SIDE_RIGHT, connectionPair{0, 0}, connectionPair{2, 2}},
+		{types.DRIVING_SIDE_LEFT, connectionPair{2, 2}, connectionPair{0, 0}},
+	}
+	for _, test := range tests {
+		net, approachID := newTestCrossroad(test.drivingSide)
+		outcomingLinks := []*Link{net.Links[1], net.Links[2], net.Links[3]}
+		connections := getIntersectionsConnections(net.Links[approachID], outcomingLinks, test.drivingSide)
+		assert.Equal(t, test.north, connections[0][0], "Wrong lanes of the approach to the north for %s traffic", test.drivingSide)
+		assert.Equal(t, test.south, connections[1][0], "Wrong lanes of the approach to the south for %s traffic", test.drivingSide)
+		assert.Equal(t, connectionPair{1, 1}, connections[2][0], "Through lane should be in the middle for %s traffic", test.drivingSide)
+	}
+}
+
+func TestDrivingSidePockets(t *testing.T) {
+	// Left turn-only lane is the inner pocket for right-hand traffic and the outer one for left-hand traffic
+	tests := []struct {
+		drivingSide types.DrivingSide
+		lanesChange [2]int
+	}{
+		{types.DRIVING_SIDE_RIGHT, [2]int{-1, 0}},
+		{types.DRIVING_SIDE_LEFT, [2]int{0, -1}},
+	}
+	for _, test := range tests {
+		net, approachID := newTestCrossroad(test.drivingSide)
+		_, err := net.GenerateMovements(WithPocketLength(40))
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{test.lanesChange, {0, 0}}, net.Links[approachID].lanesInfo.LanesChange, "Wrong pocket side for %s traffic", test.drivingSide)
+	}
+}
+
+func TestDrivingSideOffset(t *testing.T) {
+	// Geometry of two-way road to the east is shifted to the south for right-hand traffic and to the north for left-hand traffic
+	tests := []struct {
+		drivingSide types.DrivingSide
+		northern    bool
+	}{
+		{types.DRIVING_SIDE_RIGHT, false},
+		{types.DRIVING_SIDE_LEFT, true},
+	}
+	for _, test := range tests {
+		net := newTestNet(map[gmns.NodeID]orb.Point{
+			0: {0, 0},
+			1: {8 * testStep, 0},
+		})
+		net.drivingSide = test.drivingSide
+		forwardID, _ := net.addTwoWayLink(0, 1)
+		net.PrepareMesoscopicCuts(movement.NewMovementsStorage(), 3.5)
+		for _, pt := range net.Links[forwardID].geomOffset {
+			assert.Equal(t, test.northern, pt.Lat() > 0, "Wrong offset side for %s traffic", test.drivingSide)
+			assert.InDelta(t, 1.75, math.Abs(pt.Lat())*111320, 0.01, "Offset should be half of the lane width")
+		}
+	}
+}
@@ -44,13 +44,17 @@ func (net *Net) exportNodesToCSV(fname string) error {
 	defer writer.Flush()
 	writer.Comma = ';'
 
-	err = writer.Write([]string{"id", "osm_node_id", "control_type", "boundary_type", "activity_type", "activity_link_type", "zone_id", "intersection_id", "poi_id", "osm_highway", "name", "longitude", "latitude"})
+	err = writer.Write([]string{"id", "osm_node_id", "control_type", "boundary_type", "activity_type", "activity_link_type", "zone_id", "is_centroid", "intersection_id", "roundabout_id", "poi_id", "osm_highway", "barrier", "barrier_blocked_agent_types", "is_barrier_split", "name", "longitude", "latitude"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header")
 	}
 
 	for i := range net.Nodes {
 		node := net.Nodes[i]
+		barrierBlockedAgentTypes := make([]string, len(node.barrierBlockedAgentTypes))
+		for i, agentType := range node.barrierBlockedAgentTypes {
+			barrierBlockedAgentTypes[i] = agentType.String()
+		}
 		err = writer.Write([]string{
 			fmt.Sprintf("%d", node.ID),
 			fmt.Sprintf("%d", node.osmNodeID),
@@ -59,9 +63,14 @@ func (net *Net) exportNodesToCSV(fname string) error {
 			node.activityType.String(),
 			node.activityLinkType.String(),
 			fmt.Sprintf("%d", node.zoneID),
+			fmt.Sprintf("%t", node.isCentroid),
 			fmt.Sprintf("%d", node.intersectionID),
+			fmt.Sprintf("%d", node.roundaboutID),
 			fmt.Sprintf("%d", node.poiID),
 			node.osmHighway,
+			node.barrierType.String(),
+			strings.Join(barrierBlockedAgentTypes, ","),
+			fmt.Sprintf("%t", node.isBarrierSplit),
 			node.name,
 			fmt.Sprintf("%f", node.geom[0]),
 			fmt.Sprintf("%f", node.geom[1]),
@@ -84,7 +93,7 @@ func (net *Net) exportLinksToCSV(fname string) error {
 	defer writer.Flush()
 	writer.Comma = ';'
 
-	err = writer.Write([]string{"id", "source_node", "target_node", "osm_way_id", "source_osm_node_id", "target_osm_node_id", "link_class", "is_link", "link_type", "control_type", "allowed_agent_types", "was_bidirectional", "lanes", "max_speed", "free_speed", "capacity", "length_meters", "name", "geom"})
+	err = writer.Write([]string{"id", "source_node", "target_node", "osm_way_id", "source_osm_node_id", "target_osm_node_id", "link_class", "is_link", "link_type", "control_type", "allowed_agent_types", "was_bidirectional", "roundabout_id", "lanes", "max_speed", "free_speed", "capacity", "volume", "max_height", "max_width", "max_length", "max_weight", "max_axle_load", "length_meters", "name", "geom"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header")
 	}
@@ -108,10 +117,17 @@ func (net *Net) exportLinksToCSV(fname string) error {
 			link.controlType.String(),
 			strings.Join(allowedAgentTypes, ","),
 			fmt.Sprintf("%t", link.wasBidirectional),
+			fmt.Sprintf("%d", link.roundaboutID),
 			fmt.Sprintf("%d", link.lanesNum),
 			fmt.Sprintf("%f", link.maxSpeed),
 			fmt.Sprintf("%f", link.freeSpeed),
 			fmt.Sprintf("%d", link.capacity),
+			fmt.Sprintf("%f", link.volume),
+			fmt.Sprintf("%f", link.restrictions.MaxHeight),
+			fmt.Sprintf("%f", link.restrictions.MaxWidth),
+			fmt.Sprintf("%f", link.restrictions.MaxLength),
+			fmt.Sprintf("%f", link.restrictions.MaxWeight),
+			fmt.Sprintf("%f", link.restrictions.MaxAxleLoad),
 			fmt.Sprintf("%f", link.lengthMeters),
 			link.name,
 			wkt.MarshalString(link.geom),
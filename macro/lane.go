@@ -2,52 +2,75 @@ package macro
 
 import (
 	"sort"
+	"strings"
+
+	"github.com/LdDl/osm2gmns/types"
 )
 
 const (
 	resolution = 5.0
 )
 
+// LanesInfo describes how number of lanes changes along the link
+// LanesList - number of lanes for every segment of the link;
+// LanesChange - lanes which are dropped (negative values) on the inner and on the outer sides of the segment relative to the link's lanes.
+// Lanes are numbered from the inner side: from the left for right-hand traffic and from the right for left-hand traffic;
+// LanesChangePoints - distances (meters) from the link's start where segments start and end.
 type LanesInfo struct {
 	LanesList         []int
 	LanesChange       [][2]int
 	LanesChangePoints []float64
 }
 
+// NewLanesInfo returns lanes information for the link without any pockets
 func NewLanesInfo(link *Link) LanesInfo {
+	return newLanesInfoWithPockets(link, 0, 0, 0)
+}
+
+// newLanesInfoWithPockets returns lanes information for the link which has given number of pocket lanes on the left and on the right sides.
+// Pockets are present in the last `pocketLength` meters of the link only
+func newLanesInfoWithPockets(link *Link, leftPockets int, rightPockets int, pocketLength float64) LanesInfo {
 	lanesInfo := LanesInfo{
 		LanesList:         make([]int, 0),
 		LanesChange:       make([][2]int, 0),
-		LanesChangePoints: make([]float64, 0),
-	}
-	if link.lengthMeters < resolution {
-		lanesInfo.LanesChangePoints = []float64{0.0, link.lengthMeters}
-	} else {
-		lanesChangePointsTemp := []float64{0.0, link.lengthMeters}
-		for len(lanesChangePointsTemp) != 0 {
-			target := lanesChangePointsTemp[0]
-			remove := make(map[int]struct{})
-			for idx, point := range lanesChangePointsTemp {
-				if target-resolution <= point && point <= target+resolution {
-					remove[idx] = struct{}{}
-				}
-			}
-			lanesInfo.LanesChangePoints = append(lanesInfo.LanesChangePoints, target)
-			for idx := range remove {
-				lanesChangePointsTemp = append(lanesChangePointsTemp[:idx], lanesChangePointsTemp[idx+1:]...)
-			}
+		LanesChangePoints: []float64{0.0, link.lengthMeters},
+	}
+	if link.lengthMeters >= resolution {
+		changePoints := []float64{0.0, link.lengthMeters}
+		if leftPockets+rightPockets > 0 && pocketLength > 0 {
+			// Pocket can't start right at the link's start: there should be space for lanes which are connected to upstream links
+			changePoints = append(changePoints, max(link.lengthMeters-pocketLength, 2*resolution))
 		}
-		sort.Float64s(lanesInfo.LanesChangePoints)
+		lanesInfo.LanesChangePoints = mergeChangePoints(changePoints, link.lengthMeters)
 	}
-	for i := 0; i < len(lanesInfo.LanesChangePoints)-1; i++ {
-		lanesInfo.LanesList = append(lanesInfo.LanesList, link.lanesNum)
-		lanesInfo.LanesChange = append(lanesInfo.LanesChange, [2]int{0.0, 0.0})
+	segmentsNum := len(lanesInfo.LanesChangePoints) - 1
+	for i := 0; i < segmentsNum; i++ {
+		if i == segmentsNum-1 {
+			lanesInfo.LanesList = append(lanesInfo.LanesList, link.lanesNum)
+			lanesInfo.LanesChange = append(lanesInfo.LanesChange, [2]int{0, 0})
+			continue
+		}
+		lanesInfo.LanesList = append(lanesInfo.LanesList, link.lanesNum-leftPockets-rightPockets)
+		lanesInfo.LanesChange = append(lanesInfo.LanesChange, [2]int{-leftPockets, -rightPockets})
 	}
 	return lanesInfo
 }
 
+// mergeChangePoints sorts change points and drops ones which are closer than resolution to the previous point.
+// Start and end of the link are always kept
+func mergeChangePoints(changePoints []float64, length float64) []float64 {
+	sort.Float64s(changePoints)
+	merged := []float64{0.0}
+	for _, point := range changePoints {
+		if point-merged[len(merged)-1] > resolution && length-point > resolution {
+			merged = append(merged, point)
+		}
+	}
+	return append(merged, length)
+}
+
 func laneIndices(lanes int, lanesChangeLeft int, lanesChangeRight int) []int {
-	if lanes < lanesChangeLeft || lanes < lanesChangeRight {
+	if lanes < lanesChangeLeft || lanes < lanesChangeRight || lanes+min(lanesChangeLeft, 0)+min(lanesChangeRight, 0) <= 0 {
 		return make([]int, 0)
 	}
 	laneIndices := make([]int, lanes)
@@ -64,7 +87,7 @@ func laneIndices(lanes int, lanesChangeLeft int, lanesChangeRight int) []int {
 		laneIndices = append(left, laneIndices...)
 	}
 	if lanesChangeRight < 0 {
-		laneIndices = laneIndices[:lanes+lanesChangeRight]
+		laneIndices = laneIndices[:len(laneIndices)+lanesChangeRight]
 	} else if lanesChangeRight > 0 {
 		right := make([]int, lanesChangeRight)
 		for i := range right {
@@ -74,3 +97,128 @@ func laneIndices(lanes int, lanesChangeLeft int, lanesChangeRight int) []int {
 	}
 	return laneIndices
 }
+
+// parseTurnLanes splits value of `turn:lanes` tag into the turn directions of every lane (from the left to the right)
+func parseTurnLanes(value string) [][]string {
+	if value == "" {
+		return nil
+	}
+	lanes := strings.Split(value, "|")
+	turnLanes := make([][]string, len(lanes))
+	for i, lane := range lanes {
+		for _, turn := range strings.Split(lane, ";") {
+			turnLanes[i] = append(turnLanes[i], strings.TrimSpace(turn))
+		}
+	}
+	return turnLanes
+}
+
+var (
+	leftTurns = map[string]struct{}{
+		"left":        {},
+		"slight_left": {},
+		"sharp_left":  {},
+	}
+	rightTurns = map[string]struct{}{
+		"right":        {},
+		"slight_right": {},
+		"sharp_right":  {},
+	}
+)
+
+// turnOnlyLanes returns number of consecutive inner-turn-only lanes on the inner side and outer-turn-only lanes on the outer side.
+// Lanes should be ordered from the inner side. At least one lane is always kept for other directions
+func turnOnlyLanes(turnLanes [][]string, innerTurns map[string]struct{}, outerTurns map[string]struct{}) (int, int) {
+	isOnly := func(turns []string, allowed map[string]struct{}, allowReverse bool) bool {
+		for _, turn := range turns {
+			if _, ok := allowed[turn]; !ok && !(allowReverse && turn == "reverse") {
+				return false
+			}
+		}
+		return len(turns) > 0
+	}
+	left := 0
+	// U-turns are made from the inner side regardless of driving side
+	for left < len(turnLanes)-1 && isOnly(turnLanes[left], innerTurns, true) {
+		left++
+	}
+	right := 0
+	for right < len(turnLanes)-1-left && isOnly(turnLanes[len(turnLanes)-1-right], outerTurns, false) {
+		right++
+	}
+	return left, right
+}
+
+// preparePockets fills lanes information of links with respect to turn pockets
+func (net *Net) preparePockets(pocketLength float64) {
+	for _, link := range net.Links {
+		leftPockets, rightPockets := 0, 0
+		if pocketLength > 0 {
+			leftPockets, rightPockets = net.findPockets(link, pocketLength)
+		}
+		link.leftPockets, link.rightPockets, link.pocketLength = leftPockets, rightPockets, pocketLength
+		link.lanesInfo = newLanesInfoWithPockets(link, leftPockets, rightPockets, pocketLength)
+	}
+}
+
+// findPockets returns number of pocket lanes on the inner and on the outer sides of the link.
+// Pockets are turn-only lanes from `turn:lanes` tag. If the link continues another one then only extra lanes could be pockets;
+// extra lanes of short links without `turn:lanes` tag are considered as inner pockets (left for right-hand traffic)
+func (net *Net) findPockets(link *Link, pocketLength float64) (int, int) {
+	left, right := 0, 0
+	if len(link.turnLanes) == link.lanesNum {
+		if net.drivingSide == types.DRIVING_SIDE_LEFT {
+			// Tag lists lanes from the left to the right while lanes are numbered from the inner side
+			turnLanes := make([][]string, len(link.turnLanes))
+			for i := range link.turnLanes {
+				turnLanes[i] = link.turnLanes[len(link.turnLanes)-1-i]
+			}
+			left, right = turnOnlyLanes(turnLanes, rightTurns, leftTurns)
+		} else {
+			left, right = turnOnlyLanes(link.turnLanes, leftTurns, rightTurns)
+		}
+	}
+	maxPockets := link.lanesNum - 1
+	if upstreamLink := net.findUpstreamLink(link); upstreamLink != nil {
+		maxPockets = link.lanesNum - upstreamLink.lanesNum
+		if maxPockets <= 0 {
+			return 0, 0
+		}
+		if left+right == 0 && link.lengthMeters <= 2*pocketLength {
+			left = maxPockets
+		}
+	}
+	left = min(left, maxPockets)
+	right = min(right, maxPockets-left)
+	return left, right
+}
+
+// findUpstreamLink returns the only link which is continued by the given one. Reverse directions are ignored. Returns nil if there are several (or none) candidates
+func (net *Net) findUpstreamLink(link *Link) *Link {
+	sourceNode, ok := net.Nodes[link.sourceNodeID]
+	if !ok {
+		return nil
+	}
+	var upstreamLink *Link
+	for _, incomingLinkID := range sourceNode.incomingLinks {
+		incomingLink, ok := net.Links[incomingLinkID]
+		if !ok || incomingLink.sourceNodeID == link.targetNodeID {
+			continue
+		}
+		if upstreamLink != nil {
+			return nil
+		}
+		upstreamLink = incomingLink
+	}
+	if upstreamLink == nil {
+		return nil
+	}
+	for _, outcomingLinkID := range sourceNode.outcomingLinks {
+		outcomingLink, ok := net.Links[outcomingLinkID]
+		if !ok || outcomingLink.ID == link.ID || outcomingLink.targetNodeID == upstreamLink.sourceNodeID {
+			continue
+		}
+		return nil
+	}
+	return upstreamLink
+}
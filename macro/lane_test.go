@@ -0,0 +1,68 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanesInfoPockets(t *testing.T) {
+	turnLanes := parseTurnLanes("left|left;through|through|right")
+	left, right := turnOnlyLanes(turnLanes, leftTurns, rightTurns)
+	assert.Equal(t, 1, left, "Shared left lane should not be a pocket")
+	assert.Equal(t, 1, right, "Wrong number of right pockets")
+
+	link := &Link{lanesNum: 4, lengthMeters: 100}
+	lanesInfo := newLanesInfoWithPockets(link, left, right, 40)
+	assert.Equal(t, []float64{0, 60, 100}, lanesInfo.LanesChangePoints, "Wrong change points")
+	assert.Equal(t, []int{2, 4}, lanesInfo.LanesList, "Wrong lanes list")
+	assert.Equal(t, [][2]int{{-1, -1}, {0, 0}}, lanesInfo.LanesChange, "Wrong lanes change")
+	link.lanesInfo = lanesInfo
+	assert.Equal(t, []int{2, 3}, link.GetIncomingLaneIndices(), "Pockets should be dropped upstream")
+	assert.Equal(t, []int{1, 2, 3, 4}, link.GetOutcomingLaneIndices(), "Pockets should be present downstream")
+
+	// Pocket is longer than the link: it starts right after the space for upstream connections
+	short := &Link{lanesNum: 3, lengthMeters: 30}
+	assert.Equal(t, []float64{0, 10, 30}, newLanesInfoWithPockets(short, 1, 0, 40).LanesChangePoints, "Wrong change points for short link")
+	// Link is too short for any pocket
+	tiny := &Link{lanesNum: 3, lengthMeters: 8}
+	assert.Equal(t, []int{3}, newLanesInfoWithPockets(tiny, 1, 0, 40).LanesList, "Tiny link should not have pockets")
+}
+
+func TestGenerateMovementsPockets(t *testing.T) {
+	// Four-lane approach 0-1 with turn-only lanes meets crossing road 2-1-3
+	net := newTestNet(map[gmns.NodeID]orb.Point{
+		0: {-8 * testStep, 0},
+		1: {0, 0},
+		2: {0, testStep},
+		3: {0, -testStep},
+		4: {testStep, 0},
+	})
+	approachID := net.addLink(0, 1, func(link *Link) {
+		link.lanesNum = 4
+		link.turnLanes = parseTurnLanes("left|through|through|right")
+	})
+	net.addLink(1, 2)
+	net.addLink(1, 3)
+	net.addLink(1, 4, func(link *Link) {
+		link.lanesNum = 2
+	})
+
+	_, err := net.GenerateMovements()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4}, net.Links[approachID].lanesInfo.LanesList, "Pockets should be disabled by default")
+
+	_, err = net.GenerateMovements(WithPocketLength(40))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, net.Links[approachID].lanesInfo.LanesList, "Turn-only lanes should become pockets")
+	assert.Equal(t, [][2]int{{-1, -1}, {0, 0}}, net.Links[approachID].lanesInfo.LanesChange, "Wrong lanes change")
+}
+
+func TestLaneIndices(t *testing.T) {
+	assert.Equal(t, []int{-1, 1, 2}, laneIndices(2, 1, 0), "Left pocket should have negative index")
+	assert.Equal(t, []int{1, 2, 3}, laneIndices(2, 0, 1), "Right pocket should follow the last lane")
+	assert.Equal(t, []int{-1, 1}, laneIndices(3, 1, -2), "Wrong indices for mixed changes")
+	assert.Empty(t, laneIndices(2, -1, -1), "All lanes are dropped")
+}
@@ -28,13 +28,16 @@ type Link struct {
 	freeSpeed          float64
 	maxSpeed           float64
 	capacity           int
+	volume             float64
 	ID                 gmns.LinkID
 	osmWayID           osm.WayID
 	linkClass          types.LinkClass
 	linkType           types.LinkType
 	linkConnectionType types.LinkConnectionType
 	controlType        types.ControlType
+	isPriorityRoad     bool
 	allowedAgentTypes  []types.AgentType
+	restrictions       types.VehicleRestrictions
 	sourceNodeID       gmns.NodeID
 	targetNodeID       gmns.NodeID
 
@@ -42,8 +45,18 @@ type Link struct {
 	targetOsmNodeID osm.NodeID
 
 	wasBidirectional bool
+	isRoundabout     bool
+	roundaboutID     int
+	// Direction relative to the source OSM way
+	direction DirectionType
 
 	lanesNum int
+	// Turn directions for every lane (from the left to the right as in the tag). See ref.: https://wiki.openstreetmap.org/wiki/Key:turn
+	turnLanes [][]string
+	// Number of pocket lanes on the inner and on the outer sides and length (meters) of pockets (see preparePockets)
+	leftPockets  int
+	rightPockets int
+	pocketLength float64
 	/* For Mesoscopic and Microscopic */
 	mesolinks              []gmns.LinkID
 	lanesInfo              LanesInfo
@@ -58,8 +71,9 @@ type Link struct {
 	downstreamShortCut bool
 	upstreamShortCut   bool
 
-	downstreamIsTarget bool
-	upstreamIsTarget   bool
+	// Link is cut back from the target (downstream) or the source (upstream) node since the node has movements
+	downstreamIsCut bool
+	upstreamIsCut   bool
 
 	upstreamCutLen   float64
 	downstreamCutLen float64
@@ -80,6 +94,15 @@ func (link *Link) GetOutcomingLanes() int {
 	return link.lanesInfo.LanesList[idx]
 }
 
+func (link *Link) GetIncomingLaneIndices() []int {
+	lanesInfo := link.lanesInfo
+	if len(lanesInfo.LanesChange) == 0 {
+		log.Warn().Str("scope", "macro_internal").Int("macro_link_id", int(link.ID)).Msg("Macroscopic link has no lanes change")
+		return make([]int, 0)
+	}
+	return laneIndices(link.lanesNum, lanesInfo.LanesChange[0][0], lanesInfo.LanesChange[0][1])
+}
+
 func (link *Link) GetOutcomingLaneIndices() []int {
 	lanesInfo := link.lanesInfo
 	idx := len(lanesInfo.LanesChange) - 1
@@ -112,6 +135,7 @@ func NewLinkFrom(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, sourceO
 		freeSpeed:          freeSpeed,
 		maxSpeed:           maxSpeed,
 		capacity:           capacity,
+		volume:             -1,
 		ID:                 id,
 		osmWayID:           way.ID,
 		linkClass:          way.LinkClass,
@@ -123,6 +147,11 @@ func NewLinkFrom(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, sourceO
 		targetOsmNodeID:    targetOSMNodeID,
 		controlType:        types.CONTROL_TYPE_NOT_SIGNAL,
 		allowedAgentTypes:  make([]types.AgentType, len(way.AllowedAgentTypes)),
+		restrictions:       way.Tags.VehicleRestrictions,
+		direction:          direction,
+		isPriorityRoad:     way.Tags.IsPriorityRoad(),
+		isRoundabout:       way.IsRoundabout,
+		roundaboutID:       -1,
 	}
 	copy(link.allowedAgentTypes, way.AllowedAgentTypes)
 
@@ -156,15 +185,38 @@ func NewLinkFrom(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, sourceO
 	if link.lanesNum <= 0 {
 		link.lanesNum = types.NewLanesDefault(link.linkType)
 	}
+	if way.IsOneWay {
+		link.turnLanes = parseTurnLanes(way.Tags.TurnLanes())
+	} else if direction == DIRECTION_FORWARD {
+		link.turnLanes = parseTurnLanes(way.Tags.TurnLanesForward())
+	} else {
+		link.turnLanes = parseTurnLanes(way.Tags.TurnLanesBackward())
+	}
 
-	// Walk all segment nodes except the first and the last one to detect links under traffic light control
+	// Walk all segment nodes except the first and the last one to detect links under traffic light (or other kind of) control
+	segmentDistances := cumulativeDistances(segmentNodes)
+	segmentLength := segmentDistances[len(segmentDistances)-1]
 	for i := 1; i < len(segmentNodes)-1; i++ {
 		node := segmentNodes[i]
-		if node.ControlType == types.CONTROL_TYPE_IS_SIGNAL {
-			link.controlType = node.ControlType
+		if controlAppliesToLink(node, direction, segmentDistances[i], segmentLength) {
+			link.controlType = types.PriorControlType(link.controlType, node.ControlType)
+		}
+		// Barriers, bridges and etc. could restrict the whole link
+		link.restrictions = link.restrictions.Tightest(node.VehicleRestrictions)
+		if len(node.BarrierBlockedAgentTypes) > 0 {
+			link.allowedAgentTypes = types.AgentsExclude(link.allowedAgentTypes, node.BarrierBlockedAgentTypes)
 		}
 	}
 
+	// Directional stop and give way signs on the junction node itself are related to the approaching link only
+	targetNode := segmentNodes[len(segmentNodes)-1]
+	if direction == DIRECTION_BACKWARD {
+		targetNode = segmentNodes[0]
+	}
+	if targetNode.ControlType.IsApproachControl() && targetNode.ControlDirection != types.CONTROL_DIRECTION_BOTH && controlDirectionMatches(targetNode.ControlDirection, direction) {
+		link.controlType = types.PriorControlType(link.controlType, targetNode.ControlType)
+	}
+
 	// Prepare geometry
 	link.geom = make(orb.LineString, 0, len(segmentNodes))
 	switch direction {
@@ -189,3 +241,104 @@ func NewLinkFrom(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, sourceO
 	link.lanesInfo = NewLanesInfo(&link)
 	return &link
 }
+
+// GetSourceNodeID returns identifier of the source node
+func (link *Link) GetSourceNodeID() gmns.NodeID {
+	return link.sourceNodeID
+}
+
+// GetTargetNodeID returns identifier of the target node
+func (link *Link) GetTargetNodeID() gmns.NodeID {
+	return link.targetNodeID
+}
+
+// GetLinkType returns type of the link
+func (link *Link) GetLinkType() types.LinkType {
+	return link.linkType
+}
+
+// GetFreeSpeed returns free speed (km/h) of the link
+func (link *Link) GetFreeSpeed() float64 {
+	return link.freeSpeed
+}
+
+// GetLengthMeters returns length (meters) of the link
+func (link *Link) GetLengthMeters() float64 {
+	return link.lengthMeters
+}
+
+// GetCapacity returns capacity (veh/h/lane) of the link
+func (link *Link) GetCapacity() int {
+	return link.capacity
+}
+
+// GetLanesNum returns number of lanes of the link
+func (link *Link) GetLanesNum() int {
+	return link.lanesNum
+}
+
+// GetVolume returns assigned volume (veh/h) of the link. It is -1 if no assignment has been done
+func (link *Link) GetVolume() float64 {
+	return link.volume
+}
+
+// SetVolume sets assigned volume (veh/h) of the link
+func (link *Link) SetVolume(volume float64) {
+	link.volume = volume
+}
+
+// GetAllowedAgentTypes returns agent types which are allowed to use the link
+func (link *Link) GetAllowedAgentTypes() []types.AgentType {
+	return link.allowedAgentTypes
+}
+
+// GetLanesListCut returns number of lanes for every part of the link after mesoscopic cuts
+func (link *Link) GetLanesListCut() []int {
+	return link.lanesListCut
+}
+
+// GetLaneIndicesCut returns lane indices for every part of the link after mesoscopic cuts
+func (link *Link) GetLaneIndicesCut() [][]int {
+	indices := make([][]int, len(link.lanesChangeCut))
+	for i, lanesChange := range link.lanesChangeCut {
+		indices[i] = laneIndices(link.lanesNum, lanesChange[0], lanesChange[1])
+	}
+	return indices
+}
+
+// GetGeomOffsetCut returns geometry (EPSG:4326) for every part of the link after mesoscopic cuts
+func (link *Link) GetGeomOffsetCut() []orb.LineString {
+	return link.geomOffsetCut
+}
+
+// IsUpstreamCut returns true if the link has been cut back from its source node
+func (link *Link) IsUpstreamCut() bool {
+	return link.upstreamIsCut
+}
+
+// IsDownstreamCut returns true if the link has been cut back from its target node
+func (link *Link) IsDownstreamCut() bool {
+	return link.downstreamIsCut
+}
+
+// GetMesoLinks returns identifiers of mesoscopic links which have been generated for the link
+func (link *Link) GetMesoLinks() []gmns.LinkID {
+	return link.mesolinks
+}
+
+// SetMesoLinks sets identifiers of mesoscopic links which have been generated for the link
+// Notice: it copies given slice
+func (link *Link) SetMesoLinks(mesolinks []gmns.LinkID) {
+	link.mesolinks = make([]gmns.LinkID, len(mesolinks))
+	copy(link.mesolinks, mesolinks)
+}
+
+// GetRoundaboutID returns identifier of the roundabout which link belongs to (-1 if there is no such)
+func (link *Link) GetRoundaboutID() int {
+	return link.roundaboutID
+}
+
+// GetGeom returns geometry (EPSG:4326) of the link
+func (link *Link) GetGeom() orb.LineString {
+	return link.geom
+}
@@ -0,0 +1,128 @@
+package macro
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+const (
+	// Min length (meters) of the link's part which remains after cuts near intersections
+	minCutLinkLength = 2.0
+)
+
+var (
+	// Length (meters) of the cut near intersection. Index is number of lanes
+	cutLengthByLanes = []float64{2.0, 8.0, 12.0, 14.0, 16.0, 18.0, 20.0, 22.0, 24.0}
+)
+
+// cutLength returns length (meters) of the cut near intersection for given number of lanes
+func cutLength(lanesNum int) float64 {
+	if lanesNum < 0 {
+		return cutLengthByLanes[0]
+	}
+	if lanesNum >= len(cutLengthByLanes) {
+		return cutLengthByLanes[len(cutLengthByLanes)-1]
+	}
+	return cutLengthByLanes[lanesNum]
+}
+
+// PrepareMesoscopicCuts prepares links for mesoscopic network generation:
+// 1. Geometry of links which were bidirectional is shifted to the driving side (right for right-hand traffic) by half of lanes width
+// 2. Links are cut back from nodes which have movements, so there is room for movements connectors
+// 3. Remaining part of the link is split at lane change points
+// laneWidth - width of the single lane (meters)
+func (net *Net) PrepareMesoscopicCuts(mvmtStorage *movement.MovementsStorage, laneWidth float64) {
+	nodesWithMovements := make(map[gmns.NodeID]struct{})
+	for _, mvmt := range mvmtStorage.List() {
+		nodesWithMovements[mvmt.MacroNodeID] = struct{}{}
+	}
+	for _, link := range net.Links {
+		link.prepareCut(nodesWithMovements, laneWidth, net.drivingSide)
+	}
+}
+
+// prepareCut evaluates offset geometry, cuts lengths and lanes for every part of the link after cut
+func (link *Link) prepareCut(nodesWithMovements map[gmns.NodeID]struct{}, laneWidth float64, drivingSide types.DrivingSide) {
+	link.geomOffset = link.geom
+	link.geomEuclideanOffset = link.geomEuclidean
+	if link.wasBidirectional && len(link.geom) > 1 {
+		scale := geomath.EuclideanScale(link.geom[0].Lat())
+		// Shift to the driving side: positive offset is for the left side
+		offset := -laneWidth * float64(link.lanesNum) / 2.0 * scale
+		if drivingSide == types.DRIVING_SIDE_LEFT {
+			offset = -offset
+		}
+		link.geomEuclideanOffset = geomath.OffsetCurve(link.geomEuclidean, offset)
+		link.geomOffset = geomath.LineToSpherical(link.geomEuclideanOffset)
+	}
+	link.lengthMetersOffset = geo.LengthHaversine(link.geomOffset)
+
+	_, link.upstreamIsCut = nodesWithMovements[link.sourceNodeID]
+	_, link.downstreamIsCut = nodesWithMovements[link.targetNodeID]
+	link.upstreamCutLen = 0
+	link.downstreamCutLen = 0
+	link.upstreamShortCut = false
+	link.downstreamShortCut = false
+	if link.upstreamIsCut {
+		link.upstreamCutLen = cutLength(link.GetIncomingLanes())
+	}
+	if link.downstreamIsCut {
+		link.downstreamCutLen = cutLength(link.GetOutcomingLanes())
+	}
+	// Link is too short for full cuts: shrink them proportionally
+	availableLength := math.Max(link.lengthMetersOffset-minCutLinkLength, 0)
+	if totalCut := link.upstreamCutLen + link.downstreamCutLen; totalCut > availableLength {
+		ratio := availableLength / totalCut
+		link.upstreamCutLen *= ratio
+		link.downstreamCutLen *= ratio
+		link.upstreamShortCut = link.upstreamIsCut
+		link.downstreamShortCut = link.downstreamIsCut
+	}
+
+	// Lane change points are evaluated for original geometry
+	scale := 1.0
+	if link.lengthMeters > 0 {
+		scale = link.lengthMetersOffset / link.lengthMeters
+	}
+	startCut := link.upstreamCutLen
+	endCut := link.lengthMetersOffset - link.downstreamCutLen
+	breakpoints := []float64{startCut}
+	changePoints := link.lanesInfo.LanesChangePoints
+	for i := 1; i < len(changePoints)-1; i++ {
+		point := changePoints[i] * scale
+		if point > startCut && point < endCut {
+			breakpoints = append(breakpoints, point)
+		}
+	}
+	breakpoints = append(breakpoints, endCut)
+
+	link.lanesListCut = make([]int, 0, len(breakpoints)-1)
+	link.lanesChangeCut = make([][2]int, 0, len(breakpoints)-1)
+	link.geomOffsetCut = make([]orb.LineString, 0, len(breakpoints)-1)
+	link.geomEuclideanOffsetCut = make([]orb.LineString, 0, len(breakpoints)-1)
+	for i := 0; i < len(breakpoints)-1; i++ {
+		// Lanes of the part are the lanes of original segment which contains middle of the part
+		middle := (breakpoints[i] + breakpoints[i+1]) / 2.0 / scale
+		segmentIdx := 0
+		for segmentIdx < len(link.lanesInfo.LanesList)-1 && middle > changePoints[segmentIdx+1] {
+			segmentIdx++
+		}
+		lanesNum := link.lanesNum
+		lanesChange := [2]int{0, 0}
+		if segmentIdx < len(link.lanesInfo.LanesList) {
+			lanesNum = link.lanesInfo.LanesList[segmentIdx]
+			lanesChange = link.lanesInfo.LanesChange[segmentIdx]
+		}
+		geomCut := geomath.SubstringHaversine(link.geomOffset, breakpoints[i], breakpoints[i+1])
+		link.lanesListCut = append(link.lanesListCut, lanesNum)
+		link.lanesChangeCut = append(link.lanesChangeCut, lanesChange)
+		link.geomOffsetCut = append(link.geomOffsetCut, geomCut)
+		link.geomEuclideanOffsetCut = append(link.geomEuclideanOffsetCut, geomath.LineToEuclidean(geomCut))
+	}
+}
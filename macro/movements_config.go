@@ -0,0 +1,118 @@
+package macro
+
+import (
+	"runtime"
+
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+)
+
+// MovementsConfig is the set of parameters for movements generation
+type MovementsConfig struct {
+	turnProfile *movement.TurnPenaltyProfile
+	geomConfig  *movement.GeomConfig
+	classifier  *movement.ClassifierConfig
+	startID     movement.MovementID
+	// Max number of nodes which are processed simultaneously
+	concurrency int
+	// Length (meters) of turn pockets
+	pocketLength float64
+	// Driving side is inherited from the network
+	drivingSide types.DrivingSide
+}
+
+// NewMovementsConfigDefault returns default parameters for movements generation
+func NewMovementsConfigDefault() *MovementsConfig {
+	return &MovementsConfig{
+		turnProfile: movement.NewTurnPenaltyProfileDefault(),
+		geomConfig:  movement.NewGeomConfigDefault(),
+		classifier:  movement.NewClassifierConfigDefault(),
+		startID:     0,
+		concurrency: runtime.NumCPU(),
+	}
+}
+
+// WithTurnPenaltyProfile sets profile which is used to evaluate penalty, capacity and free speed of movements
+// Notice: nil value disables evaluation, so -1 values will be exported
+func WithTurnPenaltyProfile(profile *movement.TurnPenaltyProfile) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.turnProfile = profile
+	}
+}
+
+// WithGeomConfig sets configuration for movements geometry (e.g. curved turning paths)
+// Notice: nil value means default configuration
+func WithGeomConfig(geomConfig *movement.GeomConfig) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.geomConfig = geomConfig
+	}
+}
+
+// WithClassifierConfig sets configuration for movements classification (angle thresholds, bounds, U-turn policy)
+// Notice: nil value means default configuration
+func WithClassifierConfig(classifier *movement.ClassifierConfig) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.classifier = classifier
+	}
+}
+
+// WithMovementsStartID sets identifier of the first movement
+func WithMovementsStartID(startID movement.MovementID) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.startID = startID
+	}
+}
+
+// WithConcurrency sets max number of nodes which are processed simultaneously
+// Notice: values less than 1 are treated as 1 (sequential processing)
+func WithConcurrency(concurrency int) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.concurrency = concurrency
+	}
+}
+
+// WithPocketLength sets length (meters) of turn pockets. Pockets are prepared before movements, so they affect lane indices of movements
+// Pockets are disabled by default, so lanes of links are not changed unless this option is used (40 meters is a reasonable value)
+// Notice: zero or negative value disables pockets
+func WithPocketLength(pocketLength float64) func(*MovementsConfig) {
+	return func(cfg *MovementsConfig) {
+		cfg.pocketLength = pocketLength
+	}
+}
+
+// applyDrivingSide makes copies of classifier and geometry configurations which are mirrored for left-hand traffic
+// Nil configurations are replaced with default ones, since movements could not be classified and shaped without them
+func (cfg *MovementsConfig) applyDrivingSide(drivingSide types.DrivingSide) {
+	cfg.drivingSide = drivingSide
+	if cfg.classifier == nil {
+		cfg.classifier = movement.NewClassifierConfigDefault()
+	}
+	if cfg.geomConfig == nil {
+		cfg.geomConfig = movement.NewGeomConfigDefault()
+	}
+	classifier := *cfg.classifier
+	classifier.DrivingSide = drivingSide
+	cfg.classifier = &classifier
+	if drivingSide == types.DRIVING_SIDE_LEFT {
+		geomConfig := *cfg.geomConfig
+		geomConfig.LaneOffset = -geomConfig.LaneOffset
+		cfg.geomConfig = &geomConfig
+	}
+}
+
+// turnAttributes returns options which set penalty, capacity and free speed for the movement between given links
+func (cfg *MovementsConfig) turnAttributes(incomingLink, outcomingLink *Link, mvmtType movement.MovementType, controlType types.ControlType, lanesNum int, mvmtGeom orb.LineString) []func(*movement.Movement) {
+	if cfg.turnProfile == nil {
+		return nil
+	}
+	turnAngle := movement.FindTurnAngle(incomingLink.geomEuclidean, outcomingLink.geomEuclidean)
+	turnRadius := movement.FindTurnRadius(mvmtGeom, turnAngle)
+	// Profile is defined for right-hand traffic
+	mvmtType = mvmtType.RightHandEquivalent(cfg.drivingSide)
+	return []func(*movement.Movement){
+		movement.WithPenalty(cfg.turnProfile.Penalty(mvmtType, controlType, turnAngle)),
+		movement.WithCapacity(cfg.turnProfile.Capacity(mvmtType, controlType, lanesNum, incomingLink.capacity)),
+		movement.WithFreeSpeed(cfg.turnProfile.FreeSpeed(turnRadius, incomingLink.freeSpeed, outcomingLink.freeSpeed)),
+	}
+}
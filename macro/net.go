@@ -2,10 +2,12 @@ package macro
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/LdDl/osm2gmns/gmns"
 	"github.com/LdDl/osm2gmns/movement"
 	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/utils"
 	"github.com/LdDl/osm2gmns/wrappers"
 	"github.com/paulmach/osm"
 	"github.com/pkg/errors"
@@ -13,14 +15,31 @@ import (
 
 var (
 	ErrLinkNotFound = fmt.Errorf("Link not found")
+	ErrNodeNotFound = fmt.Errorf("Node not found")
 )
 
 type Net struct {
 	Nodes map[gmns.NodeID]*Node
 	Links map[gmns.LinkID]*Link
+
+	drivingSide types.DrivingSide
+	// Zones and centroids have been attached to the network (see AttachZones)
+	zonesAttached bool
+}
+
+// WithDrivingSide sets driving side of the network. Right-hand traffic is used by default
+func WithDrivingSide(drivingSide types.DrivingSide) func(*Net) {
+	return func(net *Net) {
+		net.drivingSide = drivingSide
+	}
 }
 
-func NewNetFromOSM(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM) (*Net, error) {
+// DrivingSide returns driving side of the network
+func (net *Net) DrivingSide() types.DrivingSide {
+	return net.drivingSide
+}
+
+func NewNetFromOSM(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM, options ...func(*Net)) (*Net, error) {
 
 	lastLinkID := gmns.LinkID(0)
 	lastNodeID := gmns.NodeID(0)
@@ -94,18 +113,27 @@ func NewNetFromOSM(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.No
 	}
 
 	net := &Net{Nodes: nodes, Links: links}
+	for _, option := range options {
+		option(net)
+	}
+	net.detectRoundabouts()
 	net.genBoundaryAndActivityType()
 	return net, nil
 }
 
 func prepareSegments(way *wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM) (segments [][]osm.NodeID, err error) {
-	nodesNum := len(way.Nodes)
+	wayNodes := way.Nodes
+	if way.IsCycle {
+		// Closed way should be splitted at crossings only (e.g. roundabout's entries and exits), not at arbitrary first node
+		wayNodes = rotateCycle(way.Nodes, nodesSet)
+	}
+	nodesNum := len(wayNodes)
 	lastNodeIdx := 0
 	idx := 0
 	for {
-		segmentNodes := []osm.NodeID{way.Nodes[lastNodeIdx]}
+		segmentNodes := []osm.NodeID{wayNodes[lastNodeIdx]}
 		for idx = lastNodeIdx + 1; idx < nodesNum; idx++ {
-			nextNodeID := way.Nodes[idx]
+			nextNodeID := wayNodes[idx]
 			nextNode, ok := nodesSet[nextNodeID]
 			if !ok {
 				return segments, fmt.Errorf("no such node: %d", nextNodeID)
@@ -124,6 +152,25 @@ func prepareSegments(way *wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.Nod
 	return segments, nil
 }
 
+// rotateCycle returns nodes of the closed way started (and finished) at the first crossing node
+// Notice: it returns given slice when there are no crossings or way is started at crossing already
+func rotateCycle(wayNodes []osm.NodeID, nodesSet map[osm.NodeID]*wrappers.NodeOSM) []osm.NodeID {
+	for idx := 0; idx < len(wayNodes)-1; idx++ {
+		node, ok := nodesSet[wayNodes[idx]]
+		if !ok || !node.IsCrossing {
+			continue
+		}
+		if idx == 0 {
+			return wayNodes
+		}
+		rotated := make([]osm.NodeID, 0, len(wayNodes))
+		rotated = append(rotated, wayNodes[idx:len(wayNodes)-1]...)
+		rotated = append(rotated, wayNodes[:idx+1]...)
+		return rotated
+	}
+	return wayNodes
+}
+
 // genBoundaryAndActivityType updated BoundaryType, ActivityType, ActivityLinkType for nodes
 // In case when counters for acitivites are equal prioritization will be used
 func (net *Net) genBoundaryAndActivityType() error {
@@ -209,28 +256,69 @@ func (net *Net) genBoundaryAndActivityType() error {
 			}
 		}
 	}
+	if net.zonesAttached {
+		// Zones have been assigned by point-in-polygon already
+		return nil
+	}
 	for nodeID := range net.Nodes {
 		node := net.Nodes[nodeID]
 		if node.boundaryType == types.BOUNDARY_NONE {
 			continue
 		}
-		node.zoneID = node.ID
+		node.zoneID = gmns.ZoneID(node.ID)
 	}
 	return nil
 }
 
-func (net *Net) GenerateMovements() (movement.MovementsStorage, error) {
-	ans := movement.NewMovementsStorage()
-	for i := range net.Nodes {
-		node := net.Nodes[i]
-		movements, err := node.FindMovements(net.Links)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Can't find movements for macro node with ID: '%d' (osm: '%d')", node.ID, node.osmNodeID)
+func (net *Net) GenerateMovements(options ...func(*MovementsConfig)) (*movement.MovementsStorage, error) {
+	cfg := NewMovementsConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	cfg.applyDrivingSide(net.drivingSide)
+	net.preparePockets(cfg.pocketLength)
+
+	// Fixed order of nodes makes merge of results deterministic
+	nodesIDs := make([]gmns.NodeID, 0, len(net.Nodes))
+	for nodeID := range net.Nodes {
+		nodesIDs = append(nodesIDs, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+
+	allMovements, err := net.findMovements(nodesIDs, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ans := movement.NewMovementsStorage(movement.WithStartID(cfg.startID))
+	ans.AddMovements(allMovements)
+	return ans, nil
+}
+
+// findMovements finds movements for given nodes using up to cfg.concurrency workers
+// Result follows order of given nodes
+func (net *Net) findMovements(nodesIDs []gmns.NodeID, cfg *MovementsConfig) ([]movement.Movement, error) {
+	nodesMovements := make([][]movement.Movement, len(nodesIDs))
+	nodesErrors := make([]error, len(nodesIDs))
+	utils.RunWorkers(len(nodesIDs), max(cfg.concurrency, 1), func(jobs <-chan int) {
+		for idx := range jobs {
+			node := net.Nodes[nodesIDs[idx]]
+			movements, err := node.FindMovements(net.Links, cfg)
+			if err != nil {
+				nodesErrors[idx] = errors.Wrapf(err, "Can't find movements for macro node with ID: '%d' (osm: '%d')", node.ID, node.osmNodeID)
+				continue
+			}
+			nodesMovements[idx] = movements
 		}
-		for j := range movements {
-			mvmt := movements[j]
-			ans[mvmt.ID] = &mvmt
+	})
+
+	allMovements := []movement.Movement{}
+	for idx := range nodesIDs {
+		if nodesErrors[idx] != nil {
+			return nil, nodesErrors[idx]
 		}
+		allMovements = append(allMovements, nodesMovements[idx]...)
 	}
-	return ans, nil
+	return allMovements, nil
 }
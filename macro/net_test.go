@@ -0,0 +1,53 @@
+package macro
+
+import (
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+)
+
+// testStep is ~15 meters along the equator (degrees)
+const testStep = 0.000135
+
+// testNet wraps network which is built link by link for tests
+type testNet struct {
+	*Net
+	points map[gmns.NodeID]orb.Point
+}
+
+// newTestNet prepares network with nodes at given points (EPSG:4326) and no links
+func newTestNet(points map[gmns.NodeID]orb.Point) *testNet {
+	net := &testNet{
+		Net:    &Net{Nodes: make(map[gmns.NodeID]*Node), Links: make(map[gmns.LinkID]*Link)},
+		points: points,
+	}
+	for nodeID, pt := range points {
+		net.Nodes[nodeID] = &Node{ID: nodeID, intersectionID: -1, roundaboutID: -1, zoneID: -1, poiID: -1, geom: pt, geomEuclidean: geomath.PointToEuclidean(pt)}
+	}
+	return net
+}
+
+// addLink adds straight single-lane link for cars between given nodes. Options are applied before geometry is prepared
+func (net *testNet) addLink(source, target gmns.NodeID, options ...func(*Link)) gmns.LinkID {
+	link := &Link{ID: gmns.LinkID(len(net.Links)), sourceNodeID: source, targetNodeID: target, lanesNum: 1, allowedAgentTypes: []types.AgentType{types.AGENT_AUTO}, roundaboutID: -1}
+	for _, option := range options {
+		option(link)
+	}
+	link.setGeom(orb.LineString{net.points[source], net.points[target]})
+	net.Links[link.ID] = link
+	net.Nodes[source].outcomingLinks = append(net.Nodes[source].outcomingLinks, link.ID)
+	net.Nodes[target].incomingLinks = append(net.Nodes[target].incomingLinks, link.ID)
+	return link.ID
+}
+
+// addTwoWayLink adds pair of opposite links (forward and backward relative to the imaginary source way) between given nodes
+func (net *testNet) addTwoWayLink(source, target gmns.NodeID, options ...func(*Link)) (gmns.LinkID, gmns.LinkID) {
+	withDirection := func(direction DirectionType) []func(*Link) {
+		return append([]func(*Link){func(link *Link) {
+			link.direction = direction
+			link.wasBidirectional = true
+		}}, options...)
+	}
+	return net.addLink(source, target, withDirection(DIRECTION_FORWARD)...), net.addLink(target, source, withDirection(DIRECTION_BACKWARD)...)
+}
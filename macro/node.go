@@ -19,20 +19,29 @@ type Node struct {
 	ID               gmns.NodeID
 	osmNodeID        osm.NodeID
 	intersectionID   int
-	zoneID           gmns.NodeID
+	roundaboutID     int
+	zoneID           gmns.ZoneID
 	poiID            PoiID
 	controlType      types.ControlType
+	controlDirection types.ControlDirection
 	boundaryType     types.BoundaryType
 	activityType     types.ActivityType
 	activityLinkType types.LinkType
 	geom             orb.Point
 	geomEuclidean    orb.Point
 
+	barrierType              types.BarrierType
+	barrierBlockedAgentTypes []types.AgentType
+	isBarrierSplit           bool
+
+	// Ring of the roundabout which node belongs to (nil if there is no such or the ring could not be walked around)
+	roundabout *roundaboutRing
+
 	/* Mesoscopic */
 	movements        []*movement.Movement
 	movementIsNeeded bool
 
-	/* Not used */
+	// Node represents zone's centroid (see AttachZones)
 	isCentroid bool
 }
 
@@ -46,25 +55,34 @@ func NewNodeFrom(id gmns.NodeID, node *wrappers.NodeOSM) *Node {
 		ID:               id,
 		osmNodeID:        node.ID,
 		intersectionID:   -1,
+		roundaboutID:     -1,
 		zoneID:           -1,
 		poiID:            -1,
 		controlType:      node.ControlType,
+		controlDirection: node.ControlDirection,
 		boundaryType:     types.BOUNDARY_NONE,
 		geom:             node.InnerNode.Point(),
 		movementIsNeeded: true, // Consider all nodes as intersections by default
+		barrierType:      node.Barrier,
+		isBarrierSplit:   node.IsBarrierSplit,
 	}
+	newNode.barrierBlockedAgentTypes = make([]types.AgentType, len(node.BarrierBlockedAgentTypes))
+	copy(newNode.barrierBlockedAgentTypes, node.BarrierBlockedAgentTypes)
 	newNode.geomEuclidean = geomath.PointToEuclidean(newNode.geom)
 	return &newNode
 }
 
-func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movement, error) {
+func (node *Node) FindMovements(links map[gmns.LinkID]*Link, cfg *MovementsConfig) ([]movement.Movement, error) {
 	movements := []movement.Movement{}
 
 	income := len(node.incomingLinks)
 	outcome := len(node.outcomingLinks)
-	if income == 0 || outcome == 0 {
+	// There are no turns at zone's centroid
+	if income == 0 || outcome == 0 || node.isCentroid {
 		return movements, nil
 	}
+	// Major and minor approaches for unsignalized intersection (nil if there is no priority at all)
+	majorApproaches := node.findMajorApproaches(links)
 
 	if outcome == 1 {
 		// Merge
@@ -80,7 +98,7 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 			if !ok {
 				return nil, errors.Wrapf(ErrLinkNotFound, "Incoming Link ID: %d", incomingLinkID)
 			}
-			if incomingLink.sourceNodeID != outcomingLink.targetNodeID { // Ignore all reverse directions
+			if incomingLink.sourceNodeID != outcomingLink.targetNodeID || node.isReverseAllowed(cfg.classifier.UTurnPolicy, links) { // Ignore reverse directions unless policy allows them
 				incomingLinksList = append(incomingLinksList, incomingLink)
 			}
 		}
@@ -88,8 +106,8 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 			return movements, nil
 		}
 
-		connections := getSpansConnections(outcomingLink, incomingLinksList)
-		incomingLaneIndices := outcomingLink.GetOutcomingLaneIndices()
+		connections := getSpansConnections(outcomingLink, incomingLinksList, cfg.drivingSide)
+		incomingLaneIndices := outcomingLink.GetIncomingLaneIndices()
 		for i := range incomingLinksList {
 			incomingLink := incomingLinksList[i]
 			incomeLaneIndexStart := connections[i][0].first
@@ -98,22 +116,41 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 			outcomeLaneIndexEnd := connections[i][1].second
 			lanesNum := incomeLaneIndexEnd - incomeLaneIndexStart + 1
 
+			// Barrier on the node could block some agents
+			allowedAgentTypes := types.AgentsExclude(incomingLink.allowedAgentTypes, node.barrierBlockedAgentTypes)
+			if len(allowedAgentTypes) == 0 {
+				continue
+			}
 			outcomingLaneIndices := incomingLink.GetOutcomingLaneIndices()
-			mvmtTextID, mvmtType := movement.FindMovementType(incomingLink.geomEuclidean, outcomingLink.geomEuclidean)
-			mvmtGeom := movement.FindMovementGeom(incomingLink.geom, outcomingLink.geom)
-			mvmt := movement.NewMovement(
-				node.ID, incomingLink.ID, outcomingLinkID, mvmtTextID, mvmtType, mvmtGeom,
+			mvmtTextID, mvmtType := movement.FindMovementTypeWith(incomingLink.geomEuclidean, outcomingLink.geomEuclidean, cfg.classifier)
+			if mvmtType == movement.MOVEMENT_TYPE_U_TURN && incomingLink.sourceNodeID != outcomingLink.targetNodeID && !node.isUTurnAllowed(cfg.classifier.UTurnPolicy, incomingLink, outcomingLink, links) {
+				continue
+			}
+			mvmtGeom := movement.FindMovementGeomWith(incomingLink.geom, outcomingLink.geom, cfg.geomConfig)
+			mvmtPriority := movement.MOVEMENT_PRIORITY_UNDEFINED
+			if isMajor, ok := majorApproaches[incomingLink.ID]; ok {
+				mvmtPriority = movement.NewMovementPriority(isMajor, mvmtType)
+			}
+			mvmtControlType := node.movementControlType(incomingLink, majorApproaches)
+			mvmtOptions := []func(*movement.Movement){
 				movement.WithOSMNode(node.osmNodeID),
 				movement.WithSourceOSMNode(incomingLink.sourceOsmNodeID),
 				movement.WithTargetOSMNode(outcomingLink.targetOsmNodeID),
-				movement.WithControlType(node.controlType),
-				movement.WithAllowedAgentTypes(incomingLink.allowedAgentTypes),
+				movement.WithControlType(mvmtControlType),
+				movement.WithPriority(mvmtPriority),
+				movement.WithAllowedAgentTypes(allowedAgentTypes),
+				movement.WithDrivingSide(cfg.drivingSide),
 				movement.WithLanesNum(lanesNum),
 				movement.WithIncomeLane(outcomingLaneIndices[incomeLaneIndexStart], outcomingLaneIndices[incomeLaneIndexEnd]),
 				movement.WithIncomeLaneSequence(incomeLaneIndexStart, incomeLaneIndexEnd),
 				movement.WithOutcomeLane(incomingLaneIndices[outcomeLaneIndexStart], incomingLaneIndices[outcomeLaneIndexEnd]),
 				movement.WithOutcomeLaneSequence(outcomeLaneIndexStart, outcomeLaneIndexEnd),
-			)
+			}
+			mvmtOptions = append(mvmtOptions, cfg.turnAttributes(incomingLink, outcomingLink, mvmtType, mvmtControlType, lanesNum, mvmtGeom)...)
+			if node.roundabout != nil {
+				mvmtOptions = append(mvmtOptions, movement.WithRoundaboutExit(node.roundabout.exitNumber(incomingLink.ID, outcomingLink.ID)))
+			}
+			mvmt := movement.NewMovement(node.ID, incomingLink.ID, outcomingLinkID, mvmtTextID, mvmtType, mvmtGeom, mvmtOptions...)
 			movements = append(movements, mvmt)
 		}
 	} else {
@@ -132,14 +169,15 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 				if !ok {
 					return nil, errors.Wrapf(ErrLinkNotFound, "Intersection outcoming Link ID: %d", outcomingLinkID)
 				}
-				if incomingLink.sourceNodeID != outcomingLink.targetNodeID { // Ignore all reverse directions
+				if incomingLink.sourceNodeID != outcomingLink.targetNodeID || node.isReverseAllowed(cfg.classifier.UTurnPolicy, links) { // Ignore reverse directions unless policy allows them
 					outcomingLinksList = append(outcomingLinksList, outcomingLink)
 				}
 			}
+			// Approach which has reverse links only (e.g. parallel links to the same node) should not stop the rest ones
 			if len(outcomingLinksList) == 0 {
-				return movements, nil
+				continue
 			}
-			connections := getIntersectionsConnections(incomingLink, outcomingLinksList)
+			connections := getIntersectionsConnections(incomingLink, outcomingLinksList, cfg.drivingSide)
 			outcomingLaneIndices := incomingLink.GetOutcomingLaneIndices()
 
 			for i := range outcomingLinksList {
@@ -150,22 +188,41 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 				outcomeLaneIndexEnd := connections[i][1].second
 				lanesNum := incomeLaneIndexEnd - incomeLaneIndexStart + 1
 
-				incomingLaneIndices := outcomingLink.GetOutcomingLaneIndices()
-				mvmtTextID, mvmtType := movement.FindMovementType(incomingLink.geomEuclidean, outcomingLink.geomEuclidean)
-				mvmtGeom := movement.FindMovementGeom(incomingLink.geom, outcomingLink.geom)
-				mvmt := movement.NewMovement(
-					node.ID, incomingLinkID, outcomingLink.ID, mvmtTextID, mvmtType, mvmtGeom,
+				// Barrier on the node could block some agents
+				allowedAgentTypes := types.AgentsExclude(incomingLink.allowedAgentTypes, node.barrierBlockedAgentTypes)
+				if len(allowedAgentTypes) == 0 {
+					continue
+				}
+				incomingLaneIndices := outcomingLink.GetIncomingLaneIndices()
+				mvmtTextID, mvmtType := movement.FindMovementTypeWith(incomingLink.geomEuclidean, outcomingLink.geomEuclidean, cfg.classifier)
+				if mvmtType == movement.MOVEMENT_TYPE_U_TURN && incomingLink.sourceNodeID != outcomingLink.targetNodeID && !node.isUTurnAllowed(cfg.classifier.UTurnPolicy, incomingLink, outcomingLink, links) {
+					continue
+				}
+				mvmtGeom := movement.FindMovementGeomWith(incomingLink.geom, outcomingLink.geom, cfg.geomConfig)
+				mvmtPriority := movement.MOVEMENT_PRIORITY_UNDEFINED
+				if isMajor, ok := majorApproaches[incomingLink.ID]; ok {
+					mvmtPriority = movement.NewMovementPriority(isMajor, mvmtType)
+				}
+				mvmtControlType := node.movementControlType(incomingLink, majorApproaches)
+				mvmtOptions := []func(*movement.Movement){
 					movement.WithOSMNode(node.osmNodeID),
 					movement.WithSourceOSMNode(incomingLink.sourceOsmNodeID),
 					movement.WithTargetOSMNode(outcomingLink.targetOsmNodeID),
-					movement.WithControlType(node.controlType),
-					movement.WithAllowedAgentTypes(incomingLink.allowedAgentTypes),
+					movement.WithControlType(mvmtControlType),
+					movement.WithPriority(mvmtPriority),
+					movement.WithAllowedAgentTypes(allowedAgentTypes),
+					movement.WithDrivingSide(cfg.drivingSide),
 					movement.WithLanesNum(lanesNum),
 					movement.WithIncomeLane(outcomingLaneIndices[incomeLaneIndexStart], outcomingLaneIndices[incomeLaneIndexEnd]),
 					movement.WithIncomeLaneSequence(incomeLaneIndexStart, incomeLaneIndexEnd),
 					movement.WithOutcomeLane(incomingLaneIndices[outcomeLaneIndexStart], incomingLaneIndices[outcomeLaneIndexEnd]),
 					movement.WithOutcomeLaneSequence(outcomeLaneIndexStart, outcomeLaneIndexEnd),
-				)
+				}
+				mvmtOptions = append(mvmtOptions, cfg.turnAttributes(incomingLink, outcomingLink, mvmtType, mvmtControlType, lanesNum, mvmtGeom)...)
+				if node.roundabout != nil {
+					mvmtOptions = append(mvmtOptions, movement.WithRoundaboutExit(node.roundabout.exitNumber(incomingLink.ID, outcomingLink.ID)))
+				}
+				mvmt := movement.NewMovement(node.ID, incomingLinkID, outcomingLink.ID, mvmtTextID, mvmtType, mvmtGeom, mvmtOptions...)
 				movements = append(movements, mvmt)
 			}
 		}
@@ -173,3 +230,51 @@ func (node *Node) FindMovements(links map[gmns.LinkID]*Link) ([]movement.Movemen
 
 	return movements, nil
 }
+
+// movementControlType returns control type for the movement which starts from given incoming link
+// Intersection-wide control types (signals, all-way stops and etc.) have priority over approach control types (stop and give way signs).
+// Stop and give way signs without direction on the node itself are applied to minor approaches only (see findMajorApproaches).
+// When there is no priority between approaches they are applied to every approach
+func (node *Node) movementControlType(incomingLink *Link, majorApproaches map[gmns.LinkID]bool) types.ControlType {
+	if node.controlType != types.CONTROL_TYPE_NOT_SIGNAL && !node.controlType.IsApproachControl() {
+		return node.controlType
+	}
+	if node.controlType.IsApproachControl() && node.controlDirection == types.CONTROL_DIRECTION_BOTH && !majorApproaches[incomingLink.ID] {
+		return node.controlType
+	}
+	// Directional control types on the node itself have been attributed to the approaching links already
+	if incomingLink.controlType.IsApproachControl() {
+		return incomingLink.controlType
+	}
+	return types.CONTROL_TYPE_NOT_SIGNAL
+}
+
+// GetGeom returns geometry (EPSG:4326) of the node
+func (node *Node) GetGeom() orb.Point {
+	return node.geom
+}
+
+// GetIncomingLinks returns identifiers of links which end at the node
+func (node *Node) GetIncomingLinks() []gmns.LinkID {
+	return node.incomingLinks
+}
+
+// GetOutcomingLinks returns identifiers of links which start at the node
+func (node *Node) GetOutcomingLinks() []gmns.LinkID {
+	return node.outcomingLinks
+}
+
+// GetZoneID returns identifier of the zone which node belongs to
+func (node *Node) GetZoneID() gmns.ZoneID {
+	return node.zoneID
+}
+
+// GetRoundaboutID returns identifier of the roundabout which node belongs to (-1 if there is no such)
+func (node *Node) GetRoundaboutID() int {
+	return node.roundaboutID
+}
+
+// IsCentroid returns true if the node represents zone's centroid
+func (node *Node) IsCentroid() bool {
+	return node.isCentroid
+}
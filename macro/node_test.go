@@ -0,0 +1,79 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMovementsDiverge(t *testing.T) {
+	// Node 0 has two parallel links back to node 1, so approach from node 1 has reverse directions only
+	net := newTestNet(map[gmns.NodeID]orb.Point{0: {0, 0}, 1: {-20 * testStep, 0}, 2: {20 * testStep, 0}})
+	net.addLink(1, 0)
+	firstParallelID := net.addLink(0, 1)
+	secondParallelID := net.addLink(0, 1)
+	approachID := net.addLink(2, 0)
+
+	movements, err := net.Nodes[0].FindMovements(net.Links, NewMovementsConfigDefault())
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2, "Approach after the one without movements should be handled too")
+	for _, mvmt := range movements {
+		assert.Equal(t, approachID, mvmt.IncomeMacroLinkID, "Wrong incoming link")
+		assert.Contains(t, []gmns.LinkID{firstParallelID, secondParallelID}, mvmt.OutcomeMacroLinkID, "Wrong outcoming link")
+	}
+}
+
+func TestGenerateMovementsConcurrency(t *testing.T) {
+	// Grid of 4x4 two-way streets
+	points := make(map[gmns.NodeID]orb.Point)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			points[gmns.NodeID(i*4+j)] = orb.Point{float64(i) * 10 * testStep, float64(j) * 10 * testStep}
+		}
+	}
+	net := newTestNet(points)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			nodeID := gmns.NodeID(i*4 + j)
+			if i < 3 {
+				net.addTwoWayLink(nodeID, nodeID+4)
+			}
+			if j < 3 {
+				net.addTwoWayLink(nodeID, nodeID+1)
+			}
+		}
+	}
+
+	sequential, err := net.GenerateMovements(WithConcurrency(1))
+	assert.NoError(t, err)
+	assert.Greater(t, sequential.Len(), 0, "Movements should be generated")
+	for run := 0; run < 5; run++ {
+		parallel, err := net.GenerateMovements(WithConcurrency(8))
+		assert.NoError(t, err)
+		assert.Equal(t, sequential.Len(), parallel.Len(), "Wrong number of movements")
+		expected, found := sequential.List(), parallel.List()
+		for i := range expected {
+			assert.Equal(t, expected[i].ID, found[i].ID, "Identifiers should not depend on concurrency")
+			assert.Equal(t, expected[i].MacroNodeID, found[i].MacroNodeID, "Wrong node of movement %d", expected[i].ID)
+			assert.Equal(t, expected[i].IncomeMacroLinkID, found[i].IncomeMacroLinkID, "Wrong incoming link of movement %d", expected[i].ID)
+			assert.Equal(t, expected[i].OutcomeMacroLinkID, found[i].OutcomeMacroLinkID, "Wrong outcoming link of movement %d", expected[i].ID)
+		}
+	}
+}
+
+func TestGenerateMovementsNilConfigs(t *testing.T) {
+	// Crossroads of two-way streets
+	net := newTestNet(map[gmns.NodeID]orb.Point{0: {0, 0}, 1: {-20 * testStep, 0}, 2: {20 * testStep, 0}, 3: {0, 20 * testStep}, 4: {0, -20 * testStep}})
+	for nodeID := gmns.NodeID(1); nodeID <= 4; nodeID++ {
+		net.addTwoWayLink(nodeID, 0)
+	}
+
+	expected, err := net.GenerateMovements()
+	assert.NoError(t, err)
+	found, err := net.GenerateMovements(WithClassifierConfig(nil), WithGeomConfig(nil))
+	assert.NoError(t, err, "Default configurations should be used instead of nil ones")
+	assert.Greater(t, found.Len(), 0, "Movements should be generated")
+	assert.Equal(t, expected.List(), found.List(), "Movements should be the same as for default configurations")
+}
@@ -0,0 +1,65 @@
+package macro
+
+import (
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+)
+
+// nodesUnion is the disjoint-set of nodes. It is used to group nodes which are connected by some rule (e.g. roundabout rings or consolidated intersections)
+type nodesUnion struct {
+	parents map[gmns.NodeID]gmns.NodeID
+}
+
+func newNodesUnion() *nodesUnion {
+	return &nodesUnion{
+		parents: make(map[gmns.NodeID]gmns.NodeID),
+	}
+}
+
+// find returns root of the group which node belongs to. Node which has not been united with anything is the root of itself
+func (union *nodesUnion) find(nodeID gmns.NodeID) gmns.NodeID {
+	parent, ok := union.parents[nodeID]
+	if !ok || parent == nodeID {
+		return nodeID
+	}
+	root := union.find(parent)
+	union.parents[nodeID] = root
+	return root
+}
+
+// unite merges groups of given nodes. Both nodes become members of the union even if they are the same node
+func (union *nodesUnion) unite(a, b gmns.NodeID) {
+	rootA, rootB := union.find(a), union.find(b)
+	// The smallest identifier is kept as the root to get reproducible results
+	if rootB < rootA {
+		rootA, rootB = rootB, rootA
+	}
+	union.parents[rootA] = rootA
+	union.parents[rootB] = rootA
+}
+
+// groups returns members of every group. Groups are ordered by their roots and members are ordered by identifiers
+func (union *nodesUnion) groups() [][]gmns.NodeID {
+	membersByRoot := make(map[gmns.NodeID][]gmns.NodeID)
+	for nodeID := range union.parents {
+		root := union.find(nodeID)
+		membersByRoot[root] = append(membersByRoot[root], nodeID)
+	}
+	roots := make([]gmns.NodeID, 0, len(membersByRoot))
+	for root := range membersByRoot {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i] < roots[j]
+	})
+	groups := make([][]gmns.NodeID, 0, len(roots))
+	for _, root := range roots {
+		members := membersByRoot[root]
+		sort.Slice(members, func(i, j int) bool {
+			return members[i] < members[j]
+		})
+		groups = append(groups, members)
+	}
+	return groups
+}
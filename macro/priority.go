@@ -0,0 +1,76 @@
+package macro
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+)
+
+// findMajorApproaches classifies incoming links of the unsignalized intersection into major (true) and minor (false) approaches.
+// Approaches under stop or give way control are always minor. Then `priority_road` tags are considered and the link type hierarchy is used as a fallback.
+// Returns nil if there is no priority at the node: node is not an intersection, it is controlled as a whole (signal, all-way stop) or all approaches are equal.
+func (node *Node) findMajorApproaches(links map[gmns.LinkID]*Link) map[gmns.LinkID]bool {
+	if node.controlType != types.CONTROL_TYPE_NOT_SIGNAL && !node.controlType.IsApproachControl() {
+		return nil
+	}
+	if node.controlType == types.CONTROL_TYPE_ALL_WAY_STOP {
+		return nil
+	}
+	if node.neighboursNum(links) < 3 {
+		return nil
+	}
+	incomingLinks := make([]*Link, 0, len(node.incomingLinks))
+	for _, linkID := range node.incomingLinks {
+		if link, ok := links[linkID]; ok {
+			incomingLinks = append(incomingLinks, link)
+		}
+	}
+	majorApproaches := make(map[gmns.LinkID]bool, len(incomingLinks))
+	uncontrolledLinkTypes := make([]types.LinkType, 0, len(incomingLinks))
+	hasPriorityRoad := false
+	for _, link := range incomingLinks {
+		if link.controlType.IsApproachControl() {
+			majorApproaches[link.ID] = false
+			continue
+		}
+		uncontrolledLinkTypes = append(uncontrolledLinkTypes, link.linkType)
+		if link.isPriorityRoad {
+			hasPriorityRoad = true
+		}
+	}
+	priorLinkType := types.FindPriorLinkType(uncontrolledLinkTypes)
+	minorFound := len(majorApproaches) > 0
+	for _, link := range incomingLinks {
+		if _, ok := majorApproaches[link.ID]; ok {
+			continue
+		}
+		isMajor := link.linkType == priorLinkType
+		if hasPriorityRoad {
+			isMajor = link.isPriorityRoad
+		}
+		majorApproaches[link.ID] = isMajor
+		if !isMajor {
+			minorFound = true
+		}
+	}
+	if !minorFound {
+		// Equal approaches: no priority could be inferred
+		return nil
+	}
+	return majorApproaches
+}
+
+// neighboursNum returns number of unique neighbour nodes (both upstream and downstream)
+func (node *Node) neighboursNum(links map[gmns.LinkID]*Link) int {
+	neighbours := make(map[gmns.NodeID]struct{})
+	for _, linkID := range node.incomingLinks {
+		if link, ok := links[linkID]; ok {
+			neighbours[link.sourceNodeID] = struct{}{}
+		}
+	}
+	for _, linkID := range node.outcomingLinks {
+		if link, ok := links[linkID]; ok {
+			neighbours[link.targetNodeID] = struct{}{}
+		}
+	}
+	return len(neighbours)
+}
@@ -0,0 +1,75 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovementsPriority(t *testing.T) {
+	// Crossroads of primary west-east road and residential north-south road
+	prepareNet := func(residentialOptions ...func(*Link)) (*testNet, map[string]gmns.LinkID) {
+		net := newTestNet(map[gmns.NodeID]orb.Point{
+			0: {0, 0}, 1: {-20 * testStep, 0}, 2: {20 * testStep, 0}, 3: {0, 20 * testStep}, 4: {0, -20 * testStep},
+		})
+		primary := func(link *Link) {
+			link.linkType = types.LINK_PRIMARY
+		}
+		residential := append([]func(*Link){func(link *Link) {
+			link.linkType = types.LINK_RESIDENTIAL
+		}}, residentialOptions...)
+		approaches := make(map[string]gmns.LinkID)
+		approaches["west"], _ = net.addTwoWayLink(1, 0, primary)
+		approaches["east"], _ = net.addTwoWayLink(2, 0, primary)
+		approaches["north"], _ = net.addTwoWayLink(3, 0, residential...)
+		approaches["south"], _ = net.addTwoWayLink(4, 0, residential...)
+		return net, approaches
+	}
+	priorities := func(net *testNet, incomingLinkID gmns.LinkID) map[movement.MovementType]movement.MovementPriority {
+		movements, err := net.Nodes[0].FindMovements(net.Links, NewMovementsConfigDefault())
+		assert.NoError(t, err)
+		found := make(map[movement.MovementType]movement.MovementPriority)
+		for _, mvmt := range movements {
+			if mvmt.IncomeMacroLinkID == incomingLinkID {
+				found[mvmt.MType] = mvmt.Priority()
+			}
+		}
+		return found
+	}
+
+	// Link types hierarchy
+	net, approaches := prepareNet()
+	west := priorities(net, approaches["west"])
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MAJOR_THRU, west[movement.MOVEMENT_TYPE_THRU])
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MAJOR_LEFT, west[movement.MOVEMENT_TYPE_LEFT])
+	south := priorities(net, approaches["south"])
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MINOR_RIGHT, south[movement.MOVEMENT_TYPE_RIGHT])
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MINOR_LEFT, south[movement.MOVEMENT_TYPE_LEFT])
+	assert.Equal(t, 1, west[movement.MOVEMENT_TYPE_THRU].Rank(), "Major through should have the highest rank")
+	assert.Equal(t, 2, west[movement.MOVEMENT_TYPE_LEFT].Rank(), "Wrong rank of major left")
+	assert.Equal(t, 2, south[movement.MOVEMENT_TYPE_RIGHT].Rank(), "Wrong rank of minor right")
+	assert.Equal(t, 4, south[movement.MOVEMENT_TYPE_LEFT].Rank(), "Minor left should have the lowest rank")
+	assert.Equal(t, 4, south[movement.MOVEMENT_TYPE_RIGHT].RankFor(types.DRIVING_SIDE_LEFT), "Minor right is far side turn for left-hand traffic")
+
+	// `priority_road` tags override link types hierarchy
+	net, approaches = prepareNet(func(link *Link) {
+		link.isPriorityRoad = true
+	})
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MAJOR_THRU, priorities(net, approaches["south"])[movement.MOVEMENT_TYPE_THRU], "Priority road should be major")
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MINOR_THRU, priorities(net, approaches["west"])[movement.MOVEMENT_TYPE_THRU], "Road without priority should be minor")
+
+	// Stop sign makes approach minor regardless of the link type
+	net, approaches = prepareNet()
+	net.Links[approaches["west"]].controlType = types.CONTROL_TYPE_STOP
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MINOR_THRU, priorities(net, approaches["west"])[movement.MOVEMENT_TYPE_THRU], "Approach under stop sign should be minor")
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_MAJOR_THRU, priorities(net, approaches["east"])[movement.MOVEMENT_TYPE_THRU], "Approach without stop sign should be major")
+
+	// Signalized intersection has no priorities
+	net, approaches = prepareNet()
+	net.Nodes[0].controlType = types.CONTROL_TYPE_IS_SIGNAL
+	assert.Equal(t, movement.MOVEMENT_PRIORITY_UNDEFINED, priorities(net, approaches["west"])[movement.MOVEMENT_TYPE_THRU], "There should be no priority at signalized intersection")
+}
@@ -0,0 +1,203 @@
+package macro
+
+import (
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/rs/zerolog/log"
+)
+
+// roundaboutRing describes positions of approaches along the ring of the roundabout
+type roundaboutRing struct {
+	// Ring nodes in order of passing
+	nodes []gmns.NodeID
+	// Positions (indices of ring nodes) of approach links which enter and leave the ring
+	entries map[gmns.LinkID]int
+	exits   map[gmns.LinkID]int
+	// Ring has been replaced with the single node (see CollapseRoundabouts)
+	collapsed bool
+}
+
+// detectRoundabouts groups connected roundabout links (roundabout could consist of several OSM ways) and
+// assigns shared roundabout identifier to those links and their nodes. Positions of approaches are evaluated for every ring
+// which could be walked around, so exits are numbered regardless of collapsing
+func (net *Net) detectRoundabouts() int {
+	union := newNodesUnion()
+	for _, link := range net.Links {
+		if link.isRoundabout {
+			union.unite(link.sourceNodeID, link.targetNodeID)
+		}
+	}
+	rings := union.groups()
+	for roundaboutID, ringNodes := range rings {
+		for _, nodeID := range ringNodes {
+			if node, ok := net.Nodes[nodeID]; ok {
+				node.roundaboutID = roundaboutID
+			}
+		}
+	}
+	for _, link := range net.Links {
+		if link.isRoundabout {
+			link.roundaboutID = net.Nodes[link.sourceNodeID].roundaboutID
+		}
+	}
+	for roundaboutID, ringNodes := range rings {
+		ring := net.prepareRing(ringNodes, roundaboutID)
+		if ring == nil {
+			continue
+		}
+		for _, nodeID := range ringNodes {
+			net.Nodes[nodeID].roundabout = ring
+		}
+	}
+	return len(rings)
+}
+
+// prepareRing walks around the ring of given roundabout and evaluates positions of approaches
+// Returns nil if the ring could not be walked around (e.g. partially mapped roundabout)
+// Notice: nodes should be sorted by identifiers
+func (net *Net) prepareRing(nodesIDs []gmns.NodeID, roundaboutID int) *roundaboutRing {
+	ringNodes := net.walkRing(nodesIDs[0], roundaboutID)
+	if len(ringNodes) != len(nodesIDs) {
+		return nil
+	}
+	ring := &roundaboutRing{
+		nodes:   ringNodes,
+		entries: make(map[gmns.LinkID]int),
+		exits:   make(map[gmns.LinkID]int),
+	}
+	for position, nodeID := range ringNodes {
+		node := net.Nodes[nodeID]
+		for _, linkID := range node.incomingLinks {
+			if link, ok := net.Links[linkID]; ok && link.roundaboutID != roundaboutID {
+				ring.entries[linkID] = position
+			}
+		}
+		for _, linkID := range node.outcomingLinks {
+			if link, ok := net.Links[linkID]; ok && link.roundaboutID != roundaboutID {
+				ring.exits[linkID] = position
+			}
+		}
+	}
+	return ring
+}
+
+// CollapseRoundabouts replaces every roundabout with the single node placed at the centroid of the ring.
+// Ring links are removed and approach links are reattached to the new node. Movements should be generated after collapsing.
+// Roundabouts which ring could not be walked around (e.g. partially mapped ones) are left as is.
+// Returns number of collapsed roundabouts.
+func (net *Net) CollapseRoundabouts() (int, error) {
+	rings := make(map[int]*roundaboutRing)
+	for _, node := range net.Nodes {
+		if node.roundaboutID < 0 {
+			continue
+		}
+		if node.roundabout == nil {
+			log.Warn().Str("scope", "collapse_roundabouts").Int("roundabout_id", node.roundaboutID).Int("node_id", int(node.ID)).Msg("Can't walk around the ring. Roundabout won't be collapsed")
+			continue
+		}
+		if !node.roundabout.collapsed {
+			rings[node.roundaboutID] = node.roundabout
+		}
+	}
+	roundaboutsIDs := make([]int, 0, len(rings))
+	for roundaboutID := range rings {
+		roundaboutsIDs = append(roundaboutsIDs, roundaboutID)
+	}
+	sort.Ints(roundaboutsIDs)
+
+	for _, roundaboutID := range roundaboutsIDs {
+		ring := rings[roundaboutID]
+		nodesIDs := make([]gmns.NodeID, len(ring.nodes))
+		copy(nodesIDs, ring.nodes)
+		sort.Slice(nodesIDs, func(i, j int) bool {
+			return nodesIDs[i] < nodesIDs[j]
+		})
+		net.collapseCluster(nodesIDs)
+		ring.collapsed = true
+	}
+	if len(roundaboutsIDs) == 0 {
+		return 0, nil
+	}
+	err := net.genBoundaryAndActivityType()
+	if err != nil {
+		return len(roundaboutsIDs), err
+	}
+	return len(roundaboutsIDs), nil
+}
+
+// walkRing follows links of the given roundabout in the travel direction starting from given node
+// Returns nodes in order of passing. Result is empty if the ring is not closed or it forks
+func (net *Net) walkRing(startNodeID gmns.NodeID, roundaboutID int) []gmns.NodeID {
+	ring := []gmns.NodeID{startNodeID}
+	nodeID := startNodeID
+	for len(ring) <= len(net.Nodes) {
+		node, ok := net.Nodes[nodeID]
+		if !ok {
+			return nil
+		}
+		var nextLink *Link
+		for _, linkID := range node.outcomingLinks {
+			link, ok := net.Links[linkID]
+			if !ok || link.roundaboutID != roundaboutID {
+				continue
+			}
+			if nextLink != nil {
+				return nil
+			}
+			nextLink = link
+		}
+		if nextLink == nil {
+			return nil
+		}
+		nodeID = nextLink.targetNodeID
+		if nodeID == startNodeID {
+			return ring
+		}
+		ring = append(ring, nodeID)
+	}
+	return nil
+}
+
+// RoundaboutExitNumber returns number of the exit (starting from 1) counted along the ring from given entry link to given exit link.
+// Exit at the same position as entry is considered as the last one (full circle). Returns 0 if links are not approaches of the same roundabout
+func (net *Net) RoundaboutExitNumber(entryLinkID, exitLinkID gmns.LinkID) int {
+	entryLink, ok := net.Links[entryLinkID]
+	if !ok {
+		return 0
+	}
+	node, ok := net.Nodes[entryLink.targetNodeID]
+	if !ok || node.roundabout == nil {
+		return 0
+	}
+	return node.roundabout.exitNumber(entryLinkID, exitLinkID)
+}
+
+// exitNumber returns number of the exit (starting from 1) counted along the ring from given entry to given exit.
+// Exit at the same position as entry is considered as the last one (full circle). Returns 0 for unknown approaches
+func (ring *roundaboutRing) exitNumber(entryLinkID, exitLinkID gmns.LinkID) int {
+	entryPosition, ok := ring.entries[entryLinkID]
+	if !ok {
+		return 0
+	}
+	exitPosition, ok := ring.exits[exitLinkID]
+	if !ok {
+		return 0
+	}
+	ringSize := len(ring.nodes)
+	distance := func(position int) int {
+		d := (position - entryPosition + ringSize) % ringSize
+		if d == 0 {
+			return ringSize
+		}
+		return d
+	}
+	target := distance(exitPosition)
+	exitNumber := 0
+	for _, position := range ring.exits {
+		if distance(position) <= target {
+			exitNumber++
+		}
+	}
+	return exitNumber
+}
@@ -0,0 +1,78 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseRoundabouts(t *testing.T) {
+	// Counter-clockwise ring ~15 meters wide and one bidirectional arm per ring node
+	net := newTestNet(map[gmns.NodeID]orb.Point{
+		0: {0, 0}, 1: {testStep, 0}, 2: {testStep, testStep}, 3: {0, testStep},
+		4: {0, -20 * testStep}, 5: {20 * testStep, 0}, 6: {testStep, 20 * testStep}, 7: {-20 * testStep, testStep},
+	})
+	ringLink := func(link *Link) {
+		link.isRoundabout = true
+	}
+	for _, pair := range [][2]gmns.NodeID{{0, 1}, {1, 2}, {2, 3}, {3, 0}} {
+		net.addLink(pair[0], pair[1], ringLink)
+	}
+	entryID := net.addLink(4, 0)
+	uTurnExitID := net.addLink(0, 4)
+	net.addLink(5, 1)
+	firstExitID := net.addLink(1, 5)
+	net.addLink(6, 2)
+	secondExitID := net.addLink(2, 6)
+	net.addLink(7, 3)
+	thirdExitID := net.addLink(3, 7)
+
+	assert.Equal(t, 1, net.detectRoundabouts(), "Ring links should form single roundabout")
+	for nodeID := gmns.NodeID(0); nodeID < 4; nodeID++ {
+		assert.Equal(t, 0, net.Nodes[nodeID].GetRoundaboutID(), "Ring node should be a part of the roundabout")
+	}
+	assert.Equal(t, -1, net.Nodes[4].GetRoundaboutID(), "Arm node should not be a part of the roundabout")
+	assert.Equal(t, -1, net.Links[entryID].GetRoundaboutID(), "Approach link should not be a part of the roundabout")
+
+	// Exits are numbered without collapsing
+	assert.Equal(t, 1, net.RoundaboutExitNumber(entryID, firstExitID), "Wrong number of the first exit")
+	assert.Equal(t, 2, net.RoundaboutExitNumber(entryID, secondExitID), "Wrong number of the second exit")
+	assert.Equal(t, 3, net.RoundaboutExitNumber(entryID, thirdExitID), "Wrong number of the third exit")
+	assert.Equal(t, 4, net.RoundaboutExitNumber(entryID, uTurnExitID), "Exit to the same arm should be the last one")
+	assert.Equal(t, 0, net.RoundaboutExitNumber(firstExitID, secondExitID), "Exit link is not an entry")
+	movements, err := net.Nodes[0].FindMovements(net.Links, NewMovementsConfigDefault())
+	assert.NoError(t, err)
+	for _, mvmt := range movements {
+		expected := 0
+		if mvmt.IncomeMacroLinkID == entryID && mvmt.OutcomeMacroLinkID == uTurnExitID {
+			expected = 4
+		}
+		assert.Equal(t, expected, mvmt.RoundaboutExit(), "Only movement between approaches should get number of the exit")
+	}
+
+	collapsedNum, err := net.CollapseRoundabouts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, collapsedNum, "Roundabout should be collapsed")
+	assert.Len(t, net.Nodes, 5, "Ring nodes should be replaced with single node")
+	assert.Len(t, net.Links, 8, "Ring links should be removed")
+
+	collapsed := net.Nodes[0]
+	assert.Equal(t, 0, collapsed.GetRoundaboutID(), "Collapsed node should keep roundabout identifier")
+	assert.Equal(t, 2, net.RoundaboutExitNumber(entryID, secondExitID), "Collapsing should keep numbers of exits")
+	collapsedNum, err = net.CollapseRoundabouts()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, collapsedNum, "Roundabout should not be collapsed twice")
+
+	movements, err = collapsed.FindMovements(net.Links, NewMovementsConfigDefault())
+	assert.NoError(t, err)
+	found := false
+	for _, mvmt := range movements {
+		if mvmt.IncomeMacroLinkID == entryID && mvmt.OutcomeMacroLinkID == secondExitID {
+			found = true
+			assert.Equal(t, 2, mvmt.RoundaboutExit(), "Movement should keep number of the exit")
+		}
+	}
+	assert.True(t, found, "Movement through the second exit should be generated")
+}
@@ -0,0 +1,113 @@
+package macro
+
+import (
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+)
+
+// ClusterSignals attributes traffic signals which are placed near (not on) the junction to the downstream intersection node.
+// Signal node which is not an intersection itself is being walked downstream (respecting `traffic_signals:direction`) until the first intersection node is met.
+// If such intersection is within maxDistance (in meters) it becomes signalized and the source signal node is not considered as signalized anymore.
+// Returns number of intersection nodes which have become signalized.
+func (net *Net) ClusterSignals(maxDistance float64) int {
+	if maxDistance <= 0 {
+		return 0
+	}
+	// Walk nodes in a determined order to get reproducible results
+	signalNodes := make([]gmns.NodeID, 0)
+	for nodeID, node := range net.Nodes {
+		if node.controlType != types.CONTROL_TYPE_IS_SIGNAL {
+			continue
+		}
+		if net.isIntersection(node) {
+			continue
+		}
+		signalNodes = append(signalNodes, nodeID)
+	}
+	sort.Slice(signalNodes, func(i, j int) bool {
+		return signalNodes[i] < signalNodes[j]
+	})
+
+	attributed := make(map[gmns.NodeID]struct{})
+	for _, signalNodeID := range signalNodes {
+		signalNode := net.Nodes[signalNodeID]
+		var nearestNode *Node
+		nearestDistance := maxDistance
+		for _, outcomingLinkID := range signalNode.outcomingLinks {
+			outcomingLink, ok := net.Links[outcomingLinkID]
+			if !ok {
+				continue
+			}
+			if !controlDirectionMatches(signalNode.controlDirection, outcomingLink.direction) {
+				continue
+			}
+			// When there is no explicit direction the nearest intersection is picked
+			intersectionNode, distance := net.findDownstreamIntersection(outcomingLink, maxDistance)
+			if intersectionNode == nil || distance > nearestDistance {
+				continue
+			}
+			nearestNode = intersectionNode
+			nearestDistance = distance
+		}
+		if nearestNode == nil {
+			continue
+		}
+		nearestNode.controlType = types.CONTROL_TYPE_IS_SIGNAL
+		nearestNode.controlDirection = types.CONTROL_DIRECTION_BOTH
+		attributed[nearestNode.ID] = struct{}{}
+		// Signal has been moved to the intersection. Original `osm_highway` is kept for the reference
+		signalNode.controlType = types.CONTROL_TYPE_NOT_SIGNAL
+	}
+	return len(attributed)
+}
+
+// findDownstreamIntersection walks links chain starting from given link until the first intersection node is found
+// Returns intersection node and distance (in meters) to it. Node is nil when there is no intersection within maxDistance or chain forks before
+func (net *Net) findDownstreamIntersection(startLink *Link, maxDistance float64) (*Node, float64) {
+	link := startLink
+	distance := 0.0
+	visited := make(map[gmns.LinkID]struct{})
+	for {
+		if _, ok := visited[link.ID]; ok {
+			return nil, -1
+		}
+		visited[link.ID] = struct{}{}
+		distance += link.lengthMeters
+		if distance > maxDistance {
+			return nil, -1
+		}
+		targetNode, ok := net.Nodes[link.targetNodeID]
+		if !ok {
+			return nil, -1
+		}
+		if net.isIntersection(targetNode) {
+			return targetNode, distance
+		}
+		// Continue through pass-through nodes only
+		var nextLink *Link
+		for _, outcomingLinkID := range targetNode.outcomingLinks {
+			outcomingLink, ok := net.Links[outcomingLinkID]
+			if !ok {
+				continue
+			}
+			if outcomingLink.targetNodeID == link.sourceNodeID { // Ignore reverse direction
+				continue
+			}
+			if nextLink != nil {
+				return nil, -1
+			}
+			nextLink = outcomingLink
+		}
+		if nextLink == nil {
+			return nil, -1
+		}
+		link = nextLink
+	}
+}
+
+// isIntersection checks if node connects three or more neighbour nodes
+func (net *Net) isIntersection(node *Node) bool {
+	return node.neighboursNum(net.Links) >= 3
+}
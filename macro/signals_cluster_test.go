@@ -0,0 +1,39 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterSignals(t *testing.T) {
+	// Signal node 1 is ~30 meters upstream of T-junction 2 on the road 0-1-2
+	prepareNet := func(signalDirection types.ControlDirection) *testNet {
+		net := newTestNet(map[gmns.NodeID]orb.Point{
+			0: {-20 * testStep, 0}, 1: {-2 * testStep, 0}, 2: {0, 0}, 3: {0, 20 * testStep}, 4: {20 * testStep, 0},
+		})
+		net.Nodes[1].controlType = types.CONTROL_TYPE_IS_SIGNAL
+		net.Nodes[1].controlDirection = signalDirection
+		for _, pair := range [][2]gmns.NodeID{{0, 1}, {1, 2}, {2, 3}, {2, 4}} {
+			net.addTwoWayLink(pair[0], pair[1])
+		}
+		return net
+	}
+
+	net := prepareNet(types.CONTROL_DIRECTION_BOTH)
+	assert.Equal(t, 0, net.ClusterSignals(20), "Intersection is too far from the signal")
+	assert.Equal(t, types.CONTROL_TYPE_IS_SIGNAL, net.Nodes[1].controlType, "Signal should be kept as is")
+	assert.Equal(t, 1, net.ClusterSignals(40), "Signal should be attributed to the intersection")
+	assert.Equal(t, types.CONTROL_TYPE_IS_SIGNAL, net.Nodes[2].controlType, "Intersection should be signalized")
+	assert.Equal(t, types.CONTROL_TYPE_NOT_SIGNAL, net.Nodes[1].controlType, "Signal should be moved to the intersection")
+
+	// Signal controls traffic going away from the intersection only
+	net = prepareNet(types.CONTROL_DIRECTION_BACKWARD)
+	assert.Equal(t, 0, net.ClusterSignals(40), "Signal should be attributed downstream only")
+	assert.Equal(t, types.CONTROL_TYPE_NOT_SIGNAL, net.Nodes[2].controlType, "Intersection should not be signalized")
+
+	assert.Equal(t, 0, prepareNet(types.CONTROL_DIRECTION_BOTH).ClusterSignals(0), "Clustering should be disabled")
+}
@@ -0,0 +1,33 @@
+package macro
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+)
+
+// isReverseAllowed checks if movement which goes back along the incoming link (to the same neighbour node) should be generated
+func (node *Node) isReverseAllowed(policy movement.UTurnPolicy, links map[gmns.LinkID]*Link) bool {
+	switch policy {
+	case movement.UTURN_POLICY_ALWAYS:
+		return true
+	case movement.UTURN_POLICY_DEAD_END:
+		return node.neighboursNum(links) == 1
+	default:
+		return false
+	}
+}
+
+// isUTurnAllowed checks if movement which is classified as U-turn (but does not go back along the incoming link) should be generated
+func (node *Node) isUTurnAllowed(policy movement.UTurnPolicy, incomingLink, outcomingLink *Link, links map[gmns.LinkID]*Link) bool {
+	switch policy {
+	case movement.UTURN_POLICY_NEVER:
+		return false
+	case movement.UTURN_POLICY_DEAD_END:
+		return node.neighboursNum(links) == 1
+	case movement.UTURN_POLICY_DIVIDED:
+		// Carriageways of divided roads are mapped as separate one-way ways
+		return !incomingLink.wasBidirectional && !outcomingLink.wasBidirectional
+	default:
+		return true
+	}
+}
@@ -0,0 +1,262 @@
+package macro
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+var (
+	ErrZonesAttached = fmt.Errorf("Zones have been attached already")
+	ErrNoCentroids   = fmt.Errorf("Zones have no centroid nodes. Zones should be attached to the network")
+)
+
+const (
+	connectorsNumDefault = 3
+)
+
+// ZonesConfig is the set of parameters for attaching zones to the network
+type ZonesConfig struct {
+	// Number of the nearest nodes to connect centroid with
+	connectorsNum int
+	// Max length (meters) of the connector. Zero value means no limit
+	maxConnectorLength float64
+}
+
+// NewZonesConfigDefault returns default parameters for attaching zones: 3 connectors per centroid, no length limit
+func NewZonesConfigDefault() *ZonesConfig {
+	return &ZonesConfig{
+		connectorsNum:      connectorsNumDefault,
+		maxConnectorLength: 0,
+	}
+}
+
+// WithConnectorsNum sets number of the nearest nodes to connect every centroid with
+func WithConnectorsNum(connectorsNum int) func(*ZonesConfig) {
+	return func(cfg *ZonesConfig) {
+		cfg.connectorsNum = connectorsNum
+	}
+}
+
+// WithMaxConnectorLength sets max length (meters) of the connector. Zero value means no limit
+func WithMaxConnectorLength(maxConnectorLength float64) func(*ZonesConfig) {
+	return func(cfg *ZonesConfig) {
+		cfg.maxConnectorLength = maxConnectorLength
+	}
+}
+
+// AttachZones sets zone of every node by point-in-polygon and adds one centroid node per zone.
+// Every centroid is connected with the k nearest eligible nodes (the ones with both incoming and outcoming links) by pair of LINK_CONNECTOR links.
+// Nodes inside the zone are preferred; the nearest nodes of the whole network are used for zones without nodes.
+// Nodes outside of any zone get no zone. Movements should be generated after attaching zones.
+// Returns number of connector links.
+func (net *Net) AttachZones(zones zone.Zones, options ...func(*ZonesConfig)) (int, error) {
+	if net.zonesAttached {
+		return 0, ErrZonesAttached
+	}
+	cfg := NewZonesConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+
+	nodesIDs := make([]gmns.NodeID, 0, len(net.Nodes))
+	lastNodeID := gmns.NodeID(-1)
+	for nodeID := range net.Nodes {
+		nodesIDs = append(nodesIDs, nodeID)
+		lastNodeID = max(lastNodeID, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+	lastLinkID := gmns.LinkID(-1)
+	for linkID := range net.Links {
+		lastLinkID = max(lastLinkID, linkID)
+	}
+
+	eligible := make([]*Node, 0, len(nodesIDs))
+	for _, nodeID := range nodesIDs {
+		node := net.Nodes[nodeID]
+		node.zoneID = -1
+		if found := zones.Find(node.geom); found != nil {
+			node.zoneID = found.ID
+		}
+		if len(node.incomingLinks) > 0 && len(node.outcomingLinks) > 0 {
+			eligible = append(eligible, node)
+		}
+	}
+
+	connectorsNum := 0
+	for _, zn := range zones {
+		lastNodeID++
+		centroid := &Node{
+			incomingLinks:  make([]gmns.LinkID, 0),
+			outcomingLinks: make([]gmns.LinkID, 0),
+			name:           zn.GetName(),
+			ID:             lastNodeID,
+			osmNodeID:      -1,
+			intersectionID: -1,
+			roundaboutID:   -1,
+			zoneID:         zn.ID,
+			poiID:          -1,
+			controlType:    types.CONTROL_TYPE_NOT_SIGNAL,
+			activityType:   types.ACTIVITY_NONE,
+			boundaryType:   types.BOUNDARY_NONE,
+			geom:           zn.GetCentroid(),
+			geomEuclidean:  geomath.PointToEuclidean(zn.GetCentroid()),
+			isCentroid:     true,
+		}
+		net.Nodes[centroid.ID] = centroid
+		zn.SetCentroidNodeID(centroid.ID)
+
+		for _, node := range nearestNodes(centroid, zn.ID, eligible, cfg) {
+			allowedAgentTypes := net.adjacentAgentTypes(node)
+			lastLinkID++
+			net.addConnector(lastLinkID, centroid, node, allowedAgentTypes)
+			lastLinkID++
+			net.addConnector(lastLinkID, node, centroid, allowedAgentTypes)
+			connectorsNum += 2
+		}
+	}
+	net.zonesAttached = true
+	err := net.genBoundaryAndActivityType()
+	if err != nil {
+		return connectorsNum, err
+	}
+	return connectorsNum, nil
+}
+
+// Centroids returns identifiers of centroid nodes for every zone
+func (net *Net) Centroids() map[gmns.ZoneID]gmns.NodeID {
+	centroids := make(map[gmns.ZoneID]gmns.NodeID)
+	for nodeID, node := range net.Nodes {
+		if node.isCentroid {
+			centroids[node.zoneID] = nodeID
+		}
+	}
+	return centroids
+}
+
+// AttachedZones returns zones which centroid nodes belong to the network ordered by identifiers.
+// ErrNoCentroids is returned when there are no such zones
+func (net *Net) AttachedZones(zones zone.Zones) (zone.Zones, error) {
+	attached := make(zone.Zones, 0, len(zones))
+	for _, zn := range zones {
+		if _, ok := net.Nodes[zn.GetCentroidNodeID()]; ok && zn.GetCentroidNodeID() >= 0 {
+			attached = append(attached, zn)
+		}
+	}
+	if len(attached) == 0 {
+		return nil, ErrNoCentroids
+	}
+	sort.Slice(attached, func(i, j int) bool {
+		return attached[i].ID < attached[j].ID
+	})
+	return attached, nil
+}
+
+// Bound returns bounding box (EPSG:4326) of the network nodes
+func (net *Net) Bound() orb.Bound {
+	points := make(orb.MultiPoint, 0, len(net.Nodes))
+	for _, node := range net.Nodes {
+		points = append(points, node.geom)
+	}
+	return points.Bound()
+}
+
+// nearestNodes returns up to k nearest nodes to the centroid. Nodes inside the zone are preferred
+func nearestNodes(centroid *Node, zoneID gmns.ZoneID, eligible []*Node, cfg *ZonesConfig) []*Node {
+	candidates := make([]*Node, 0)
+	for _, node := range eligible {
+		if node.zoneID == zoneID {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, eligible...)
+	}
+	distances := make(map[gmns.NodeID]float64, len(candidates))
+	for _, node := range candidates {
+		distances[node.ID] = geo.Distance(centroid.geom, node.geom)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distances[candidates[i].ID] < distances[candidates[j].ID]
+	})
+	nearest := make([]*Node, 0, cfg.connectorsNum)
+	for _, node := range candidates {
+		if len(nearest) >= cfg.connectorsNum {
+			break
+		}
+		if cfg.maxConnectorLength > 0 && distances[node.ID] > cfg.maxConnectorLength {
+			break
+		}
+		nearest = append(nearest, node)
+	}
+	return nearest
+}
+
+// adjacentAgentTypes returns agent types which are allowed on any link adjacent to the node
+func (net *Net) adjacentAgentTypes(node *Node) []types.AgentType {
+	observed := make(map[types.AgentType]struct{})
+	agentTypes := make([]types.AgentType, 0)
+	for _, linkIDs := range [][]gmns.LinkID{node.incomingLinks, node.outcomingLinks} {
+		for _, linkID := range linkIDs {
+			link, ok := net.Links[linkID]
+			if !ok {
+				continue
+			}
+			for _, agentType := range link.allowedAgentTypes {
+				if _, ok := observed[agentType]; !ok {
+					observed[agentType] = struct{}{}
+					agentTypes = append(agentTypes, agentType)
+				}
+			}
+		}
+	}
+	sort.Slice(agentTypes, func(i, j int) bool {
+		return agentTypes[i] < agentTypes[j]
+	})
+	return agentTypes
+}
+
+// addConnector adds LINK_CONNECTOR link between given nodes. Connector is named after the zone's centroid
+// Notice: it copies given agent types slice
+func (net *Net) addConnector(id gmns.LinkID, source, target *Node, allowedAgentTypes []types.AgentType) {
+	name := source.name
+	if target.isCentroid {
+		name = target.name
+	}
+	link := &Link{
+		ID:                 id,
+		name:               name,
+		freeSpeed:          types.NewSpeedDefault(types.LINK_CONNECTOR),
+		maxSpeed:           types.NewSpeedDefault(types.LINK_CONNECTOR),
+		capacity:           types.NewCapacityDefault(types.LINK_CONNECTOR),
+		volume:             -1,
+		osmWayID:           -1,
+		linkClass:          types.LINK_CLASS_HIGHWAY,
+		linkType:           types.LINK_CONNECTOR,
+		linkConnectionType: types.NOT_A_LINK,
+		controlType:        types.CONTROL_TYPE_NOT_SIGNAL,
+		allowedAgentTypes:  make([]types.AgentType, len(allowedAgentTypes)),
+		sourceNodeID:       source.ID,
+		targetNodeID:       target.ID,
+		sourceOsmNodeID:    source.osmNodeID,
+		targetOsmNodeID:    target.osmNodeID,
+		wasBidirectional:   true,
+		roundaboutID:       -1,
+		direction:          DIRECTION_FORWARD,
+		lanesNum:           types.NewLanesDefault(types.LINK_CONNECTOR),
+		restrictions:       types.NewVehicleRestrictionsDefault(),
+	}
+	copy(link.allowedAgentTypes, allowedAgentTypes)
+	link.setGeom(orb.LineString{source.geom, target.geom})
+	net.Links[id] = link
+	source.outcomingLinks = append(source.outcomingLinks, id)
+	target.incomingLinks = append(target.incomingLinks, id)
+}
@@ -0,0 +1,48 @@
+package macro
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachZones(t *testing.T) {
+	// Straight two-way road along the equator: two nodes per grid cell
+	net := newTestNet(map[gmns.NodeID]orb.Point{0: {0.0001, 0.0005}, 1: {0.0004, 0.0005}, 2: {0.0011, 0.0005}, 3: {0.0019, 0.0005}})
+	for _, pair := range [][2]gmns.NodeID{{0, 1}, {1, 2}, {2, 3}} {
+		net.addTwoWayLink(pair[0], pair[1])
+	}
+
+	// Two cells ~111 meters wide
+	zones := zone.NewGrid(orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{0.002, 0.001}}, 111.32)
+	assert.Len(t, zones, 2, "Wrong number of grid cells")
+
+	_, err := net.AttachedZones(zones)
+	assert.ErrorIs(t, err, ErrNoCentroids, "Zones should not be attached yet")
+	connectorsNum, err := net.AttachZones(zones, WithConnectorsNum(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, connectorsNum, "Every centroid should get pair of connectors")
+	assert.Equal(t, gmns.ZoneID(0), net.Nodes[0].GetZoneID(), "Node should be assigned to the first zone")
+	assert.Equal(t, gmns.ZoneID(1), net.Nodes[3].GetZoneID(), "Node should be assigned to the second zone")
+
+	centroids := net.Centroids()
+	assert.Len(t, centroids, 2, "Wrong number of centroids")
+	centroid := net.Nodes[centroids[0]]
+	assert.True(t, centroid.IsCentroid(), "Centroid node should be marked")
+	assert.Equal(t, centroids[0], zones[0].GetCentroidNodeID(), "Zone should reference its centroid")
+	attached, err := net.AttachedZones(zones)
+	assert.NoError(t, err)
+	assert.Len(t, attached, 2, "Every zone should be attached")
+	assert.Len(t, centroid.outcomingLinks, 1, "Wrong number of connectors")
+	connector := net.Links[centroid.outcomingLinks[0]]
+	assert.Equal(t, types.LINK_CONNECTOR, connector.GetLinkType(), "Wrong link type of connector")
+	assert.Equal(t, gmns.NodeID(1), connector.GetTargetNodeID(), "Centroid should be connected with the nearest node inside the zone")
+	assert.False(t, connector.restrictions.IsRestricted(), "Connector should not be restricted")
+
+	_, err = net.AttachZones(zones)
+	assert.ErrorIs(t, err, ErrZonesAttached, "Zones should be attached once")
+}
@@ -0,0 +1,159 @@
+package meso
+
+import (
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+type Link struct {
+	ID           gmns.LinkID
+	sourceNodeID gmns.NodeID
+	targetNodeID gmns.NodeID
+	// Parent macroscopic node (for connectors only, -1 otherwise)
+	macroNodeID gmns.NodeID
+	// Parent macroscopic link (for ordinary links). For connectors it is incoming macroscopic link of the movement
+	macroLinkID gmns.LinkID
+	// Parent movement (for connectors only, -1 otherwise)
+	movementID     movement.MovementID
+	movementTextID movement.MovementCompositeType
+	isConnection   bool
+	linkType       types.LinkType
+	lanesNum       int
+	// Indices of lanes from the inner side to the outer one (for ordinary links only)
+	laneIndices []int
+	freeSpeed   float64
+	// Capacity (veh/h/lane)
+	capacity          int
+	allowedAgentTypes []types.AgentType
+	lengthMeters      float64
+	geom              orb.LineString
+	geomEuclidean     orb.LineString
+}
+
+func newLink(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, geom orb.LineString, options ...func(*Link)) *Link {
+	link := &Link{
+		ID:             id,
+		sourceNodeID:   sourceNodeID,
+		targetNodeID:   targetNodeID,
+		macroNodeID:    -1,
+		macroLinkID:    -1,
+		movementID:     -1,
+		movementTextID: movement.MOVEMENT_UNDEFINED,
+		freeSpeed:      -1,
+		capacity:       -1,
+		geom:           geom,
+		geomEuclidean:  geomath.LineToEuclidean(geom),
+		lengthMeters:   geo.LengthHaversine(geom),
+	}
+	for _, option := range options {
+		option(link)
+	}
+	return link
+}
+
+// withMacroLink sets parent macroscopic link and inherits its attributes
+func withMacroLink(macroLinkID gmns.LinkID, linkType types.LinkType, freeSpeed float64, capacity int, allowedAgentTypes []types.AgentType) func(*Link) {
+	return func(link *Link) {
+		link.macroLinkID = macroLinkID
+		link.linkType = linkType
+		link.freeSpeed = freeSpeed
+		link.capacity = capacity
+		link.allowedAgentTypes = make([]types.AgentType, len(allowedAgentTypes))
+		copy(link.allowedAgentTypes, allowedAgentTypes)
+	}
+}
+
+// withMovement marks link as connector for the movement and inherits movement's attributes
+func withMovement(mvmt *movement.Movement) func(*Link) {
+	return func(link *Link) {
+		link.isConnection = true
+		link.macroNodeID = mvmt.MacroNodeID
+		link.movementID = mvmt.ID
+		link.movementTextID = mvmt.MTextID
+		link.lanesNum = mvmt.LanesNum()
+		if freeSpeed := mvmt.FreeSpeed(); freeSpeed >= 0 {
+			link.freeSpeed = freeSpeed
+		}
+		// Capacity of links is per lane while capacity of movements is total
+		if capacity := mvmt.Capacity(); capacity >= 0 {
+			link.capacity = capacity / max(link.lanesNum, 1)
+		}
+		allowedAgentTypes := mvmt.AllowedAgentTypes()
+		link.allowedAgentTypes = make([]types.AgentType, len(allowedAgentTypes))
+		copy(link.allowedAgentTypes, allowedAgentTypes)
+	}
+}
+
+// withLaneIndices sets indices of lanes (from the inner side to the outer one) and number of lanes
+// Notice: it copies given slice
+func withLaneIndices(laneIndices []int) func(*Link) {
+	return func(link *Link) {
+		link.laneIndices = make([]int, len(laneIndices))
+		copy(link.laneIndices, laneIndices)
+		link.lanesNum = len(laneIndices)
+	}
+}
+
+// GetSourceNodeID returns identifier of the source node
+func (link *Link) GetSourceNodeID() gmns.NodeID {
+	return link.sourceNodeID
+}
+
+// GetTargetNodeID returns identifier of the target node
+func (link *Link) GetTargetNodeID() gmns.NodeID {
+	return link.targetNodeID
+}
+
+// GetMacroNodeID returns identifier of parent macroscopic node (-1 for ordinary links)
+func (link *Link) GetMacroNodeID() gmns.NodeID {
+	return link.macroNodeID
+}
+
+// GetMacroLinkID returns identifier of parent macroscopic link
+func (link *Link) GetMacroLinkID() gmns.LinkID {
+	return link.macroLinkID
+}
+
+// GetMovementID returns identifier of parent movement (-1 for ordinary links)
+func (link *Link) GetMovementID() movement.MovementID {
+	return link.movementID
+}
+
+// IsConnection returns true if link is connector for the movement
+func (link *Link) IsConnection() bool {
+	return link.isConnection
+}
+
+// GetLaneIndices returns indices of lanes (from the inner side to the outer one: left to right for right-hand traffic)
+func (link *Link) GetLaneIndices() []int {
+	return link.laneIndices
+}
+
+// GetFreeSpeed returns free speed (km/h)
+func (link *Link) GetFreeSpeed() float64 {
+	return link.freeSpeed
+}
+
+// GetCapacity returns capacity (veh/h/lane)
+func (link *Link) GetCapacity() int {
+	return link.capacity
+}
+
+// GetAllowedAgentTypes returns agent types which are allowed to use the link
+func (link *Link) GetAllowedAgentTypes() []types.AgentType {
+	return link.allowedAgentTypes
+}
+
+// GetLengthMeters returns length of the link in meters
+func (link *Link) GetLengthMeters() float64 {
+	return link.lengthMeters
+}
+
+// GetGeom returns geometry (EPSG:4326) of the link
+func (link *Link) GetGeom() orb.LineString {
+	return link.geom
+}
@@ -0,0 +1,166 @@
+package meso
+
+import (
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	laneWidthDefault = 3.5
+)
+
+type Net struct {
+	Nodes map[gmns.NodeID]*Node
+	Links map[gmns.LinkID]*Link
+
+	laneWidth   float64
+	drivingSide types.DrivingSide
+}
+
+// WithLaneWidth sets width (meters) of the single lane. It is used to shift geometry of bidirectional links
+func WithLaneWidth(laneWidth float64) func(*Net) {
+	return func(net *Net) {
+		net.laneWidth = laneWidth
+	}
+}
+
+// DrivingSide returns driving side of the network. It is inherited from the macroscopic network
+func (net *Net) DrivingSide() types.DrivingSide {
+	return net.drivingSide
+}
+
+// linkEnds is the pair of mesoscopic nodes which are the first and the last ones for the macroscopic link
+type linkEnds struct {
+	first gmns.NodeID
+	last  gmns.NodeID
+}
+
+// NewNetFromMacroscopic generates mesoscopic network:
+// macroscopic links are cut back from intersections and split at lane change points, then every movement is represented by connector link
+func NewNetFromMacroscopic(macroNet *macro.Net, mvmtStorage *movement.MovementsStorage, options ...func(*Net)) (*Net, error) {
+	net := &Net{
+		Nodes:       make(map[gmns.NodeID]*Node),
+		Links:       make(map[gmns.LinkID]*Link),
+		laneWidth:   laneWidthDefault,
+		drivingSide: macroNet.DrivingSide(),
+	}
+	for _, option := range options {
+		option(net)
+	}
+	macroNet.PrepareMesoscopicCuts(mvmtStorage, net.laneWidth)
+
+	macroLinksIDs := make([]gmns.LinkID, 0, len(macroNet.Links))
+	for linkID := range macroNet.Links {
+		macroLinksIDs = append(macroLinksIDs, linkID)
+	}
+	sort.Slice(macroLinksIDs, func(i, j int) bool {
+		return macroLinksIDs[i] < macroLinksIDs[j]
+	})
+
+	lastNodeID := gmns.NodeID(0)
+	lastLinkID := gmns.LinkID(0)
+	// Macroscopic nodes without movements are shared between links
+	sharedNodes := make(map[gmns.NodeID]gmns.NodeID)
+	sharedNode := func(macroNodeID gmns.NodeID) (*Node, error) {
+		if mesoNodeID, ok := sharedNodes[macroNodeID]; ok {
+			return net.Nodes[mesoNodeID], nil
+		}
+		macroNode, ok := macroNet.Nodes[macroNodeID]
+		if !ok {
+			return nil, errors.Wrapf(macro.ErrNodeNotFound, "Node ID: %d", macroNodeID)
+		}
+		node := newNode(lastNodeID, macroNodeID, -1, macroNode.GetZoneID(), macroNode.GetGeom())
+		net.Nodes[node.ID] = node
+		sharedNodes[macroNodeID] = node.ID
+		lastNodeID++
+		return node, nil
+	}
+
+	macroLinksEnds := make(map[gmns.LinkID]linkEnds, len(macroLinksIDs))
+	for _, macroLinkID := range macroLinksIDs {
+		macroLink := macroNet.Links[macroLinkID]
+		geoms := macroLink.GetGeomOffsetCut()
+		laneIndicesList := macroLink.GetLaneIndicesCut()
+		if len(geoms) == 0 {
+			log.Warn().Str("scope", "meso_generation").Int("macro_link_id", int(macroLinkID)).Msg("Macroscopic link has no parts after cuts")
+			continue
+		}
+		nodes := make([]*Node, 0, len(geoms)+1)
+		if macroLink.IsUpstreamCut() {
+			node := newNode(lastNodeID, -1, macroLinkID, -1, geoms[0][0])
+			net.Nodes[node.ID] = node
+			lastNodeID++
+			nodes = append(nodes, node)
+		} else {
+			node, err := sharedNode(macroLink.GetSourceNodeID())
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't prepare upstream node for macro link %d", macroLinkID)
+			}
+			nodes = append(nodes, node)
+		}
+		for i := 1; i < len(geoms); i++ {
+			node := newNode(lastNodeID, -1, macroLinkID, -1, geoms[i][0])
+			net.Nodes[node.ID] = node
+			lastNodeID++
+			nodes = append(nodes, node)
+		}
+		if macroLink.IsDownstreamCut() {
+			lastGeom := geoms[len(geoms)-1]
+			node := newNode(lastNodeID, -1, macroLinkID, -1, lastGeom[len(lastGeom)-1])
+			net.Nodes[node.ID] = node
+			lastNodeID++
+			nodes = append(nodes, node)
+		} else {
+			node, err := sharedNode(macroLink.GetTargetNodeID())
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't prepare downstream node for macro link %d", macroLinkID)
+			}
+			nodes = append(nodes, node)
+		}
+
+		mesoLinks := make([]gmns.LinkID, 0, len(geoms))
+		for i := range geoms {
+			// Geometry should start and end exactly at the nodes (shared ones could be shifted from the offset geometry)
+			geom := make(orb.LineString, len(geoms[i]))
+			copy(geom, geoms[i])
+			geom[0] = nodes[i].geom
+			geom[len(geom)-1] = nodes[i+1].geom
+			link := newLink(lastLinkID, nodes[i].ID, nodes[i+1].ID, geom,
+				withMacroLink(macroLinkID, macroLink.GetLinkType(), macroLink.GetFreeSpeed(), macroLink.GetCapacity(), macroLink.GetAllowedAgentTypes()),
+				withLaneIndices(laneIndicesList[i]),
+			)
+			net.Links[link.ID] = link
+			mesoLinks = append(mesoLinks, link.ID)
+			lastLinkID++
+		}
+		macroLink.SetMesoLinks(mesoLinks)
+		macroLinksEnds[macroLinkID] = linkEnds{first: nodes[0].ID, last: nodes[len(nodes)-1].ID}
+	}
+
+	// Connectors for movements
+	for _, mvmt := range mvmtStorage.List() {
+		incomeEnds, okIncome := macroLinksEnds[mvmt.IncomeMacroLinkID]
+		outcomeEnds, okOutcome := macroLinksEnds[mvmt.OutcomeMacroLinkID]
+		if !okIncome || !okOutcome {
+			log.Warn().Str("scope", "meso_generation").Int("movement_id", int(mvmt.ID)).Msg("Can't find mesoscopic links for movement")
+			continue
+		}
+		sourceNode := net.Nodes[incomeEnds.last]
+		targetNode := net.Nodes[outcomeEnds.first]
+		incomeMacroLink := macroNet.Links[mvmt.IncomeMacroLinkID]
+		link := newLink(lastLinkID, sourceNode.ID, targetNode.ID, orb.LineString{sourceNode.geom, targetNode.geom},
+			withMacroLink(mvmt.IncomeMacroLinkID, incomeMacroLink.GetLinkType(), incomeMacroLink.GetFreeSpeed(), incomeMacroLink.GetCapacity(), incomeMacroLink.GetAllowedAgentTypes()),
+			withMovement(mvmt),
+		)
+		net.Links[link.ID] = link
+		lastLinkID++
+	}
+	return net, nil
+}
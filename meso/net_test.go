@@ -0,0 +1,124 @@
+package meso
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNetFromMacroscopic(t *testing.T) {
+	// T-junction at node 2: two-way road 1-2-3 along the equator and two-way road 2-4 to the north. Every leg is ~110 meters
+	nodesSet := map[osm.NodeID]*wrappers.NodeOSM{
+		1: {ID: 1, InnerNode: osm.Node{ID: 1, Lon: 0, Lat: 0}, IsCrossing: true},
+		2: {ID: 2, InnerNode: osm.Node{ID: 2, Lon: 0.001, Lat: 0}, IsCrossing: true},
+		3: {ID: 3, InnerNode: osm.Node{ID: 3, Lon: 0.002, Lat: 0}, IsCrossing: true},
+		4: {ID: 4, InnerNode: osm.Node{ID: 4, Lon: 0.001, Lat: 0.001}, IsCrossing: true},
+	}
+	newWay := func(id osm.WayID, nodes ...osm.NodeID) *wrappers.WayOSM {
+		return &wrappers.WayOSM{ID: id, Nodes: nodes, FreeSpeed: -1, Capacity: -1, LinkType: types.LINK_RESIDENTIAL, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}}
+	}
+	macroNet, err := macro.NewNetFromOSM([]*wrappers.WayOSM{newWay(1, 1, 2, 3), newWay(2, 2, 4)}, nodesSet)
+	assert.NoError(t, err)
+	mvmtStorage, err := macroNet.GenerateMovements()
+	assert.NoError(t, err)
+
+	junctionID := gmns.NodeID(-1)
+	for _, node := range macroNet.Nodes {
+		if node.GetGeom()[0] == 0.001 && node.GetGeom()[1] == 0 {
+			junctionID = node.ID
+		}
+	}
+	junctionMovements := 0
+	for _, mvmt := range mvmtStorage.List() {
+		if mvmt.MacroNodeID == junctionID {
+			junctionMovements++
+		}
+	}
+	assert.Greater(t, junctionMovements, 0, "Junction should have movements")
+
+	net, err := NewNetFromMacroscopic(macroNet, mvmtStorage)
+	assert.NoError(t, err)
+
+	// Links are cut back from the junction only
+	for _, macroLink := range macroNet.Links {
+		assert.Equal(t, macroLink.GetSourceNodeID() == junctionID, macroLink.IsUpstreamCut(), "Link %d should be cut back from its source node if and only if it is the junction", macroLink.ID)
+		assert.Equal(t, macroLink.GetTargetNodeID() == junctionID, macroLink.IsDownstreamCut(), "Link %d should be cut back from its target node if and only if it is the junction", macroLink.ID)
+		mesoLinks := macroLink.GetMesoLinks()
+		assert.NotEmpty(t, mesoLinks, "Every macroscopic link should have mesoscopic ones")
+		for _, mesoLinkID := range mesoLinks {
+			mesoLink := net.Links[mesoLinkID]
+			assert.False(t, mesoLink.IsConnection(), "Parts of macroscopic link are not connectors")
+			assert.Equal(t, macroLink.ID, mesoLink.GetMacroLinkID(), "Wrong parent macroscopic link")
+		}
+		first, last := net.Links[mesoLinks[0]], net.Links[mesoLinks[len(mesoLinks)-1]]
+		assert.Equal(t, macroLink.IsUpstreamCut(), net.Nodes[first.GetSourceNodeID()].GetMacroNodeID() == -1, "Cut link should start at its own mesoscopic node")
+		assert.Equal(t, macroLink.IsDownstreamCut(), net.Nodes[last.GetTargetNodeID()].GetMacroNodeID() == -1, "Cut link should end at its own mesoscopic node")
+		if macroLink.IsUpstreamCut() || macroLink.IsDownstreamCut() {
+			assert.Less(t, first.GetLengthMeters(), macroLink.GetLengthMeters(), "Cut link should be shorter than the macroscopic one")
+		}
+	}
+
+	// Every movement at the junction is a connector between ends of cut links
+	connectors := 0
+	for _, link := range net.Links {
+		if !link.IsConnection() {
+			continue
+		}
+		connectors++
+		mvmt, ok := mvmtStorage.Get(link.GetMovementID())
+		assert.True(t, ok, "Connector should refer to existing movement")
+		assert.Equal(t, mvmt.MacroNodeID, link.GetMacroNodeID(), "Wrong parent macroscopic node of connector")
+		incomeMesoLinks := macroNet.Links[mvmt.IncomeMacroLinkID].GetMesoLinks()
+		outcomeMesoLinks := macroNet.Links[mvmt.OutcomeMacroLinkID].GetMesoLinks()
+		assert.Equal(t, net.Links[incomeMesoLinks[len(incomeMesoLinks)-1]].GetTargetNodeID(), link.GetSourceNodeID(), "Connector should start at the end of incoming link")
+		assert.Equal(t, net.Links[outcomeMesoLinks[0]].GetSourceNodeID(), link.GetTargetNodeID(), "Connector should end at the start of outcoming link")
+	}
+	assert.Equal(t, mvmtStorage.Len(), connectors, "Every movement should have connector")
+
+	// Dead ends have no movements, so both directions share single mesoscopic node there
+	sharedNodes := 0
+	for _, node := range net.Nodes {
+		if node.GetMacroNodeID() != -1 {
+			assert.NotEqual(t, junctionID, node.GetMacroNodeID(), "Junction should not be shared")
+			sharedNodes++
+		}
+	}
+	assert.Equal(t, 3, sharedNodes, "Wrong number of shared nodes at dead ends")
+}
+
+func TestNewNetFromMacroscopicShortLink(t *testing.T) {
+	// Junctions 2 and 3 are ~5.5 meters apart: cuts should be shrunk, so link still has non-empty part
+	nodesSet := map[osm.NodeID]*wrappers.NodeOSM{
+		1: {ID: 1, InnerNode: osm.Node{ID: 1, Lon: 0, Lat: 0}, IsCrossing: true},
+		2: {ID: 2, InnerNode: osm.Node{ID: 2, Lon: 0.001, Lat: 0}, IsCrossing: true},
+		3: {ID: 3, InnerNode: osm.Node{ID: 3, Lon: 0.00105, Lat: 0}, IsCrossing: true},
+		4: {ID: 4, InnerNode: osm.Node{ID: 4, Lon: 0.002, Lat: 0}, IsCrossing: true},
+		5: {ID: 5, InnerNode: osm.Node{ID: 5, Lon: 0.001, Lat: 0.001}, IsCrossing: true},
+		6: {ID: 6, InnerNode: osm.Node{ID: 6, Lon: 0.00105, Lat: -0.001}, IsCrossing: true},
+	}
+	newWay := func(id osm.WayID, nodes ...osm.NodeID) *wrappers.WayOSM {
+		return &wrappers.WayOSM{ID: id, Nodes: nodes, FreeSpeed: -1, Capacity: -1, IsOneWay: true, LinkType: types.LINK_RESIDENTIAL, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}}
+	}
+	macroNet, err := macro.NewNetFromOSM([]*wrappers.WayOSM{newWay(1, 1, 2, 3, 4), newWay(2, 5, 2), newWay(3, 3, 6)}, nodesSet)
+	assert.NoError(t, err)
+	mvmtStorage, err := macroNet.GenerateMovements()
+	assert.NoError(t, err)
+	net, err := NewNetFromMacroscopic(macroNet, mvmtStorage)
+	assert.NoError(t, err)
+
+	for _, macroLink := range macroNet.Links {
+		if !macroLink.IsUpstreamCut() || !macroLink.IsDownstreamCut() {
+			continue
+		}
+		mesoLinks := macroLink.GetMesoLinks()
+		assert.Len(t, mesoLinks, 1, "Short link should not be split")
+		assert.InDelta(t, 2.0, net.Links[mesoLinks[0]].GetLengthMeters(), 0.1, "Short link should keep min length after cuts")
+		return
+	}
+	t.Error("There should be link between two junctions")
+}
@@ -0,0 +1,39 @@
+package meso
+
+import (
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+)
+
+type Node struct {
+	ID gmns.NodeID
+	// Parent macroscopic node (-1 if node is placed on the macroscopic link)
+	macroNodeID gmns.NodeID
+	// Parent macroscopic link (-1 if node represents the macroscopic node)
+	macroLinkID   gmns.LinkID
+	zoneID        gmns.ZoneID
+	geom          orb.Point
+	geomEuclidean orb.Point
+}
+
+func newNode(id gmns.NodeID, macroNodeID gmns.NodeID, macroLinkID gmns.LinkID, zoneID gmns.ZoneID, geom orb.Point) *Node {
+	return &Node{
+		ID:            id,
+		macroNodeID:   macroNodeID,
+		macroLinkID:   macroLinkID,
+		zoneID:        zoneID,
+		geom:          geom,
+		geomEuclidean: geomath.PointToEuclidean(geom),
+	}
+}
+
+// GetMacroNodeID returns identifier of parent macroscopic node (-1 if node is placed on the macroscopic link)
+func (node *Node) GetMacroNodeID() gmns.NodeID {
+	return node.macroNodeID
+}
+
+// GetGeom returns geometry (EPSG:4326) of the node
+func (node *Node) GetGeom() orb.Point {
+	return node.geom
+}
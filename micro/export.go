@@ -0,0 +1,124 @@
+package micro
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/pkg/errors"
+)
+
+func (net *Net) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameNodes := fmt.Sprintf(fnameParts[0] + "_micro_nodes.csv")
+	fnameLinks := fmt.Sprintf(fnameParts[0] + "_micro_links.csv")
+
+	err := net.exportNodesToCSV(fnameNodes)
+	if err != nil {
+		return errors.Wrap(err, "Can't export nodes")
+	}
+
+	err = net.exportLinksToCSV(fnameLinks)
+	if err != nil {
+		return errors.Wrap(err, "Can't export links")
+	}
+	return nil
+}
+
+func (net *Net) exportNodesToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"id", "meso_link_id", "macro_link_id", "macro_node_id", "lane_index", "cell_index", "longitude", "latitude"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	nodesIDs := make([]gmns.NodeID, 0, len(net.Nodes))
+	for nodeID := range net.Nodes {
+		nodesIDs = append(nodesIDs, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+	for _, nodeID := range nodesIDs {
+		node := net.Nodes[nodeID]
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", node.ID),
+			fmt.Sprintf("%d", node.mesoLinkID),
+			fmt.Sprintf("%d", node.macroLinkID),
+			fmt.Sprintf("%d", node.macroNodeID),
+			fmt.Sprintf("%d", node.laneIndex),
+			fmt.Sprintf("%d", node.cellIndex),
+			fmt.Sprintf("%f", node.geom[0]),
+			fmt.Sprintf("%f", node.geom[1]),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write node")
+		}
+	}
+	return nil
+}
+
+func (net *Net) exportLinksToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"id", "source_node", "target_node", "meso_link_id", "macro_link_id", "macro_node_id", "movement_id", "cell_type", "lane_index", "free_speed", "capacity", "allowed_agent_types", "length_meters", "geom"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	linksIDs := make([]gmns.LinkID, 0, len(net.Links))
+	for linkID := range net.Links {
+		linksIDs = append(linksIDs, linkID)
+	}
+	sort.Slice(linksIDs, func(i, j int) bool {
+		return linksIDs[i] < linksIDs[j]
+	})
+	for _, linkID := range linksIDs {
+		link := net.Links[linkID]
+		allowedAgentTypes := make([]string, len(link.allowedAgentTypes))
+		for i, agentType := range link.allowedAgentTypes {
+			allowedAgentTypes[i] = agentType.String()
+		}
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", link.ID),
+			fmt.Sprintf("%d", link.sourceNodeID),
+			fmt.Sprintf("%d", link.targetNodeID),
+			fmt.Sprintf("%d", link.mesoLinkID),
+			fmt.Sprintf("%d", link.macroLinkID),
+			fmt.Sprintf("%d", link.macroNodeID),
+			fmt.Sprintf("%d", link.movementID),
+			link.cellType.String(),
+			fmt.Sprintf("%d", link.laneIndex),
+			fmt.Sprintf("%f", link.freeSpeed),
+			fmt.Sprintf("%d", link.capacity),
+			strings.Join(allowedAgentTypes, ","),
+			fmt.Sprintf("%f", link.lengthMeters),
+			wkt.MarshalString(link.geom),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write link")
+		}
+	}
+	return nil
+}
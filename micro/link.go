@@ -0,0 +1,91 @@
+package micro
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// CellType is the kind of microscopic link
+type CellType uint16
+
+const (
+	// Moving forward along the lane
+	CELL_TYPE_FORWARD = CellType(iota)
+	// Changing lane to the adjacent one
+	CELL_TYPE_LANE_CHANGE
+	// Moving through the intersection with respect to the movement lane mapping
+	CELL_TYPE_CONNECTOR
+)
+
+func (iotaIdx CellType) String() string {
+	return [...]string{"forward", "lane_change", "connector"}[iotaIdx]
+}
+
+type Link struct {
+	ID           gmns.LinkID
+	sourceNodeID gmns.NodeID
+	targetNodeID gmns.NodeID
+	// Parent mesoscopic link
+	mesoLinkID gmns.LinkID
+	// Parent macroscopic link. For connectors it is incoming macroscopic link of the movement
+	macroLinkID gmns.LinkID
+	// Parent macroscopic node (for connectors only, -1 otherwise)
+	macroNodeID gmns.NodeID
+	// Parent movement (for connectors only, -1 otherwise)
+	movementID movement.MovementID
+	cellType   CellType
+	// Lane number of the source node
+	laneIndex int
+	freeSpeed float64
+	// Capacity (veh/h)
+	capacity          int
+	allowedAgentTypes []types.AgentType
+	lengthMeters      float64
+	geom              orb.LineString
+}
+
+func newLink(id gmns.LinkID, sourceNodeID, targetNodeID gmns.NodeID, cellType CellType, geom orb.LineString, options ...func(*Link)) *Link {
+	link := &Link{
+		ID:           id,
+		sourceNodeID: sourceNodeID,
+		targetNodeID: targetNodeID,
+		mesoLinkID:   -1,
+		macroLinkID:  -1,
+		macroNodeID:  -1,
+		movementID:   -1,
+		cellType:     cellType,
+		freeSpeed:    -1,
+		capacity:     -1,
+		geom:         geom,
+		lengthMeters: geo.LengthHaversine(geom),
+	}
+	for _, option := range options {
+		option(link)
+	}
+	return link
+}
+
+// withParents sets parent mesoscopic and macroscopic entities
+func withParents(mesoLinkID gmns.LinkID, macroLinkID gmns.LinkID, macroNodeID gmns.NodeID, movementID movement.MovementID) func(*Link) {
+	return func(link *Link) {
+		link.mesoLinkID = mesoLinkID
+		link.macroLinkID = macroLinkID
+		link.macroNodeID = macroNodeID
+		link.movementID = movementID
+	}
+}
+
+// withAttributes sets lane number, free speed, capacity and allowed agent types
+// Notice: it copies given slice
+func withAttributes(laneIndex int, freeSpeed float64, capacity int, allowedAgentTypes []types.AgentType) func(*Link) {
+	return func(link *Link) {
+		link.laneIndex = laneIndex
+		link.freeSpeed = freeSpeed
+		link.capacity = capacity
+		link.allowedAgentTypes = make([]types.AgentType, len(allowedAgentTypes))
+		copy(link.allowedAgentTypes, allowedAgentTypes)
+	}
+}
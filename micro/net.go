@@ -0,0 +1,247 @@
+package micro
+
+import (
+	"math"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/meso"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	cellLengthDefault = 4.5
+	laneWidthDefault  = 3.5
+)
+
+type Net struct {
+	Nodes map[gmns.NodeID]*Node
+	Links map[gmns.LinkID]*Link
+
+	cellLength  float64
+	laneWidth   float64
+	laneChanges bool
+}
+
+// WithCellLength sets length (meters) of the single cell
+func WithCellLength(cellLength float64) func(*Net) {
+	return func(net *Net) {
+		net.cellLength = cellLength
+	}
+}
+
+// WithLaneWidth sets width (meters) of the single lane. It is used to shift lanes from the centerline of mesoscopic links
+func WithLaneWidth(laneWidth float64) func(*Net) {
+	return func(net *Net) {
+		net.laneWidth = laneWidth
+	}
+}
+
+// WithLaneChanges sets whether lane-change links between adjacent lanes should be generated
+func WithLaneChanges(allowed bool) func(*Net) {
+	return func(net *Net) {
+		net.laneChanges = allowed
+	}
+}
+
+// laneEndKey identifies micro node at the end of the lane. Lanes of the same macroscopic link share nodes at mesoscopic nodes
+type laneEndKey struct {
+	mesoNodeID  gmns.NodeID
+	macroLinkID gmns.LinkID
+	laneIndex   int
+}
+
+// NewNetFromMesoscopic generates microscopic network:
+// every lane of every mesoscopic link is split into cells, adjacent lanes are connected by lane-change links and
+// connectors follow lane mappings of the movements
+func NewNetFromMesoscopic(mesoNet *meso.Net, mvmtStorage *movement.MovementsStorage, options ...func(*Net)) (*Net, error) {
+	net := &Net{
+		Nodes:       make(map[gmns.NodeID]*Node),
+		Links:       make(map[gmns.LinkID]*Link),
+		cellLength:  cellLengthDefault,
+		laneWidth:   laneWidthDefault,
+		laneChanges: true,
+	}
+	for _, option := range options {
+		option(net)
+	}
+
+	mesoLinksIDs := make([]gmns.LinkID, 0, len(mesoNet.Links))
+	for linkID := range mesoNet.Links {
+		mesoLinksIDs = append(mesoLinksIDs, linkID)
+	}
+	sort.Slice(mesoLinksIDs, func(i, j int) bool {
+		return mesoLinksIDs[i] < mesoLinksIDs[j]
+	})
+
+	lastNodeID := gmns.NodeID(0)
+	lastLinkID := gmns.LinkID(0)
+	laneEnds := make(map[laneEndKey]gmns.NodeID)
+	// Ordered mesoscopic links for every macroscopic link
+	macroLinksParts := make(map[gmns.LinkID][]*meso.Link)
+
+	for _, mesoLinkID := range mesoLinksIDs {
+		mesoLink := mesoNet.Links[mesoLinkID]
+		if mesoLink.IsConnection() {
+			continue
+		}
+		macroLinkID := mesoLink.GetMacroLinkID()
+		macroLinksParts[macroLinkID] = append(macroLinksParts[macroLinkID], mesoLink)
+		laneIndices := mesoLink.GetLaneIndices()
+		if len(laneIndices) == 0 {
+			log.Warn().Str("scope", "micro_generation").Int("meso_link_id", int(mesoLinkID)).Msg("Mesoscopic link has no lanes")
+			continue
+		}
+
+		geom := mesoLink.GetGeom()
+		geomEuclidean := geomath.LineToEuclidean(geom)
+		scale := geomath.EuclideanScale(geom[0].Lat())
+		cellsNum := max(1, int(math.Round(mesoLink.GetLengthMeters()/net.cellLength)))
+
+		laneEndNode := func(mesoNodeID gmns.NodeID, laneIndex int, cellIndex int, pt orb.Point) gmns.NodeID {
+			key := laneEndKey{mesoNodeID: mesoNodeID, macroLinkID: macroLinkID, laneIndex: laneIndex}
+			if nodeID, ok := laneEnds[key]; ok {
+				return nodeID
+			}
+			macroNodeID := gmns.NodeID(-1)
+			if mesoNode, ok := mesoNet.Nodes[mesoNodeID]; ok {
+				macroNodeID = mesoNode.GetMacroNodeID()
+			}
+			node := newNode(lastNodeID, mesoLinkID, macroLinkID, macroNodeID, laneIndex, cellIndex, pt)
+			net.Nodes[node.ID] = node
+			laneEnds[key] = node.ID
+			lastNodeID++
+			return node.ID
+		}
+
+		lanesNodes := make([][]gmns.NodeID, len(laneIndices))
+		for pos, laneIndex := range laneIndices {
+			// Lanes are numbered from the inner side (left for right-hand traffic). Positive offset is for the left side
+			offset := (float64(len(laneIndices)-1)/2.0 - float64(pos)) * net.laneWidth * scale
+			if mesoNet.DrivingSide() == types.DRIVING_SIDE_LEFT {
+				offset = -offset
+			}
+			laneGeom := geom
+			if offset != 0 {
+				laneGeom = geomath.LineToSpherical(geomath.OffsetCurve(geomEuclidean, offset))
+			}
+			laneLength := geo.LengthHaversine(laneGeom)
+			cellLength := laneLength / float64(cellsNum)
+
+			nodes := make([]gmns.NodeID, cellsNum+1)
+			nodes[0] = laneEndNode(mesoLink.GetSourceNodeID(), laneIndex, 0, laneGeom[0])
+			for cellIdx := 1; cellIdx < cellsNum; cellIdx++ {
+				pt, _ := geo.PointAtDistanceAlongLine(laneGeom, cellLength*float64(cellIdx))
+				node := newNode(lastNodeID, mesoLinkID, macroLinkID, -1, laneIndex, cellIdx, pt)
+				net.Nodes[node.ID] = node
+				lastNodeID++
+				nodes[cellIdx] = node.ID
+			}
+			nodes[cellsNum] = laneEndNode(mesoLink.GetTargetNodeID(), laneIndex, cellsNum, laneGeom[len(laneGeom)-1])
+			lanesNodes[pos] = nodes
+
+			for cellIdx := 0; cellIdx < cellsNum; cellIdx++ {
+				cellGeom := geomath.SubstringHaversine(laneGeom, cellLength*float64(cellIdx), cellLength*float64(cellIdx+1))
+				if len(cellGeom) < 2 {
+					cellGeom = orb.LineString{net.Nodes[nodes[cellIdx]].geom, net.Nodes[nodes[cellIdx+1]].geom}
+				}
+				// Cell should start and end exactly at the nodes (shared ones could be shifted from the lane geometry)
+				cellGeom[0] = net.Nodes[nodes[cellIdx]].geom
+				cellGeom[len(cellGeom)-1] = net.Nodes[nodes[cellIdx+1]].geom
+				link := newLink(lastLinkID, nodes[cellIdx], nodes[cellIdx+1], CELL_TYPE_FORWARD, cellGeom,
+					withParents(mesoLinkID, macroLinkID, -1, -1),
+					withAttributes(laneIndex, mesoLink.GetFreeSpeed(), mesoLink.GetCapacity(), mesoLink.GetAllowedAgentTypes()),
+				)
+				net.Links[link.ID] = link
+				lastLinkID++
+			}
+		}
+
+		if !net.laneChanges {
+			continue
+		}
+		// Lane changes: from the start of the cell on the lane to the end of the same cell on the adjacent lane
+		for pos := 0; pos < len(laneIndices)-1; pos++ {
+			for cellIdx := 0; cellIdx < cellsNum; cellIdx++ {
+				pairs := [2][2]int{{pos, pos + 1}, {pos + 1, pos}}
+				for _, pair := range pairs {
+					sourceID, targetID := lanesNodes[pair[0]][cellIdx], lanesNodes[pair[1]][cellIdx+1]
+					link := newLink(lastLinkID, sourceID, targetID, CELL_TYPE_LANE_CHANGE, orb.LineString{net.Nodes[sourceID].geom, net.Nodes[targetID].geom},
+						withParents(mesoLinkID, macroLinkID, -1, -1),
+						withAttributes(laneIndices[pair[0]], mesoLink.GetFreeSpeed(), mesoLink.GetCapacity(), mesoLink.GetAllowedAgentTypes()),
+					)
+					net.Links[link.ID] = link
+					lastLinkID++
+				}
+			}
+		}
+	}
+
+	// Connectors for movements
+	for _, mesoLinkID := range mesoLinksIDs {
+		mesoLink := mesoNet.Links[mesoLinkID]
+		if !mesoLink.IsConnection() {
+			continue
+		}
+		mvmt, ok := mvmtStorage.Get(mesoLink.GetMovementID())
+		if !ok {
+			log.Warn().Str("scope", "micro_generation").Int("meso_link_id", int(mesoLinkID)).Int("movement_id", int(mesoLink.GetMovementID())).Msg("Can't find movement for connector")
+			continue
+		}
+		incomeParts, outcomeParts := macroLinksParts[mvmt.IncomeMacroLinkID], macroLinksParts[mvmt.OutcomeMacroLinkID]
+		if len(incomeParts) == 0 || len(outcomeParts) == 0 {
+			log.Warn().Str("scope", "micro_generation").Int("movement_id", int(mvmt.ID)).Msg("Can't find mesoscopic links for movement")
+			continue
+		}
+		incomeLink, outcomeLink := incomeParts[len(incomeParts)-1], outcomeParts[0]
+		incomeLaneStart, incomeLaneEnd := mvmt.IncomeLanes()
+		outcomeLaneStart, outcomeLaneEnd := mvmt.OutcomeLanes()
+		incomeLanes := lanesRange(incomeLink.GetLaneIndices(), incomeLaneStart, incomeLaneEnd)
+		outcomeLanes := lanesRange(outcomeLink.GetLaneIndices(), outcomeLaneStart, outcomeLaneEnd)
+		if len(incomeLanes) == 0 || len(outcomeLanes) == 0 {
+			log.Warn().Str("scope", "micro_generation").Int("movement_id", int(mvmt.ID)).Msg("Movement lanes do not match lanes of mesoscopic links")
+			continue
+		}
+		// Lanes are paired in order. If numbers of lanes differ then extra lanes are connected with the outermost lane of the other side
+		for i := 0; i < max(len(incomeLanes), len(outcomeLanes)); i++ {
+			incomeLane := incomeLanes[min(i, len(incomeLanes)-1)]
+			outcomeLane := outcomeLanes[min(i, len(outcomeLanes)-1)]
+			sourceID, okSource := laneEnds[laneEndKey{mesoNodeID: incomeLink.GetTargetNodeID(), macroLinkID: mvmt.IncomeMacroLinkID, laneIndex: incomeLane}]
+			targetID, okTarget := laneEnds[laneEndKey{mesoNodeID: outcomeLink.GetSourceNodeID(), macroLinkID: mvmt.OutcomeMacroLinkID, laneIndex: outcomeLane}]
+			if !okSource || !okTarget {
+				log.Warn().Str("scope", "micro_generation").Int("movement_id", int(mvmt.ID)).Int("income_lane", incomeLane).Int("outcome_lane", outcomeLane).Msg("Can't find micro nodes for movement lanes")
+				continue
+			}
+			link := newLink(lastLinkID, sourceID, targetID, CELL_TYPE_CONNECTOR, orb.LineString{net.Nodes[sourceID].geom, net.Nodes[targetID].geom},
+				withParents(mesoLinkID, mesoLink.GetMacroLinkID(), mesoLink.GetMacroNodeID(), mvmt.ID),
+				withAttributes(incomeLane, mesoLink.GetFreeSpeed(), mesoLink.GetCapacity(), mesoLink.GetAllowedAgentTypes()),
+			)
+			net.Links[link.ID] = link
+			lastLinkID++
+		}
+	}
+	return net, nil
+}
+
+// lanesRange returns lane numbers between start and end ones (inclusive) in order of the given lane indices.
+// Returns nil if any of lane numbers is not found
+func lanesRange(laneIndices []int, start int, end int) []int {
+	startPos, endPos := -1, -1
+	for pos, laneIndex := range laneIndices {
+		if laneIndex == start {
+			startPos = pos
+		}
+		if laneIndex == end {
+			endPos = pos
+		}
+	}
+	if startPos < 0 || endPos < 0 || startPos > endPos {
+		return nil
+	}
+	return laneIndices[startPos : endPos+1]
+}
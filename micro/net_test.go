@@ -0,0 +1,170 @@
+package micro
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/meso"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanesRange(t *testing.T) {
+	laneIndices := []int{-1, 1, 2, 3}
+	assert.Equal(t, []int{-1, 1}, lanesRange(laneIndices, -1, 1), "Left pocket should be included")
+	assert.Equal(t, []int{2, 3}, lanesRange(laneIndices, 2, 3), "Wrong lanes range")
+	assert.Nil(t, lanesRange(laneIndices, 3, 2), "Reversed range should not be accepted")
+	assert.Nil(t, lanesRange(laneIndices, 1, 4), "Unknown lane should not be accepted")
+}
+
+// prepareTestNets generates mesoscopic network for one-way three-lane road 1-2-3 along the equator (to the east) with one-way single-lane exit 2-4 to the south
+func prepareTestNets(t *testing.T, drivingSide types.DrivingSide) (*meso.Net, *movement.MovementsStorage) {
+	nodesSet := map[osm.NodeID]*wrappers.NodeOSM{
+		1: {ID: 1, InnerNode: osm.Node{ID: 1, Lon: 0, Lat: 0}, IsCrossing: true},
+		2: {ID: 2, InnerNode: osm.Node{ID: 2, Lon: 0.001, Lat: 0}, IsCrossing: true},
+		3: {ID: 3, InnerNode: osm.Node{ID: 3, Lon: 0.002, Lat: 0}, IsCrossing: true},
+		4: {ID: 4, InnerNode: osm.Node{ID: 4, Lon: 0.001, Lat: -0.001}, IsCrossing: true},
+	}
+	newWay := func(id osm.WayID, lanes int, nodes ...osm.NodeID) *wrappers.WayOSM {
+		return &wrappers.WayOSM{ID: id, Nodes: nodes, FreeSpeed: -1, Capacity: -1, IsOneWay: true, Tags: wrappers.WayTags{Lanes: lanes}, LinkType: types.LINK_PRIMARY, AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO}}
+	}
+	macroNet, err := macro.NewNetFromOSM([]*wrappers.WayOSM{newWay(1, 3, 1, 2, 3), newWay(2, 1, 2, 4)}, nodesSet, macro.WithDrivingSide(drivingSide))
+	assert.NoError(t, err)
+	mvmtStorage, err := macroNet.GenerateMovements()
+	assert.NoError(t, err)
+	mesoNet, err := meso.NewNetFromMacroscopic(macroNet, mvmtStorage)
+	assert.NoError(t, err)
+	return mesoNet, mvmtStorage
+}
+
+// laneCells returns forward cells of every lane of every mesoscopic link ordered along the lane
+func laneCells(net *Net) map[gmns.LinkID]map[int][]*Link {
+	cells := make(map[gmns.LinkID]map[int][]*Link)
+	for _, link := range net.Links {
+		if link.cellType != CELL_TYPE_FORWARD {
+			continue
+		}
+		if _, ok := cells[link.mesoLinkID]; !ok {
+			cells[link.mesoLinkID] = make(map[int][]*Link)
+		}
+		cells[link.mesoLinkID][link.laneIndex] = append(cells[link.mesoLinkID][link.laneIndex], link)
+	}
+	for _, lanes := range cells {
+		for _, lane := range lanes {
+			sort.Slice(lane, func(i, j int) bool {
+				return net.Nodes[lane[i].sourceNodeID].cellIndex < net.Nodes[lane[j].sourceNodeID].cellIndex
+			})
+		}
+	}
+	return cells
+}
+
+func TestNewNetFromMesoscopic(t *testing.T) {
+	mesoNet, mvmtStorage := prepareTestNets(t, types.DRIVING_SIDE_RIGHT)
+	cellLength, laneWidth := 5.0, 3.0
+	net, err := NewNetFromMesoscopic(mesoNet, mvmtStorage, WithCellLength(cellLength), WithLaneWidth(laneWidth))
+	assert.NoError(t, err)
+
+	cells := laneCells(net)
+	laneChanges := make(map[gmns.LinkID]int)
+	for _, link := range net.Links {
+		if link.cellType == CELL_TYPE_LANE_CHANGE {
+			laneChanges[link.mesoLinkID]++
+		}
+	}
+	checked := 0
+	for _, mesoLink := range mesoNet.Links {
+		if mesoLink.IsConnection() {
+			continue
+		}
+		laneIndices := mesoLink.GetLaneIndices()
+		lanes := cells[mesoLink.ID]
+		assert.Len(t, lanes, len(laneIndices), "Every lane of mesoscopic link %d should have cells", mesoLink.ID)
+		cellsNum := max(1, int(math.Round(mesoLink.GetLengthMeters()/cellLength)))
+		for pos, laneIndex := range laneIndices {
+			lane := lanes[laneIndex]
+			// Spacing
+			assert.Len(t, lane, cellsNum, "Wrong number of cells on lane %d of mesoscopic link %d", laneIndex, mesoLink.ID)
+			for i, cell := range lane {
+				if mesoLink.GetLengthMeters() >= cellLength {
+					assert.InDelta(t, cellLength, cell.lengthMeters, cellLength/2, "Cell length should be close to configured one")
+				}
+				if i > 0 {
+					assert.Equal(t, lane[i-1].targetNodeID, cell.sourceNodeID, "Cells of the lane should be chained")
+				}
+			}
+			// Lane geometry: lanes are parallel to the road with lane width between them.
+			// Road goes to the east, so inner (left) lane is northern one for right-hand traffic
+			if pos == 0 {
+				continue
+			}
+			inner, outer := lanes[laneIndices[pos-1]], lane
+			for i := range lane {
+				innerPt, outerPt := inner[i].geom[0], outer[i].geom[0]
+				assert.Greater(t, innerPt.Lat(), outerPt.Lat(), "Inner lane should be to the left of outer one")
+				assert.InDelta(t, laneWidth, geo.Distance(innerPt, outerPt), 0.05, "Lanes should be separated by lane width")
+			}
+		}
+		// Lane changes: both ways between every pair of adjacent lanes for every cell
+		assert.Equal(t, 2*(len(laneIndices)-1)*cellsNum, laneChanges[mesoLink.ID], "Wrong number of lane changes for mesoscopic link %d", mesoLink.ID)
+		checked++
+	}
+	assert.Greater(t, checked, 0, "There should be mesoscopic links")
+
+	for _, link := range net.Links {
+		source, target := net.Nodes[link.sourceNodeID], net.Nodes[link.targetNodeID]
+		switch link.cellType {
+		case CELL_TYPE_LANE_CHANGE:
+			assert.Equal(t, source.cellIndex+1, target.cellIndex, "Lane change should lead to the next cell")
+			assert.Equal(t, link.macroLinkID, target.macroLinkID, "Lane change should stay on the same link")
+			assert.NotEqual(t, source.laneIndex, target.laneIndex, "Lane change should lead to another lane")
+			assert.Equal(t, source.laneIndex, link.laneIndex, "Lane change should keep lane of the source node")
+		case CELL_TYPE_CONNECTOR:
+			mvmt, ok := mvmtStorage.Get(link.movementID)
+			assert.True(t, ok, "Connector should refer to existing movement")
+			assert.Equal(t, mvmt.IncomeMacroLinkID, source.macroLinkID, "Connector should start on incoming link of the movement")
+			assert.Equal(t, mvmt.OutcomeMacroLinkID, target.macroLinkID, "Connector should end on outcoming link of the movement")
+			incomeStart, incomeEnd := mvmt.IncomeLanes()
+			assert.True(t, source.laneIndex >= incomeStart && source.laneIndex <= incomeEnd, "Connector should start at lane of the movement")
+			outcomeStart, outcomeEnd := mvmt.OutcomeLanes()
+			assert.True(t, target.laneIndex >= outcomeStart && target.laneIndex <= outcomeEnd, "Connector should end at lane of the movement")
+		}
+	}
+
+	net, err = NewNetFromMesoscopic(mesoNet, mvmtStorage, WithLaneChanges(false))
+	assert.NoError(t, err)
+	for _, link := range net.Links {
+		assert.NotEqual(t, CELL_TYPE_LANE_CHANGE, link.cellType, "Lane changes should not be generated")
+	}
+}
+
+func TestNewNetFromMesoscopicLeftHand(t *testing.T) {
+	// Lanes are numbered from the inner side, so for left-hand traffic the inner lane is the right one: the southern one for road to the east
+	mesoNet, mvmtStorage := prepareTestNets(t, types.DRIVING_SIDE_LEFT)
+	net, err := NewNetFromMesoscopic(mesoNet, mvmtStorage)
+	assert.NoError(t, err)
+	cells := laneCells(net)
+	checked := 0
+	for _, mesoLink := range mesoNet.Links {
+		laneIndices := mesoLink.GetLaneIndices()
+		if mesoLink.IsConnection() || len(laneIndices) < 2 {
+			continue
+		}
+		lanes := cells[mesoLink.ID]
+		for pos := 1; pos < len(laneIndices); pos++ {
+			inner, outer := lanes[laneIndices[pos-1]], lanes[laneIndices[pos]]
+			for i := range outer {
+				assert.Less(t, inner[i].geom[0].Lat(), outer[i].geom[0].Lat(), "Inner lane should be to the right of outer one")
+			}
+		}
+		checked++
+	}
+	assert.Greater(t, checked, 0, "There should be multi-lane mesoscopic links")
+}
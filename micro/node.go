@@ -0,0 +1,33 @@
+package micro
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+)
+
+type Node struct {
+	ID gmns.NodeID
+	// Parent mesoscopic link
+	mesoLinkID gmns.LinkID
+	// Parent macroscopic link
+	macroLinkID gmns.LinkID
+	// Parent macroscopic node (-1 if node is not placed at the macroscopic node)
+	macroNodeID gmns.NodeID
+	// Lane number (from the inner side to the outer one) of the parent macroscopic link
+	laneIndex int
+	// Index of the cell boundary along the lane (0 - upstream end of the mesoscopic link)
+	cellIndex int
+	geom      orb.Point
+}
+
+func newNode(id gmns.NodeID, mesoLinkID gmns.LinkID, macroLinkID gmns.LinkID, macroNodeID gmns.NodeID, laneIndex int, cellIndex int, geom orb.Point) *Node {
+	return &Node{
+		ID:          id,
+		mesoLinkID:  mesoLinkID,
+		macroLinkID: macroLinkID,
+		macroNodeID: macroNodeID,
+		laneIndex:   laneIndex,
+		cellIndex:   cellIndex,
+		geom:        geom,
+	}
+}
@@ -0,0 +1,161 @@
+package movement
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+)
+
+// UTurnPolicy defines where U-turn movements are allowed
+type UTurnPolicy uint16
+
+const (
+	// U-turns are allowed everywhere except exact reversing of the incoming link
+	UTURN_POLICY_DEFAULT = UTurnPolicy(iota)
+	// U-turns are allowed everywhere including exact reversing of the incoming link
+	UTURN_POLICY_ALWAYS
+	// U-turns are not allowed at all
+	UTURN_POLICY_NEVER
+	// U-turns are allowed at dead ends only
+	UTURN_POLICY_DEAD_END
+	// U-turns are allowed between carriageways of divided roads only
+	UTURN_POLICY_DIVIDED
+)
+
+func (iotaIdx UTurnPolicy) String() string {
+	return [...]string{"default", "always", "never", "dead_end", "divided"}[iotaIdx]
+}
+
+// ClassifierConfig describes how movements are classified
+type ClassifierConfig struct {
+	// Max absolute turning angle (radians) for through movements
+	ThruAngle float64
+	// Min absolute turning angle (radians) for U-turn movements
+	UTurnAngle float64
+	// Use diagonal bounds (NEB, NWB, SEB, SWB) in addition to main ones
+	EightDirections bool
+	// Length (meters) of the link's part near the node which is used to evaluate angles. Zero or negative value means whole link
+	TailLength  float64
+	UTurnPolicy UTurnPolicy
+	// U-turns and far side turns are made to the left for right-hand traffic and to the right for left-hand traffic
+	DrivingSide types.DrivingSide
+}
+
+// NewClassifierConfigDefault returns default configuration: ±45° sectors, four bounds, whole links, default U-turn policy and right-hand traffic
+func NewClassifierConfigDefault() *ClassifierConfig {
+	return &ClassifierConfig{
+		ThruAngle:       0.25 * math.Pi,
+		UTurnAngle:      0.75 * math.Pi,
+		EightDirections: false,
+		TailLength:      0,
+		UTurnPolicy:     UTURN_POLICY_DEFAULT,
+		DrivingSide:     types.DRIVING_SIDE_RIGHT,
+	}
+}
+
+// FindMovementTypeWith extracts movement description with respect to configuration;
+// ibLine - The line with coordinates in EPSG:3857. Line represents source of movement;
+// obLine - The line with coordinates in EPSG:3857. Line represents target of movement;
+// Nil configuration is the same as default one (see NewClassifierConfigDefault).
+// Notice: use it for Euclidean space only (or EPSG:3857).
+func FindMovementTypeWith(ibLine orb.LineString, obLine orb.LineString, cfg *ClassifierConfig) (MovementCompositeType, MovementType) {
+	if cfg == nil {
+		cfg = NewClassifierConfigDefault()
+	}
+	endIB := ibLine[len(ibLine)-1]
+	startIB, endOB := ibLine[0], obLine[len(obLine)-1]
+	if cfg.TailLength > 0 {
+		tail := cfg.TailLength * geomath.EuclideanScale(geomath.PointToSpherical(endIB).Lat())
+		startIB = pointAlongLineEuclidean(ibLine, lengthEuclidean(ibLine)-tail)
+		endOB = pointAlongLineEuclidean(obLine, tail)
+	}
+
+	angleIB := math.Atan2(endIB.Y()-startIB.Y(), endIB.X()-startIB.X())
+	var direction DirectionType
+	if cfg.EightDirections {
+		switch {
+		case -0.125*math.Pi <= angleIB && angleIB < 0.125*math.Pi:
+			direction = DIRECTION_TYPE_EB
+		case 0.125*math.Pi <= angleIB && angleIB < 0.375*math.Pi:
+			direction = DIRECTION_TYPE_NEB
+		case 0.375*math.Pi <= angleIB && angleIB < 0.625*math.Pi:
+			direction = DIRECTION_TYPE_NB
+		case 0.625*math.Pi <= angleIB && angleIB < 0.875*math.Pi:
+			direction = DIRECTION_TYPE_NWB
+		case -0.375*math.Pi <= angleIB && angleIB < -0.125*math.Pi:
+			direction = DIRECTION_TYPE_SEB
+		case -0.625*math.Pi <= angleIB && angleIB < -0.375*math.Pi:
+			direction = DIRECTION_TYPE_SB
+		case -0.875*math.Pi <= angleIB && angleIB < -0.625*math.Pi:
+			direction = DIRECTION_TYPE_SWB
+		default:
+			direction = DIRECTION_TYPE_WB
+		}
+	} else {
+		if -0.75*math.Pi <= angleIB && angleIB < -0.25*math.Pi {
+			direction = DIRECTION_TYPE_SB
+		} else if -0.25*math.Pi <= angleIB && angleIB < 0.25*math.Pi {
+			direction = DIRECTION_TYPE_EB
+		} else if 0.25*math.Pi <= angleIB && angleIB < 0.75*math.Pi {
+			direction = DIRECTION_TYPE_NB
+		} else {
+			direction = DIRECTION_TYPE_WB
+		}
+	}
+
+	angleDiff := FindTurnAngle(orb.LineString{startIB, endIB}, orb.LineString{endIB, endOB})
+	// Angle is mirrored for left-hand traffic, so positive values are always for far side turns
+	farSideAngle := angleDiff
+	if cfg.DrivingSide == types.DRIVING_SIDE_LEFT {
+		farSideAngle = -angleDiff
+		if farSideAngle <= -math.Pi+1e-9 {
+			// Exact reversing is U-turn for both driving sides
+			farSideAngle = math.Pi
+		}
+	}
+
+	var movementShortType MovementShortType
+	var movementType MovementType
+	if -cfg.ThruAngle <= farSideAngle && farSideAngle <= cfg.ThruAngle {
+		movementShortType = MOVEMENT_SHORT_TYPE_THRU
+		movementType = MOVEMENT_TYPE_THRU
+	} else if farSideAngle > cfg.UTurnAngle {
+		movementShortType = MOVEMENT_SHORT_TYPE_U_TURN
+		movementType = MOVEMENT_TYPE_U_TURN
+	} else if angleDiff < 0 {
+		movementShortType = MOVEMENT_SHORT_TYPE_RIGHT
+		movementType = MOVEMENT_TYPE_RIGHT
+	} else {
+		movementShortType = MOVEMENT_SHORT_TYPE_LEFT
+		movementType = MOVEMENT_TYPE_LEFT
+	}
+
+	return movementTextIDsMatch[direction.String()+movementShortType.String()], movementType
+}
+
+// lengthEuclidean returns length of the line in EPSG:3857 units
+func lengthEuclidean(line orb.LineString) float64 {
+	length := 0.0
+	for i := 1; i < len(line); i++ {
+		length += math.Hypot(line[i][0]-line[i-1][0], line[i][1]-line[i-1][1])
+	}
+	return length
+}
+
+// pointAlongLineEuclidean returns point at the given distance along the line in EPSG:3857. Distance is clamped to the line length
+func pointAlongLineEuclidean(line orb.LineString, distance float64) orb.Point {
+	if distance <= 0 {
+		return line[0]
+	}
+	for i := 1; i < len(line); i++ {
+		segmentLength := math.Hypot(line[i][0]-line[i-1][0], line[i][1]-line[i-1][1])
+		if distance <= segmentLength && segmentLength > 0 {
+			ratio := distance / segmentLength
+			return orb.Point{line[i-1][0] + ratio*(line[i][0]-line[i-1][0]), line[i-1][1] + ratio*(line[i][1]-line[i-1][1])}
+		}
+		distance -= segmentLength
+	}
+	return line[len(line)-1]
+}
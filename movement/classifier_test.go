@@ -0,0 +1,53 @@
+package movement
+
+import (
+	"math"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMovementTypeWith(t *testing.T) {
+	// Approach goes to the north-east, exit goes to the north
+	givenInboundLine := orb.LineString{{0, 0}, {100, 100}}
+	givenOutboundLine := orb.LineString{{100, 100}, {100, 200}}
+
+	cfg := NewClassifierConfigDefault()
+	mvmtTextID, mvmtType := FindMovementTypeWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, MOVEMENT_NBT, mvmtTextID, "Wrong movement text ID for four bounds")
+	assert.Equal(t, MOVEMENT_TYPE_THRU, mvmtType, "Wrong movement type for default thresholds")
+	mvmtTextID, mvmtType = FindMovementTypeWith(givenInboundLine, givenOutboundLine, nil)
+	assert.Equal(t, MOVEMENT_NBT, mvmtTextID, "Default configuration should be used")
+	assert.Equal(t, MOVEMENT_TYPE_THRU, mvmtType, "Default configuration should be used")
+
+	cfg.EightDirections = true
+	cfg.ThruAngle = math.Pi / 6
+	mvmtTextID, mvmtType = FindMovementTypeWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, MOVEMENT_NEBL, mvmtTextID, "Wrong movement text ID for eight bounds")
+	assert.Equal(t, MOVEMENT_TYPE_LEFT, mvmtType, "Wrong movement type for custom thresholds")
+	assert.Equal(t, DIRECTION_TYPE_NB, mvmtTextID.Direction().Cardinal(), "Wrong cardinal direction")
+
+	// Approach bends near the node: only the last meters matter
+	givenInboundLine = orb.LineString{{0, -1000}, {0, 0}, {100, 0}}
+	givenOutboundLine = orb.LineString{{100, 0}, {200, 0}}
+	mvmtTextID, _ = FindMovementTypeWith(givenInboundLine, givenOutboundLine, NewClassifierConfigDefault())
+	assert.Equal(t, MOVEMENT_NBR, mvmtTextID, "Whole link should be used by default")
+	cfg = NewClassifierConfigDefault()
+	cfg.TailLength = 50
+	mvmtTextID, _ = FindMovementTypeWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, MOVEMENT_EBT, mvmtTextID, "Only the tail of the link should be used")
+
+	// Sharp turn to the right is U-turn for left-hand traffic only
+	givenInboundLine = orb.LineString{{0, 0}, {0, 100}}
+	givenOutboundLine = orb.LineString{{0, 100}, {20, 0}}
+	_, mvmtType = FindMovementTypeWith(givenInboundLine, givenOutboundLine, NewClassifierConfigDefault())
+	assert.Equal(t, MOVEMENT_TYPE_RIGHT, mvmtType, "Sharp right turn should stay right turn for right-hand traffic")
+	cfg = NewClassifierConfigDefault()
+	cfg.DrivingSide = types.DRIVING_SIDE_LEFT
+	mvmtTextID, mvmtType = FindMovementTypeWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, MOVEMENT_NBU, mvmtTextID, "Wrong movement text ID for left-hand traffic")
+	assert.Equal(t, MOVEMENT_TYPE_U_TURN, mvmtType, "Sharp right turn should be U-turn for left-hand traffic")
+	assert.Equal(t, MOVEMENT_TYPE_LEFT, MOVEMENT_TYPE_RIGHT.RightHandEquivalent(types.DRIVING_SIDE_LEFT), "Right turn is far side turn for left-hand traffic")
+}
@@ -10,13 +10,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-type MovementsStorage map[MovementID]*Movement
-
-func NewMovementsStorage() map[MovementID]*Movement {
-	return make(map[MovementID]*Movement)
-}
-
-func (mvmtStorage MovementsStorage) ExportToCSV(fname string) error {
+func (mvmtStorage *MovementsStorage) ExportToCSV(fname string) error {
 	file, err := os.Create(fname)
 	if err != nil {
 		return errors.Wrap(err, "Can't create file")
@@ -27,13 +21,12 @@ func (mvmtStorage MovementsStorage) ExportToCSV(fname string) error {
 	defer writer.Flush()
 	writer.Comma = ';'
 
-	err = writer.Write([]string{"id", "node_id", "osm_node_id", "name", "in_link_id", "in_lane_start", "in_lane_end", "out_link_id", "out_lane_start", "out_lane_end", "lanes_num", "from_osm_node_id", "to_osm_node_id", "type", "penalty", "capacity", "control_type", "movement_composite_type", "volume", "free_speed", "allowed_agent_types", "geom"})
+	err = writer.Write([]string{"id", "node_id", "osm_node_id", "name", "in_link_id", "in_lane_start", "in_lane_end", "out_link_id", "out_lane_start", "out_lane_end", "lanes_num", "from_osm_node_id", "to_osm_node_id", "type", "penalty", "capacity", "control_type", "priority", "priority_rank", "movement_composite_type", "roundabout_exit", "volume", "free_speed", "allowed_agent_types", "geom"})
 	if err != nil {
 		return errors.Wrap(err, "Can't write header")
 	}
 
-	for k := range mvmtStorage {
-		mvmt := mvmtStorage[k]
+	for _, mvmt := range mvmtStorage.List() {
 		allowedAgentTypes := make([]string, len(mvmt.allowedAgentTypes))
 		for i, agentType := range mvmt.allowedAgentTypes {
 			allowedAgentTypes[i] = agentType.String()
@@ -53,12 +46,15 @@ func (mvmtStorage MovementsStorage) ExportToCSV(fname string) error {
 			fmt.Sprintf("%d", mvmt.fromOsmNodeID),
 			fmt.Sprintf("%d", mvmt.toOsmNodeID),
 			mvmt.MType.String(),
-			fmt.Sprintf("%d", -1),
-			fmt.Sprintf("%d", -1),
+			fmt.Sprintf("%f", mvmt.penalty),
+			fmt.Sprintf("%d", mvmt.capacity),
 			mvmt.controlType.String(),
+			mvmt.priority.String(),
+			fmt.Sprintf("%d", mvmt.priority.RankFor(mvmt.drivingSide)),
 			mvmt.MTextID.String(),
-			fmt.Sprintf("%d", -1),
-			fmt.Sprintf("%d", -1),
+			fmt.Sprintf("%d", mvmt.roundaboutExit),
+			fmt.Sprintf("%f", mvmt.volume),
+			fmt.Sprintf("%f", mvmt.freeSpeed),
 			strings.Join(allowedAgentTypes, ","),
 			wkt.MarshalString(mvmt.Geom),
 		})
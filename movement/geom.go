@@ -17,23 +17,18 @@ const (
 // Returns one of corresponding values: NBL, NBT, NBR, NBU, SBL, SBT, SBR, SBU, EBL, EBT, EBR, EBU, WBL, WBT, WBR, WBU along with corresponding movement with possible values: thru, right, left, uturn;
 // Notice: use it for Euclidean space only (or EPSG:3857).
 func FindMovementType(ibLine orb.LineString, obLine orb.LineString) (MovementCompositeType, MovementType) {
+	return FindMovementTypeWith(ibLine, obLine, NewClassifierConfigDefault())
+}
+
+// FindTurnAngle returns signed turning angle (radians) between source and target of movement. Positive values are for left turns, negative values are for right turns;
+// ibLine - The line with coordinates in EPSG:3857. Line represents source of movement;
+// obLine - The line with coordinates in EPSG:3857. Line represents target of movement;
+// Notice: use it for Euclidean space only (or EPSG:3857).
+func FindTurnAngle(ibLine orb.LineString, obLine orb.LineString) float64 {
 	startIB, endIB := ibLine[0], ibLine[len(ibLine)-1]
 	endOB := obLine[len(obLine)-1]
 
-	var direction DirectionType
-
 	angleIB := math.Atan2(endIB.Y()-startIB.Y(), endIB.X()-startIB.X())
-
-	if -0.75*math.Pi <= angleIB && angleIB < -0.25*math.Pi {
-		direction = DIRECTION_TYPE_SB
-	} else if -0.25*math.Pi <= angleIB && angleIB < 0.25*math.Pi {
-		direction = DIRECTION_TYPE_EB
-	} else if 0.25*math.Pi <= angleIB && angleIB < 0.75*math.Pi {
-		direction = DIRECTION_TYPE_NB
-	} else {
-		direction = DIRECTION_TYPE_WB
-	}
-
 	angleOB := math.Atan2(endOB.Y()-endIB.Y(), endOB.X()-endIB.X())
 
 	angleDiff := angleOB - angleIB
@@ -44,24 +39,20 @@ func FindMovementType(ibLine orb.LineString, obLine orb.LineString) (MovementCom
 	if angleDiff > math.Pi {
 		angleDiff -= 2 * math.Pi
 	}
+	return angleDiff
+}
 
-	var movementShortType MovementShortType
-	var movementType MovementType
-	if -0.25*math.Pi <= angleDiff && angleDiff <= 0.25*math.Pi {
-		movementShortType = MOVEMENT_SHORT_TYPE_THRU
-		movementType = MOVEMENT_TYPE_THRU
-	} else if angleDiff < -0.25*math.Pi {
-		movementShortType = MOVEMENT_SHORT_TYPE_RIGHT
-		movementType = MOVEMENT_TYPE_RIGHT
-	} else if angleDiff <= 0.75*math.Pi {
-		movementShortType = MOVEMENT_SHORT_TYPE_LEFT
-		movementType = MOVEMENT_TYPE_LEFT
-	} else {
-		movementShortType = MOVEMENT_SHORT_TYPE_U_TURN
-		movementType = MOVEMENT_TYPE_U_TURN
+// FindTurnRadius estimates radius (meters) of the circular arc which connects ends of movement geometry;
+// geom - movement geometry in EPSG:4326;
+// turnAngle - signed turning angle in radians (see FindTurnAngle);
+// Returns +Inf for straight movements.
+func FindTurnRadius(geom orb.LineString, turnAngle float64) float64 {
+	halfAngle := math.Abs(turnAngle) / 2.0
+	if len(geom) < 2 || math.Sin(halfAngle) < 1e-6 {
+		return math.Inf(1)
 	}
-
-	return movementTextIDsMatch[direction.String()+movementShortType.String()], movementType
+	chord := geo.Distance(geom[0], geom[len(geom)-1])
+	return chord / (2.0 * math.Sin(halfAngle))
 }
 
 // FindMovementGeom returns movement geometry for given lines pair;
@@ -69,19 +60,5 @@ func FindMovementType(ibLine orb.LineString, obLine orb.LineString) (MovementCom
 // obLine - The line represents target of movement;
 // Notice: panics if number of points in any line is less than 2.
 func FindMovementGeom(ibLine orb.LineString, obLine orb.LineString) orb.LineString {
-	indentIB := indentationThreshold
-	lengthIB := geo.Length(ibLine)
-	if lengthIB <= indentIB {
-		indentIB = lengthIB / 2.0
-	}
-	pointIB, _ := geo.PointAtDistanceAlongLine(ibLine, lengthIB-indentIB) // Ident from link end
-
-	indentOB := indentationThreshold
-	lengthOB := geo.Length(obLine)
-	if lengthOB <= indentOB {
-		indentOB = lengthOB / 2.0
-	}
-
-	pointOB, _ := geo.PointAtDistanceAlongLine(obLine, indentOB)
-	return orb.LineString{pointIB, pointOB}
+	return FindMovementGeomWith(ibLine, obLine, NewGeomConfigDefault())
 }
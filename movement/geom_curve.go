@@ -0,0 +1,186 @@
+package movement
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/geomath"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// GeomType is the shape of movement geometry
+type GeomType uint16
+
+const (
+	GEOM_TYPE_STRAIGHT = GeomType(iota)
+	GEOM_TYPE_QUADRATIC_BEZIER
+	GEOM_TYPE_CIRCULAR_ARC
+)
+
+func (iotaIdx GeomType) String() string {
+	return [...]string{"straight", "quadratic_bezier", "circular_arc"}[iotaIdx]
+}
+
+// GeomConfig describes how movement geometry is prepared
+type GeomConfig struct {
+	GeomType GeomType
+	// Number of points in curved geometry
+	PointsNum int
+	// Distance (meters) from the node along incoming and outcoming links where movement starts and ends
+	Indent float64
+	// Lateral offset (meters) of the movement. Positive values are for the right side of travel direction. Useful for lane-level paths.
+	// Notice: macroscopic network mirrors it for left-hand traffic, so positive values are always for the kerb side there
+	LaneOffset float64
+}
+
+// NewGeomConfigDefault returns default configuration: straight segment between points 8 meters back on each link
+func NewGeomConfigDefault() *GeomConfig {
+	return &GeomConfig{
+		GeomType:   GEOM_TYPE_STRAIGHT,
+		PointsNum:  10,
+		Indent:     indentationThreshold,
+		LaneOffset: 0,
+	}
+}
+
+// FindMovementGeomWith returns movement geometry for given lines pair with respect to configuration;
+// ibLine - The line represents source of movement (EPSG:4326);
+// obLine - The line represents target of movement (EPSG:4326);
+// Curved geometries are fitted between approach and exit tangents. If tangents do not intersect in front of the movement then straight segment is returned.
+// Nil configuration is the same as default one (see NewGeomConfigDefault).
+// Notice: panics if number of points in any line is less than 2.
+func FindMovementGeomWith(ibLine orb.LineString, obLine orb.LineString, cfg *GeomConfig) orb.LineString {
+	if cfg == nil {
+		cfg = NewGeomConfigDefault()
+	}
+	indentIB := cfg.Indent
+	lengthIB := geo.Length(ibLine)
+	if lengthIB <= indentIB {
+		indentIB = lengthIB / 2.0
+	}
+	pointIB, _ := geo.PointAtDistanceAlongLine(ibLine, lengthIB-indentIB) // Ident from link end
+
+	indentOB := cfg.Indent
+	lengthOB := geo.Length(obLine)
+	if lengthOB <= indentOB {
+		indentOB = lengthOB / 2.0
+	}
+	pointOB, _ := geo.PointAtDistanceAlongLine(obLine, indentOB)
+	if cfg.GeomType == GEOM_TYPE_STRAIGHT && cfg.LaneOffset == 0 {
+		return orb.LineString{pointIB, pointOB}
+	}
+
+	// Tangents in Euclidean space
+	scale := geomath.EuclideanScale(ibLine[len(ibLine)-1].Lat())
+	tangentIB := geomath.LineToEuclidean(orb.LineString{pointIB, ibLine[len(ibLine)-1]})
+	tangentOB := geomath.LineToEuclidean(orb.LineString{obLine[0], pointOB})
+	if cfg.LaneOffset != 0 {
+		tangentIB = geomath.OffsetCurve(tangentIB, -cfg.LaneOffset*scale)
+		tangentOB = geomath.OffsetCurve(tangentOB, -cfg.LaneOffset*scale)
+	}
+	start, end := tangentIB[0], tangentOB[1]
+	dirIB, okIB := unitVector(tangentIB[0], tangentIB[1])
+	dirOB, okOB := unitVector(tangentOB[0], tangentOB[1])
+	if cfg.GeomType == GEOM_TYPE_STRAIGHT || !okIB || !okOB {
+		return geomath.LineToSpherical(orb.LineString{start, end})
+	}
+
+	var curve orb.LineString
+	pointsNum := max(cfg.PointsNum, 3)
+	chord := orb.Point{end[0] - start[0], end[1] - start[1]}
+	cross := dirIB[0]*dirOB[1] - dirIB[1]*dirOB[0]
+	if math.Abs(cross) < 1e-6 {
+		if dirIB[0]*dirOB[0]+dirIB[1]*dirOB[1] > 0 {
+			// Tangents are parallel: just straight movement
+			return geomath.LineToSpherical(orb.LineString{start, end})
+		}
+		// Tangents are opposite: U-turn
+		if cfg.GeomType == GEOM_TYPE_CIRCULAR_ARC {
+			curve = semicircle(start, end, dirIB, pointsNum)
+		} else {
+			chordLen := math.Hypot(chord[0], chord[1])
+			control := orb.Point{(start[0]+end[0])/2.0 + dirIB[0]*chordLen, (start[1]+end[1])/2.0 + dirIB[1]*chordLen}
+			curve = quadraticBezier(start, control, end, 1.0, pointsNum)
+		}
+		return geomath.LineToSpherical(curve)
+	}
+
+	// Intersection of tangents: start + t*dirIB = end - s*dirOB
+	t := (chord[0]*dirOB[1] - chord[1]*dirOB[0]) / cross
+	s := (dirIB[0]*chord[1] - dirIB[1]*chord[0]) / cross
+	if t <= 0 || s <= 0 {
+		return geomath.LineToSpherical(orb.LineString{start, end})
+	}
+	control := orb.Point{start[0] + t*dirIB[0], start[1] + t*dirIB[1]}
+
+	switch cfg.GeomType {
+	case GEOM_TYPE_QUADRATIC_BEZIER:
+		curve = quadraticBezier(start, control, end, 1.0, pointsNum)
+	case GEOM_TYPE_CIRCULAR_ARC:
+		// Circular arc needs equal tangents: trim the longer one and keep the rest (if it is longer than 1 cm or so) as straight part
+		tangentLen := math.Min(t, s)
+		arcStart := orb.Point{control[0] - tangentLen*dirIB[0], control[1] - tangentLen*dirIB[1]}
+		arcEnd := orb.Point{control[0] + tangentLen*dirOB[0], control[1] + tangentLen*dirOB[1]}
+		// Weight of the middle control point of rational Bezier curve: sine of half of the angle between tangents
+		angleAtControl := math.Acos(math.Max(-1, math.Min(1, -(dirIB[0]*dirOB[0]+dirIB[1]*dirOB[1]))))
+		arc := quadraticBezier(arcStart, control, arcEnd, math.Sin(angleAtControl/2.0), pointsNum)
+		if t-tangentLen > 0.01 {
+			curve = append(curve, start)
+		}
+		curve = append(curve, arc...)
+		if s-tangentLen > 0.01 {
+			curve = append(curve, end)
+		}
+	default:
+		curve = orb.LineString{start, end}
+	}
+	return geomath.LineToSpherical(curve)
+}
+
+// quadraticBezier samples rational quadratic Bezier curve. Weight equal to 1 gives ordinary quadratic Bezier curve
+func quadraticBezier(p0, p1, p2 orb.Point, weight float64, pointsNum int) orb.LineString {
+	curve := make(orb.LineString, pointsNum)
+	for i := 0; i < pointsNum; i++ {
+		u := float64(i) / float64(pointsNum-1)
+		b0 := (1 - u) * (1 - u)
+		b1 := 2 * weight * (1 - u) * u
+		b2 := u * u
+		denom := b0 + b1 + b2
+		curve[i] = orb.Point{
+			(b0*p0[0] + b1*p1[0] + b2*p2[0]) / denom,
+			(b0*p0[1] + b1*p1[1] + b2*p2[1]) / denom,
+		}
+	}
+	return curve
+}
+
+// semicircle samples half of the circle between two points. Direction of rotation is picked to follow the given tangent at the start
+func semicircle(start, end orb.Point, tangent orb.Point, pointsNum int) orb.LineString {
+	center := orb.Point{(start[0] + end[0]) / 2.0, (start[1] + end[1]) / 2.0}
+	radiusVec := orb.Point{start[0] - center[0], start[1] - center[1]}
+	sign := 1.0
+	// Counter-clockwise rotation moves start point along (-y, x)
+	if -radiusVec[1]*tangent[0]+radiusVec[0]*tangent[1] < 0 {
+		sign = -1.0
+	}
+	curve := make(orb.LineString, pointsNum)
+	for i := 0; i < pointsNum; i++ {
+		angle := sign * math.Pi * float64(i) / float64(pointsNum-1)
+		cos, sin := math.Cos(angle), math.Sin(angle)
+		curve[i] = orb.Point{
+			center[0] + radiusVec[0]*cos - radiusVec[1]*sin,
+			center[1] + radiusVec[0]*sin + radiusVec[1]*cos,
+		}
+	}
+	return curve
+}
+
+// unitVector returns normalized vector from p1 to p2. Second returned value is false for degenerate vector
+func unitVector(p1, p2 orb.Point) (orb.Point, bool) {
+	dx, dy := p2[0]-p1[0], p2[1]-p1[1]
+	length := math.Hypot(dx, dy)
+	if length < 1e-9 {
+		return orb.Point{}, false
+	}
+	return orb.Point{dx / length, dy / length}, true
+}
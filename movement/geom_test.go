@@ -2,6 +2,7 @@ package movement
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/LdDl/osm2gmns/geomath"
@@ -170,3 +171,33 @@ func TestFindMovementGeom(t *testing.T) {
 		assert.InDelta(t, expectedMovementGeom[i][1], pt[1], precision, fmt.Sprintf("Wrong Y (latitude) in EPSG:3857 at pos #%d", i))
 	}
 }
+
+func TestFindMovementGeomCurved(t *testing.T) {
+	// Left turn: northbound approach, westbound exit
+	givenInboundLine := geomath.LineToSpherical(orb.LineString{{0, -100}, {0, 0}})
+	givenOutboundLine := geomath.LineToSpherical(orb.LineString{{0, 0}, {-100, 0}})
+	straight := FindMovementGeom(givenInboundLine, givenOutboundLine)
+	assert.Equal(t, straight, FindMovementGeomWith(givenInboundLine, givenOutboundLine, nil), "Default configuration should be used")
+
+	cfg := NewGeomConfigDefault()
+	cfg.GeomType = GEOM_TYPE_CIRCULAR_ARC
+	cfg.PointsNum = 7
+	curve := FindMovementGeomWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, cfg.PointsNum, len(curve), "Incorrect number of points in curved movement geometry")
+	assert.InDelta(t, straight[0][0], curve[0][0], 10e-8, "Curve should start where straight movement starts")
+	assert.InDelta(t, straight[0][1], curve[0][1], 10e-8, "Curve should start where straight movement starts")
+	assert.InDelta(t, straight[1][0], curve[len(curve)-1][0], 10e-8, "Curve should end where straight movement ends")
+	assert.InDelta(t, straight[1][1], curve[len(curve)-1][1], 10e-8, "Curve should end where straight movement ends")
+
+	// All points of the arc are on the same distance from the center
+	curveEuclidean := geomath.LineToEuclidean(curve)
+	radius := -curveEuclidean[0][1]
+	center := orb.Point{-radius, -radius}
+	for i, pt := range curveEuclidean {
+		assert.InDelta(t, radius, math.Hypot(pt[0]-center[0], pt[1]-center[1]), 10e-6, fmt.Sprintf("Point #%d is not on the arc", i))
+	}
+
+	cfg.GeomType = GEOM_TYPE_QUADRATIC_BEZIER
+	curve = FindMovementGeomWith(givenInboundLine, givenOutboundLine, cfg)
+	assert.Equal(t, cfg.PointsNum, len(curve), "Incorrect number of points in Bezier movement geometry")
+}
@@ -1,8 +1,6 @@
 package movement
 
 import (
-	"sync"
-
 	"github.com/LdDl/osm2gmns/geomath"
 	"github.com/LdDl/osm2gmns/gmns"
 	"github.com/LdDl/osm2gmns/types"
@@ -10,24 +8,6 @@ import (
 	"github.com/paulmach/osm"
 )
 
-type autoInc struct {
-	sync.Mutex
-	id MovementID
-}
-
-func (a *autoInc) ID() (id MovementID) {
-	a.Lock()
-	defer a.Unlock()
-
-	id = a.id
-	a.id++
-	return
-}
-
-var (
-	ai autoInc
-)
-
 type MovementID int
 
 type Movement struct {
@@ -38,8 +18,16 @@ type Movement struct {
 	MTextID     MovementCompositeType
 	osmNodeID   osm.NodeID
 	controlType types.ControlType
+	priority    MovementPriority
 	lanesNum    int
 
+	// Penalty (seconds), capacity (veh/h) and free speed (km/h) of the movement
+	penalty   float64
+	capacity  int
+	freeSpeed float64
+	// Assigned volume (veh/h). -1 if no assignment has been done
+	volume float64
+
 	MacroNodeID                              gmns.NodeID
 	IncomeMacroLinkID                        gmns.LinkID
 	fromOsmNodeID                            osm.NodeID
@@ -55,6 +43,10 @@ type Movement struct {
 	GeomEuclidean orb.LineString
 
 	allowedAgentTypes []types.AgentType
+
+	drivingSide types.DrivingSide
+	// Number of the exit (1st, 2nd, 3rd and etc.) counted along the ring from the entry for movements between roundabout's approaches. Zero for other movements
+	roundaboutExit int
 }
 
 // NewMovement constructs new movement;
@@ -64,10 +56,11 @@ type Movement struct {
 // mvmtTxtID - composite type of movement. One of: SBT, SBR, SBL, SBU, EBT, EBR, EBL, EBU, NBT, NBR, NBL, NBU, WBT, WBR, WBL, WBU;
 // mvmtType - type of movement. One of: THRU, RIGHT, LEFT, U_TURN;
 // geom - geometry for the Movement in EPSG:4326. It will prepares EPSG:3857 automatically.
+// Notice: identifier is -1 until movement is added to the MovementsStorage.
 func NewMovement(macroNodeID gmns.NodeID, incomeMacroLinkID, outcomeMacroLinkID gmns.LinkID, mvmtTxtID MovementCompositeType, mvmtType MovementType, geom orb.LineString, options ...func(*Movement)) Movement {
 	mvmt := Movement{
 		name:               "-",
-		ID:                 ai.ID(),
+		ID:                 -1,
 		MType:              mvmtType,
 		MTextID:            mvmtTxtID,
 		MacroNodeID:        macroNodeID,
@@ -75,6 +68,10 @@ func NewMovement(macroNodeID gmns.NodeID, incomeMacroLinkID, outcomeMacroLinkID
 		OutcomeMacroLinkID: outcomeMacroLinkID,
 		Geom:               geom,
 		GeomEuclidean:      geomath.LineToEuclidean(geom),
+		penalty:            -1,
+		capacity:           -1,
+		freeSpeed:          -1,
+		volume:             -1,
 	}
 	for _, o := range options {
 		o(&mvmt)
@@ -99,6 +96,13 @@ func WithControlType(ctrlType types.ControlType) func(*Movement) {
 	}
 }
 
+// WithPriority sets priority of the movement at unsignalized intersection
+func WithPriority(priority MovementPriority) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.priority = priority
+	}
+}
+
 // WithOSMNode sets OSM node ID for the movement
 // Notice: you should provide OSM node ID for underlying macro node
 func WithOSMNode(osmNodeID osm.NodeID) func(*Movement) {
@@ -165,9 +169,120 @@ func WithOutcomeLaneSequence(startIdx int, endIdx int) func(*Movement) {
 	}
 }
 
+// WithPenalty sets penalty (seconds) for the movement
+func WithPenalty(penalty float64) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.penalty = penalty
+	}
+}
+
+// WithCapacity sets capacity (veh/h) for the movement
+func WithCapacity(capacity int) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.capacity = capacity
+	}
+}
+
+// WithFreeSpeed sets free speed (km/h) for the movement
+func WithFreeSpeed(freeSpeed float64) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.freeSpeed = freeSpeed
+	}
+}
+
+// WithDrivingSide sets driving side of the network which movement belongs to
+func WithDrivingSide(drivingSide types.DrivingSide) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.drivingSide = drivingSide
+	}
+}
+
+// WithRoundaboutExit sets number of the exit (1st, 2nd, 3rd and etc.) counted along the ring of the roundabout from the entry
+func WithRoundaboutExit(exitNumber int) func(*Movement) {
+	return func(mvmt *Movement) {
+		mvmt.roundaboutExit = exitNumber
+	}
+}
+
 // WithName sets alias for the movement
 func WithName(name string) func(*Movement) {
 	return func(mvmt *Movement) {
 		mvmt.name = name
 	}
 }
+
+// ControlType returns control type of the movement
+func (mvmt *Movement) ControlType() types.ControlType {
+	return mvmt.controlType
+}
+
+// Priority returns priority of the movement at unsignalized intersection
+func (mvmt *Movement) Priority() MovementPriority {
+	return mvmt.priority
+}
+
+// LanesNum returns number of lanes in the movement
+func (mvmt *Movement) LanesNum() int {
+	return mvmt.lanesNum
+}
+
+// IncomeLanes returns start and end lane numbers for the lane's segment of income macro link
+func (mvmt *Movement) IncomeLanes() (int, int) {
+	return mvmt.incomeLaneStart, mvmt.incomeLaneEnd
+}
+
+// OutcomeLanes returns start and end lane numbers for the lane's segment of outcome macro link
+func (mvmt *Movement) OutcomeLanes() (int, int) {
+	return mvmt.outcomeLaneStart, mvmt.outcomeLaneEnd
+}
+
+// IncomeLaneSequence returns start and end index for the lane's segment of income macro link
+func (mvmt *Movement) IncomeLaneSequence() (int, int) {
+	return mvmt.startIncomeLaneSeqID, mvmt.endIncomeLaneSeqID
+}
+
+// OutcomeLaneSequence returns start and end index for the lane's segment of outcome macro link
+func (mvmt *Movement) OutcomeLaneSequence() (int, int) {
+	return mvmt.startOutcomeLaneSeqID, mvmt.endOutcomeLaneSeqID
+}
+
+// AllowedAgentTypes returns agent types which are allowed to use the movement
+func (mvmt *Movement) AllowedAgentTypes() []types.AgentType {
+	return mvmt.allowedAgentTypes
+}
+
+// Penalty returns penalty (seconds) of the movement
+func (mvmt *Movement) Penalty() float64 {
+	return mvmt.penalty
+}
+
+// Capacity returns capacity (veh/h) of the movement
+func (mvmt *Movement) Capacity() int {
+	return mvmt.capacity
+}
+
+// FreeSpeed returns free speed (km/h) of the movement
+func (mvmt *Movement) FreeSpeed() float64 {
+	return mvmt.freeSpeed
+}
+
+// DrivingSide returns driving side of the network which movement belongs to
+func (mvmt *Movement) DrivingSide() types.DrivingSide {
+	return mvmt.drivingSide
+}
+
+// RoundaboutExit returns number of the exit (1st, 2nd, 3rd and etc.) for the movement between roundabout's approaches
+// (e.g. through collapsed roundabout or U-turn at the ring node). Zero for other movements
+func (mvmt *Movement) RoundaboutExit() int {
+	return mvmt.roundaboutExit
+}
+
+// Volume returns assigned volume (veh/h) of the movement. It is -1 if no assignment has been done
+func (mvmt *Movement) Volume() float64 {
+	return mvmt.volume
+}
+
+// SetVolume sets assigned volume (veh/h) of the movement
+func (mvmt *Movement) SetVolume(volume float64) {
+	mvmt.volume = volume
+}
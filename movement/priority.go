@@ -0,0 +1,88 @@
+package movement
+
+import (
+	"github.com/LdDl/osm2gmns/types"
+)
+
+var (
+	movementsPriorities = []string{"undefined", "major_thru", "major_right", "major_left", "major_uturn", "minor_right", "minor_thru", "minor_left", "minor_uturn"}
+	// Ranks of movements according to HCM (Highway Capacity Manual) unsignalized intersections methodology
+	movementsPrioritiesRanks = []int{-1, 1, 1, 2, 2, 2, 3, 4, 4}
+)
+
+// MovementPriority is the priority of movement at unsignalized intersection
+type MovementPriority uint16
+
+const (
+	MOVEMENT_PRIORITY_UNDEFINED = MovementPriority(iota)
+	MOVEMENT_PRIORITY_MAJOR_THRU
+	MOVEMENT_PRIORITY_MAJOR_RIGHT
+	MOVEMENT_PRIORITY_MAJOR_LEFT
+	MOVEMENT_PRIORITY_MAJOR_U_TURN
+	MOVEMENT_PRIORITY_MINOR_RIGHT
+	MOVEMENT_PRIORITY_MINOR_THRU
+	MOVEMENT_PRIORITY_MINOR_LEFT
+	MOVEMENT_PRIORITY_MINOR_U_TURN
+)
+
+func (iotaIdx MovementPriority) String() string {
+	return movementsPriorities[iotaIdx]
+}
+
+// Rank returns priority rank for right-hand traffic: 1 - the highest priority (major through and major right), 4 - the lowest priority (minor left).
+// Returns -1 for undefined priority
+func (iotaIdx MovementPriority) Rank() int {
+	return movementsPrioritiesRanks[iotaIdx]
+}
+
+// RankFor returns priority rank with respect to driving side: near side turns (right for right-hand traffic and left for left-hand traffic) have higher priority than far side ones.
+// Returns -1 for undefined priority
+func (iotaIdx MovementPriority) RankFor(drivingSide types.DrivingSide) int {
+	if drivingSide != types.DRIVING_SIDE_LEFT {
+		return iotaIdx.Rank()
+	}
+	switch iotaIdx {
+	case MOVEMENT_PRIORITY_MAJOR_RIGHT:
+		return MOVEMENT_PRIORITY_MAJOR_LEFT.Rank()
+	case MOVEMENT_PRIORITY_MAJOR_LEFT:
+		return MOVEMENT_PRIORITY_MAJOR_RIGHT.Rank()
+	case MOVEMENT_PRIORITY_MINOR_RIGHT:
+		return MOVEMENT_PRIORITY_MINOR_LEFT.Rank()
+	case MOVEMENT_PRIORITY_MINOR_LEFT:
+		return MOVEMENT_PRIORITY_MINOR_RIGHT.Rank()
+	default:
+		return iotaIdx.Rank()
+	}
+}
+
+// NewMovementPriority returns priority for the movement
+// isMajor - whether movement starts from major approach;
+// mvmtType - type of movement.
+func NewMovementPriority(isMajor bool, mvmtType MovementType) MovementPriority {
+	if isMajor {
+		switch mvmtType {
+		case MOVEMENT_TYPE_THRU:
+			return MOVEMENT_PRIORITY_MAJOR_THRU
+		case MOVEMENT_TYPE_RIGHT:
+			return MOVEMENT_PRIORITY_MAJOR_RIGHT
+		case MOVEMENT_TYPE_LEFT:
+			return MOVEMENT_PRIORITY_MAJOR_LEFT
+		case MOVEMENT_TYPE_U_TURN:
+			return MOVEMENT_PRIORITY_MAJOR_U_TURN
+		default:
+			return MOVEMENT_PRIORITY_UNDEFINED
+		}
+	}
+	switch mvmtType {
+	case MOVEMENT_TYPE_THRU:
+		return MOVEMENT_PRIORITY_MINOR_THRU
+	case MOVEMENT_TYPE_RIGHT:
+		return MOVEMENT_PRIORITY_MINOR_RIGHT
+	case MOVEMENT_TYPE_LEFT:
+		return MOVEMENT_PRIORITY_MINOR_LEFT
+	case MOVEMENT_TYPE_U_TURN:
+		return MOVEMENT_PRIORITY_MINOR_U_TURN
+	default:
+		return MOVEMENT_PRIORITY_UNDEFINED
+	}
+}
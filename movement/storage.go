@@ -0,0 +1,97 @@
+package movement
+
+import (
+	"sort"
+	"sync"
+)
+
+// MovementsStorage holds movements of the single network and allocates identifiers for them
+type MovementsStorage struct {
+	sync.RWMutex
+	movements map[MovementID]*Movement
+	nextID    MovementID
+}
+
+// NewMovementsStorage constructs new empty storage
+func NewMovementsStorage(options ...func(*MovementsStorage)) *MovementsStorage {
+	storage := &MovementsStorage{
+		movements: make(map[MovementID]*Movement),
+		nextID:    0,
+	}
+	for _, option := range options {
+		option(storage)
+	}
+	return storage
+}
+
+// WithStartID sets identifier which will be assigned to the first added movement
+func WithStartID(startID MovementID) func(*MovementsStorage) {
+	return func(storage *MovementsStorage) {
+		storage.nextID = startID
+	}
+}
+
+// AddMovements assigns identifiers to the given movements and puts them into the storage
+// Movements are sorted by macro node, incoming link and outcoming link before identifiers assignment, so the result does not depend on the order of input
+// Notice: it is safe for concurrent use, but to get the same identifiers between runs whole set of movements should be added in single call.
+// Given slice is not modified: movements are copied into the storage
+func (storage *MovementsStorage) AddMovements(movements []Movement) {
+	mvmts := make([]Movement, len(movements))
+	copy(mvmts, movements)
+	sort.SliceStable(mvmts, func(i, j int) bool {
+		if mvmts[i].MacroNodeID != mvmts[j].MacroNodeID {
+			return mvmts[i].MacroNodeID < mvmts[j].MacroNodeID
+		}
+		if mvmts[i].IncomeMacroLinkID != mvmts[j].IncomeMacroLinkID {
+			return mvmts[i].IncomeMacroLinkID < mvmts[j].IncomeMacroLinkID
+		}
+		return mvmts[i].OutcomeMacroLinkID < mvmts[j].OutcomeMacroLinkID
+	})
+	storage.Lock()
+	defer storage.Unlock()
+	for i := range mvmts {
+		mvmt := mvmts[i]
+		mvmt.ID = storage.nextID
+		storage.nextID++
+		storage.movements[mvmt.ID] = &mvmt
+	}
+}
+
+// Get returns movement by its identifier
+func (storage *MovementsStorage) Get(id MovementID) (*Movement, bool) {
+	storage.RLock()
+	defer storage.RUnlock()
+	mvmt, ok := storage.movements[id]
+	return mvmt, ok
+}
+
+// Remove deletes movements with given identifiers from the storage. Unknown identifiers are ignored
+// Notice: identifiers of removed movements are not reused
+func (storage *MovementsStorage) Remove(ids []MovementID) {
+	storage.Lock()
+	defer storage.Unlock()
+	for _, id := range ids {
+		delete(storage.movements, id)
+	}
+}
+
+// Len returns number of movements in the storage
+func (storage *MovementsStorage) Len() int {
+	storage.RLock()
+	defer storage.RUnlock()
+	return len(storage.movements)
+}
+
+// List returns movements ordered by identifiers
+func (storage *MovementsStorage) List() []*Movement {
+	storage.RLock()
+	defer storage.RUnlock()
+	mvmts := make([]*Movement, 0, len(storage.movements))
+	for _, mvmt := range storage.movements {
+		mvmts = append(mvmts, mvmt)
+	}
+	sort.Slice(mvmts, func(i, j int) bool {
+		return mvmts[i].ID < mvmts[j].ID
+	})
+	return mvmts
+}
@@ -0,0 +1,47 @@
+package movement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovementsStorage(t *testing.T) {
+	prepare := func() []Movement {
+		return []Movement{
+			NewMovement(2, 5, 7, MOVEMENT_NBT, MOVEMENT_TYPE_THRU, nil),
+			NewMovement(1, 3, 4, MOVEMENT_EBL, MOVEMENT_TYPE_LEFT, nil),
+			NewMovement(2, 5, 6, MOVEMENT_NBR, MOVEMENT_TYPE_RIGHT, nil),
+			NewMovement(1, 2, 4, MOVEMENT_SBT, MOVEMENT_TYPE_THRU, nil),
+		}
+	}
+	expectedOrder := [][3]int{{1, 2, 4}, {1, 3, 4}, {2, 5, 6}, {2, 5, 7}}
+
+	storage := NewMovementsStorage(WithStartID(10))
+	mvmts := prepare()
+	// Input order should not matter
+	mvmts[0], mvmts[3] = mvmts[3], mvmts[0]
+	storage.AddMovements(mvmts)
+	assert.Equal(t, 4, storage.Len(), "Wrong number of movements")
+	for i, mvmt := range storage.List() {
+		assert.Equal(t, MovementID(10+i), mvmt.ID, "Wrong movement ID")
+		assert.Equal(t, expectedOrder[i], [3]int{int(mvmt.MacroNodeID), int(mvmt.IncomeMacroLinkID), int(mvmt.OutcomeMacroLinkID)}, "Wrong movement order")
+	}
+
+	// Removed identifiers are not reused
+	storage.Remove([]MovementID{11, 100})
+	assert.Equal(t, 3, storage.Len(), "Wrong number of movements after removal")
+	_, ok := storage.Get(11)
+	assert.False(t, ok, "Movement should be removed")
+	storage.AddMovements(prepare()[:1])
+	assert.Equal(t, MovementID(14), storage.List()[3].ID, "Identifiers should not be reused")
+
+	// Another storage in the same process starts from scratch
+	another := NewMovementsStorage()
+	given := prepare()
+	another.AddMovements(given)
+	assert.Equal(t, prepare(), given, "Given movements should not be reordered")
+	mvmt, ok := another.Get(0)
+	assert.True(t, ok, "Movement should exist")
+	assert.Equal(t, [3]int{1, 2, 4}, [3]int{int(mvmt.MacroNodeID), int(mvmt.IncomeMacroLinkID), int(mvmt.OutcomeMacroLinkID)}, "Wrong first movement")
+}
@@ -0,0 +1,109 @@
+package movement
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/types"
+)
+
+// TurnPenaltyProfile describes how penalty, capacity and free speed of movements are evaluated
+type TurnPenaltyProfile struct {
+	// Base penalty (seconds) for every movement type
+	TypePenalties map[MovementType]float64
+	// Additional penalty (seconds) for every control type of the node
+	ControlPenalties map[types.ControlType]float64
+	// Additional penalty (seconds) per turning degree
+	AnglePenalty float64
+	// Capacity reduction factors for every movement type
+	TypeCapacityFactors map[MovementType]float64
+	// Capacity reduction factors for every control type of the node
+	ControlCapacityFactors map[types.ControlType]float64
+	// Maximum comfortable lateral acceleration (m/s^2) which is used to estimate free speed on the turn
+	MaxLateralAcceleration float64
+	// Lower bound for free speed on the turn (km/h)
+	MinFreeSpeed float64
+}
+
+// NewTurnPenaltyProfileDefault returns default turn penalty profile
+func NewTurnPenaltyProfileDefault() *TurnPenaltyProfile {
+	return &TurnPenaltyProfile{
+		TypePenalties: map[MovementType]float64{
+			MOVEMENT_TYPE_THRU:   0,
+			MOVEMENT_TYPE_RIGHT:  2,
+			MOVEMENT_TYPE_LEFT:   5,
+			MOVEMENT_TYPE_U_TURN: 10,
+		},
+		ControlPenalties: map[types.ControlType]float64{
+			types.CONTROL_TYPE_NOT_SIGNAL:     0,
+			types.CONTROL_TYPE_IS_SIGNAL:      15,
+			types.CONTROL_TYPE_STOP:           8,
+			types.CONTROL_TYPE_ALL_WAY_STOP:   10,
+			types.CONTROL_TYPE_GIVE_WAY:       4,
+			types.CONTROL_TYPE_LEVEL_CROSSING: 5,
+		},
+		AnglePenalty: 0.02,
+		TypeCapacityFactors: map[MovementType]float64{
+			MOVEMENT_TYPE_THRU:   1.0,
+			MOVEMENT_TYPE_RIGHT:  0.85,
+			MOVEMENT_TYPE_LEFT:   0.75,
+			MOVEMENT_TYPE_U_TURN: 0.5,
+		},
+		ControlCapacityFactors: map[types.ControlType]float64{
+			types.CONTROL_TYPE_NOT_SIGNAL:     1.0,
+			types.CONTROL_TYPE_IS_SIGNAL:      0.5,
+			types.CONTROL_TYPE_STOP:           0.6,
+			types.CONTROL_TYPE_ALL_WAY_STOP:   0.5,
+			types.CONTROL_TYPE_GIVE_WAY:       0.8,
+			types.CONTROL_TYPE_LEVEL_CROSSING: 0.9,
+		},
+		MaxLateralAcceleration: 2.5,
+		MinFreeSpeed:           5.0,
+	}
+}
+
+// Penalty returns penalty (seconds) for the movement;
+// turnAngle - signed turning angle in radians (see FindTurnAngle).
+func (profile *TurnPenaltyProfile) Penalty(mvmtType MovementType, controlType types.ControlType, turnAngle float64) float64 {
+	return profile.TypePenalties[mvmtType] + profile.ControlPenalties[controlType] + profile.AnglePenalty*math.Abs(turnAngle)*180.0/math.Pi
+}
+
+// Capacity returns capacity (veh/h) for the movement;
+// laneCapacity - capacity of single lane of the incoming link (veh/h/lane).
+// Returns -1 if lane capacity is unknown.
+func (profile *TurnPenaltyProfile) Capacity(mvmtType MovementType, controlType types.ControlType, lanesNum int, laneCapacity int) int {
+	if laneCapacity < 0 {
+		return -1
+	}
+	typeFactor, ok := profile.TypeCapacityFactors[mvmtType]
+	if !ok {
+		typeFactor = 1.0
+	}
+	controlFactor, ok := profile.ControlCapacityFactors[controlType]
+	if !ok {
+		controlFactor = 1.0
+	}
+	return int(math.Round(float64(laneCapacity*max(lanesNum, 1)) * typeFactor * controlFactor))
+}
+
+// FreeSpeed returns free speed (km/h) for the movement;
+// turnRadius - radius of the turn in meters (see FindTurnRadius);
+// incomeFreeSpeed, outcomeFreeSpeed - free speeds of incoming and outcoming links (km/h). Negative values are ignored.
+// Returns -1 if free speed can't be estimated.
+func (profile *TurnPenaltyProfile) FreeSpeed(turnRadius float64, incomeFreeSpeed float64, outcomeFreeSpeed float64) float64 {
+	freeSpeed := -1.0
+	if incomeFreeSpeed >= 0 {
+		freeSpeed = incomeFreeSpeed
+	}
+	if outcomeFreeSpeed >= 0 && (freeSpeed < 0 || outcomeFreeSpeed < freeSpeed) {
+		freeSpeed = outcomeFreeSpeed
+	}
+	if math.IsInf(turnRadius, 1) || profile.MaxLateralAcceleration <= 0 {
+		return freeSpeed
+	}
+	// v = sqrt(a * R), m/s to km/h
+	turnSpeed := math.Max(math.Sqrt(profile.MaxLateralAcceleration*turnRadius)*3.6, profile.MinFreeSpeed)
+	if freeSpeed < 0 || turnSpeed < freeSpeed {
+		return turnSpeed
+	}
+	return freeSpeed
+}
@@ -0,0 +1,28 @@
+package movement
+
+import (
+	"math"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTurnPenaltyProfile(t *testing.T) {
+	profile := NewTurnPenaltyProfileDefault()
+
+	// Straight movement on uncontrolled node has no penalty
+	assert.Equal(t, 0.0, profile.Penalty(MOVEMENT_TYPE_THRU, types.CONTROL_TYPE_NOT_SIGNAL, 0), "Wrong penalty for through movement")
+	// Left turn on signalized node: 5 + 15 + 0.02 * 90
+	assert.InDelta(t, 21.8, profile.Penalty(MOVEMENT_TYPE_LEFT, types.CONTROL_TYPE_IS_SIGNAL, math.Pi/2), 1e-9, "Wrong penalty for left turn")
+
+	assert.Equal(t, 3600, profile.Capacity(MOVEMENT_TYPE_THRU, types.CONTROL_TYPE_NOT_SIGNAL, 2, 1800), "Wrong capacity for through movement")
+	assert.Equal(t, 540, profile.Capacity(MOVEMENT_TYPE_LEFT, types.CONTROL_TYPE_STOP, 1, 1200), "Wrong capacity for left turn")
+	assert.Equal(t, -1, profile.Capacity(MOVEMENT_TYPE_LEFT, types.CONTROL_TYPE_STOP, 1, -1), "Capacity should be unknown")
+
+	// Straight movement keeps the lowest speed of links
+	assert.Equal(t, 40.0, profile.FreeSpeed(math.Inf(1), 60, 40), "Wrong free speed for through movement")
+	// Tight turn: sqrt(2.5 * 10) * 3.6 = 18 km/h
+	assert.InDelta(t, 18.0, profile.FreeSpeed(10, 60, 40), 1e-9, "Wrong free speed for turn")
+	assert.Equal(t, profile.MinFreeSpeed, profile.FreeSpeed(0.1, 60, 40), "Free speed should not be less than minimum")
+}
@@ -1,10 +1,14 @@
 package movement
 
+import (
+	"github.com/LdDl/osm2gmns/types"
+)
+
 var (
 	movementsTypes       = []string{"undefined", "thru", "right", "left", "uturn"}
 	movementsShortTypes  = []string{"undefined", "T", "R", "L", "U"}
-	directionTypes       = []string{"undefined", "SB", "EB", "NB", "WB"}
-	movementsTextIDs     = []string{"undefined", "SBT", "SBR", "SBL", "SBU", "EBT", "EBR", "EBL", "EBU", "NBT", "NBR", "NBL", "NBU", "WBT", "WBR", "WBL", "WBU"}
+	directionTypes       = []string{"undefined", "SB", "EB", "NB", "WB", "NEB", "NWB", "SEB", "SWB"}
+	movementsTextIDs     = []string{"undefined", "SBT", "SBR", "SBL", "SBU", "EBT", "EBR", "EBL", "EBU", "NBT", "NBR", "NBL", "NBU", "WBT", "WBR", "WBL", "WBU", "NEBT", "NEBR", "NEBL", "NEBU", "NWBT", "NWBR", "NWBL", "NWBU", "SEBT", "SEBR", "SEBL", "SEBU", "SWBT", "SWBR", "SWBL", "SWBU"}
 	movementTextIDsMatch = map[string]MovementCompositeType{
 		"SBT": MOVEMENT_SBT,
 		"SBR": MOVEMENT_SBR,
@@ -22,6 +26,42 @@ var (
 		"WBR": MOVEMENT_WBR,
 		"WBL": MOVEMENT_WBL,
 		"WBU": MOVEMENT_WBU,
+		// Diagonal bounds are used only when classifier is configured for 8 directions
+		"NEBT": MOVEMENT_NEBT,
+		"NEBR": MOVEMENT_NEBR,
+		"NEBL": MOVEMENT_NEBL,
+		"NEBU": MOVEMENT_NEBU,
+		"NWBT": MOVEMENT_NWBT,
+		"NWBR": MOVEMENT_NWBR,
+		"NWBL": MOVEMENT_NWBL,
+		"NWBU": MOVEMENT_NWBU,
+		"SEBT": MOVEMENT_SEBT,
+		"SEBR": MOVEMENT_SEBR,
+		"SEBL": MOVEMENT_SEBL,
+		"SEBU": MOVEMENT_SEBU,
+		"SWBT": MOVEMENT_SWBT,
+		"SWBR": MOVEMENT_SWBR,
+		"SWBL": MOVEMENT_SWBL,
+		"SWBU": MOVEMENT_SWBU,
+	}
+
+	movementsTextIDsDirections = []DirectionType{
+		DIRECTION_TYPE_UNDEFINED,
+		DIRECTION_TYPE_SB, DIRECTION_TYPE_SB, DIRECTION_TYPE_SB, DIRECTION_TYPE_SB,
+		DIRECTION_TYPE_EB, DIRECTION_TYPE_EB, DIRECTION_TYPE_EB, DIRECTION_TYPE_EB,
+		DIRECTION_TYPE_NB, DIRECTION_TYPE_NB, DIRECTION_TYPE_NB, DIRECTION_TYPE_NB,
+		DIRECTION_TYPE_WB, DIRECTION_TYPE_WB, DIRECTION_TYPE_WB, DIRECTION_TYPE_WB,
+		DIRECTION_TYPE_NEB, DIRECTION_TYPE_NEB, DIRECTION_TYPE_NEB, DIRECTION_TYPE_NEB,
+		DIRECTION_TYPE_NWB, DIRECTION_TYPE_NWB, DIRECTION_TYPE_NWB, DIRECTION_TYPE_NWB,
+		DIRECTION_TYPE_SEB, DIRECTION_TYPE_SEB, DIRECTION_TYPE_SEB, DIRECTION_TYPE_SEB,
+		DIRECTION_TYPE_SWB, DIRECTION_TYPE_SWB, DIRECTION_TYPE_SWB, DIRECTION_TYPE_SWB,
+	}
+
+	// Diagonal bounds are folded counter-clockwise into main ones
+	directionsCardinal = []DirectionType{
+		DIRECTION_TYPE_UNDEFINED,
+		DIRECTION_TYPE_SB, DIRECTION_TYPE_EB, DIRECTION_TYPE_NB, DIRECTION_TYPE_WB,
+		DIRECTION_TYPE_NB, DIRECTION_TYPE_WB, DIRECTION_TYPE_EB, DIRECTION_TYPE_SB,
 	}
 )
 
@@ -39,6 +79,22 @@ func (iotaIdx MovementType) String() string {
 	return movementsTypes[iotaIdx]
 }
 
+// RightHandEquivalent returns type of movement which plays the same role for right-hand traffic:
+// left and right turns are swapped for left-hand traffic, so near side turn is always RIGHT and far side turn is always LEFT
+func (iotaIdx MovementType) RightHandEquivalent(drivingSide types.DrivingSide) MovementType {
+	if drivingSide != types.DRIVING_SIDE_LEFT {
+		return iotaIdx
+	}
+	switch iotaIdx {
+	case MOVEMENT_TYPE_RIGHT:
+		return MOVEMENT_TYPE_LEFT
+	case MOVEMENT_TYPE_LEFT:
+		return MOVEMENT_TYPE_RIGHT
+	default:
+		return iotaIdx
+	}
+}
+
 type MovementShortType uint16
 
 const (
@@ -61,12 +117,21 @@ const (
 	DIRECTION_TYPE_EB
 	DIRECTION_TYPE_NB
 	DIRECTION_TYPE_WB
+	DIRECTION_TYPE_NEB
+	DIRECTION_TYPE_NWB
+	DIRECTION_TYPE_SEB
+	DIRECTION_TYPE_SWB
 )
 
 func (iotaIdx DirectionType) String() string {
 	return directionTypes[iotaIdx]
 }
 
+// Cardinal returns one of main directions (NB, SB, EB, WB) for the given one
+func (iotaIdx DirectionType) Cardinal() DirectionType {
+	return directionsCardinal[iotaIdx]
+}
+
 type MovementCompositeType uint16
 
 const (
@@ -87,8 +152,29 @@ const (
 	MOVEMENT_WBR
 	MOVEMENT_WBL
 	MOVEMENT_WBU
+	MOVEMENT_NEBT
+	MOVEMENT_NEBR
+	MOVEMENT_NEBL
+	MOVEMENT_NEBU
+	MOVEMENT_NWBT
+	MOVEMENT_NWBR
+	MOVEMENT_NWBL
+	MOVEMENT_NWBU
+	MOVEMENT_SEBT
+	MOVEMENT_SEBR
+	MOVEMENT_SEBL
+	MOVEMENT_SEBU
+	MOVEMENT_SWBT
+	MOVEMENT_SWBR
+	MOVEMENT_SWBL
+	MOVEMENT_SWBU
 )
 
 func (iotaIdx MovementCompositeType) String() string {
 	return movementsTextIDs[iotaIdx]
 }
+
+// Direction returns direction (bound) of the movement's approach
+func (iotaIdx MovementCompositeType) Direction() DirectionType {
+	return movementsTextIDsDirections[iotaIdx]
+}
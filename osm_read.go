@@ -135,6 +135,16 @@ func (parser *Parser) ReadOSM() (*OSMWaysNodes, error) {
 		ways:              ways,
 		nodes:             nodes,
 		allowedAgentTypes: make([]types.AgentType, len(parser.allowedAgentTypes)),
+		barrierMode:       parser.barrierMode,
+
+		pureCycleMode:    parser.pureCycleMode,
+		pureCycleAnchors: parser.pureCycleAnchors,
+		pureCycleParts:   parser.pureCycleParts,
+
+		signalClusterDistance: parser.signalClusterDistance,
+		consolidation:         parser.consolidation,
+
+		drivingSide: parser.drivingSide,
 	}
 	copy(osmData.allowedAgentTypes, parser.allowedAgentTypes)
 
@@ -1,6 +1,8 @@
 package osm2gmns
 
 import (
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/poi"
 	"github.com/LdDl/osm2gmns/types"
 	"github.com/LdDl/osm2gmns/wrappers"
 	"github.com/paulmach/osm"
@@ -18,4 +20,18 @@ type OSMWaysNodes struct {
 	ways  []*wrappers.WayOSM
 
 	allowedAgentTypes []types.AgentType
+	barrierMode       BarrierMode
+
+	pureCycleMode    PureCycleMode
+	pureCycleAnchors PureCycleAnchors
+	pureCycleParts   int
+	// Pure cycles which have been met during macroscopic network generation
+	pureCycles []PureCycle
+	// Points of interest which have been prepared during macroscopic network generation
+	pois poi.POIs
+
+	signalClusterDistance float64
+	consolidation         []func(*macro.ConsolidationConfig)
+
+	drivingSide types.DrivingSide
 }
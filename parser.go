@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/LdDl/osm2gmns/macro"
 	"github.com/LdDl/osm2gmns/types"
 )
 
@@ -23,6 +24,17 @@ type Parser struct {
 	startNodeID       int
 	startLinkID       int
 	allowedAgentTypes []types.AgentType
+	barrierMode       BarrierMode
+	pureCycleMode     PureCycleMode
+	pureCycleAnchors  PureCycleAnchors
+	// Number of parts for PURE_CYCLE_ANCHORS_EQUAL_LENGTH
+	pureCycleParts int
+	// Max distance (in meters) between traffic signal node and the downstream intersection to attribute the signal to the intersection
+	signalClusterDistance float64
+	// Options of intersections consolidation. Nil value disables consolidation
+	consolidation []func(*macro.ConsolidationConfig)
+
+	drivingSide types.DrivingSide
 }
 
 func NewParser(fileName string, options ...func(*Parser)) *Parser {
@@ -32,6 +44,8 @@ func NewParser(fileName string, options ...func(*Parser)) *Parser {
 		strictMode:  false,
 		startNodeID: 0,
 		startLinkID: 0,
+
+		pureCycleParts: 2,
 	}
 	for _, option := range options {
 		option(parser)
@@ -133,6 +147,59 @@ func WithAllowedAgentTypes(allowedAgentTypes []types.AgentType) func(*Parser) {
 	}
 }
 
+// WithBarrierMode sets the way barrier nodes are handled. Default is BARRIER_MODE_RESTRICT_LINK
+func WithBarrierMode(barrierMode BarrierMode) func(*Parser) {
+	return func(parser *Parser) {
+		parser.barrierMode = barrierMode
+	}
+}
+
+// WithPureCycleMode sets the way closed ways which touch no crossing nodes (pure cycles) are handled. Default is PURE_CYCLE_MODE_KEEP
+func WithPureCycleMode(pureCycleMode PureCycleMode) func(*Parser) {
+	return func(parser *Parser) {
+		parser.pureCycleMode = pureCycleMode
+	}
+}
+
+// WithPureCycleAnchors sets the way anchor nodes for splitting closed ways are picked. Default is PURE_CYCLE_ANCHORS_FARTHEST.
+// Number of parts is used for PURE_CYCLE_ANCHORS_EQUAL_LENGTH only (2 at least)
+func WithPureCycleAnchors(pureCycleAnchors PureCycleAnchors, parts int) func(*Parser) {
+	return func(parser *Parser) {
+		parser.pureCycleAnchors = pureCycleAnchors
+		parser.pureCycleParts = max(parts, 2)
+	}
+}
+
+// WithSignalClusterDistance sets max distance (in meters) to attribute traffic signals placed near the junction to the junction node itself.
+// Zero value (default) disables such attribution
+func WithSignalClusterDistance(signalClusterDistance float64) func(*Parser) {
+	return func(parser *Parser) {
+		parser.signalClusterDistance = signalClusterDistance
+	}
+}
+
+// WithIntersectionsConsolidation enables consolidation of intersections (see macro.Net.ConsolidateIntersections) with given options.
+// Consolidation is done after traffic signals attribution, so signalized-only consolidation takes attributed signals into account
+func WithIntersectionsConsolidation(options ...func(*macro.ConsolidationConfig)) func(*Parser) {
+	return func(parser *Parser) {
+		parser.consolidation = append(make([]func(*macro.ConsolidationConfig), 0, len(options)), options...)
+	}
+}
+
+// WithDrivingSide sets driving side of the network. Right-hand traffic is used by default
+func WithDrivingSide(drivingSide types.DrivingSide) func(*Parser) {
+	return func(parser *Parser) {
+		parser.drivingSide = drivingSide
+	}
+}
+
+// WithCountry sets driving side of the network by the country code (ISO 3166-1 alpha-2, e.g. "GB", "JP", "AU")
+func WithCountry(countryCode string) func(*Parser) {
+	return func(parser *Parser) {
+		parser.drivingSide = types.DrivingSideByCountry(countryCode)
+	}
+}
+
 func (parser *Parser) String() string {
 	return fmt.Sprintf(`
 Network parser parameters:
@@ -150,6 +217,13 @@ Network parser parameters:
 	default_capacity: %v
 	start_node_id: %d
 	start_link_id: %d
+	barrier_mode: %s
+	pure_cycle_mode: %s
+	pure_cycle_anchors: %s
+	pure_cycle_parts: %d
+	signal_cluster_distance: %f
+	intersections_consolidation enabled?: %t
+	driving_side: %s
 	global verbose?: %t
 	`,
 		parser.filename,
@@ -166,6 +240,13 @@ Network parser parameters:
 		parser.defaultCapacity,
 		parser.startNodeID,
 		parser.startLinkID,
+		parser.barrierMode,
+		parser.pureCycleMode,
+		parser.pureCycleAnchors,
+		parser.pureCycleParts,
+		parser.signalClusterDistance,
+		parser.consolidation != nil,
+		parser.drivingSide,
 		VERBOSE,
 	)
 }
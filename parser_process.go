@@ -13,34 +13,65 @@ import (
 
 func (osmData *OSMWaysNodes) GenerateMacroscopic(poi bool) (*macro.Net, error) {
 	ways, nodesSet, allowedAgentTypes := osmData.ways, osmData.nodes, osmData.allowedAgentTypes
-	preparedWays, err := prepareWays(ways, nodesSet, allowedAgentTypes)
+	preparedWays, err := prepareWays(ways, nodesSet, allowedAgentTypes, osmData.pureCycleMode)
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't prepare ways")
 	}
-	preparedNodes, err := prepareNodes(nodesSet)
+	preparedNodes, err := prepareNodes(nodesSet, osmData.barrierMode)
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't prepare nodes")
 	}
-	err = markPureCycles(preparedNodes, preparedWays)
+	osmData.pureCycles, err = markPureCycles(preparedNodes, preparedWays, pureCyclesConfig{
+		mode:    osmData.pureCycleMode,
+		anchors: osmData.pureCycleAnchors,
+		parts:   osmData.pureCycleParts,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't mark pure cycles")
 	}
+	if poi {
+		osmData.pois = preparePOIs(ways, nodesSet)
+	}
 	if VERBOSE {
 		log.Info().Str("scope", "gen_macro").Msg("Preparing macroscopic network")
 	}
 	st := time.Now()
-	macroNet, err := macro.NewNetFromOSM(preparedWays, preparedNodes)
+	macroNet, err := macro.NewNetFromOSM(preparedWays, preparedNodes, macro.WithDrivingSide(osmData.drivingSide))
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't prepare macroscopic network")
 	}
 	if VERBOSE {
 		log.Info().Str("scope", "gen_macro").Int("macro_nodes_num", len(macroNet.Nodes)).Int("macro_links_num", len(macroNet.Links)).Float64("elapsed", time.Since(st).Seconds()).Msg("Preparing macroscopic network done!")
 	}
+	if osmData.signalClusterDistance > 0 {
+		if VERBOSE {
+			log.Info().Str("scope", "cluster_signals").Float64("max_distance", osmData.signalClusterDistance).Msg("Attributing traffic signals to intersections")
+		}
+		st = time.Now()
+		signalizedNum := macroNet.ClusterSignals(osmData.signalClusterDistance)
+		if VERBOSE {
+			log.Info().Str("scope", "cluster_signals").Int("signalized_intersections_num", signalizedNum).Float64("elapsed", time.Since(st).Seconds()).Msg("Attributing traffic signals to intersections done!")
+		}
+	}
+	if osmData.consolidation != nil {
+		if VERBOSE {
+			log.Info().Str("scope", "consolidate_intersections").Msg("Consolidating intersections")
+		}
+		st = time.Now()
+		clustersNum, err := macroNet.ConsolidateIntersections(osmData.consolidation...)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't consolidate intersections")
+		}
+		if VERBOSE {
+			log.Info().Str("scope", "consolidate_intersections").Int("clusters_num", clustersNum).Float64("elapsed", time.Since(st).Seconds()).Msg("Consolidating intersections done!")
+		}
+	}
 	return macroNet, nil
 }
 
 // prepareNodes examines nodes which has use count > 0 and use count > 2 on being cross
-func prepareNodes(nodesSet map[osm.NodeID]*wrappers.NodeOSM) (map[osm.NodeID]*wrappers.NodeOSM, error) {
+// When barrierMode is BARRIER_MODE_SPLIT_LINK then barriers which block any agent type are considered as crosses too
+func prepareNodes(nodesSet map[osm.NodeID]*wrappers.NodeOSM, barrierMode BarrierMode) (map[osm.NodeID]*wrappers.NodeOSM, error) {
 	if VERBOSE {
 		log.Info().Str("scope", "prepare_nodes").Int("nodes_num", len(nodesSet)).Msg("Preparing nodes")
 	}
@@ -50,6 +81,11 @@ func prepareNodes(nodesSet map[osm.NodeID]*wrappers.NodeOSM) (map[osm.NodeID]*wr
 		if node.UseCount >= 2 || node.ControlType == types.CONTROL_TYPE_IS_SIGNAL {
 			node.IsCrossing = true
 		}
+		if barrierMode == BARRIER_MODE_SPLIT_LINK && node.UseCount > 0 && !node.IsCrossing && len(node.BarrierBlockedAgentTypes) > 0 {
+			node.IsCrossing = true
+			// Keep track of such splits for further QA
+			node.IsBarrierSplit = true
+		}
 	}
 	preparedNodes := make(map[osm.NodeID]*wrappers.NodeOSM)
 	// Filter nodes that are not used at all (building, parks and etc.)
@@ -66,7 +102,7 @@ func prepareNodes(nodesSet map[osm.NodeID]*wrappers.NodeOSM) (map[osm.NodeID]*wr
 }
 
 // prepareWays prepares ways: link type, link class, link connection type, allowed agent types. Also mutates nodes data: increments use count (when being used in ways)
-func prepareWays(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM, allowedAgentTypes []types.AgentType) ([]*wrappers.WayOSM, error) {
+func prepareWays(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM, allowedAgentTypes []types.AgentType, pureCycleMode PureCycleMode) ([]*wrappers.WayOSM, error) {
 	if VERBOSE {
 		log.Info().Str("scope", "prepare_ways").Int("ways_num", len(ways)).Msg("Preparing ways")
 	}
@@ -120,7 +156,13 @@ func prepareWays(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.Node
 				way.AllowedAgentTypes = append(way.AllowedAgentTypes, agentType)
 			}
 			// Increment nodes uses
-			for _, nodeID := range way.Nodes {
+			wayNodes := way.Nodes
+			splitAtClosingNode := !way.IsCycle || pureCycleMode.closingNodeIsCrossing(way)
+			if !splitAtClosingNode {
+				// Closing node of the ring is not a junction by itself: ring should be splitted at crossings (e.g. roundabout's entries and exits) only
+				wayNodes = way.Nodes[:len(way.Nodes)-1]
+			}
+			for _, nodeID := range wayNodes {
 				existingNode, ok := nodesSet[nodeID]
 				if !ok {
 					log.Warn().Str("scope", "prepare_ways").Any("osm_way_id", way.ID).Int("node_id", int(nodeID)).Msg("Can't find way node in nodes set")
@@ -129,8 +171,10 @@ func prepareWays(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.Node
 				existingNode.UseCount++
 			}
 			// Mark first and last node as used in cross
-			nodesSet[way.Nodes[0]].IsCrossing = true
-			nodesSet[way.Nodes[len(way.Nodes)-1]].IsCrossing = true
+			if splitAtClosingNode {
+				nodesSet[way.Nodes[0]].IsCrossing = true
+				nodesSet[way.Nodes[len(way.Nodes)-1]].IsCrossing = true
+			}
 			// Append processed way to the filtered list
 			preparedWays = append(preparedWays, way)
 		case wrappers.WAY_TYPE_RAILWAY:
@@ -3,7 +3,12 @@ package osm2gmns
 import (
 	"testing"
 
+	"github.com/LdDl/osm2gmns/demand"
+	"github.com/LdDl/osm2gmns/meso"
+	"github.com/LdDl/osm2gmns/micro"
+	"github.com/LdDl/osm2gmns/signal"
 	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/zone"
 )
 
 func TestParser(t *testing.T) {
@@ -27,6 +32,12 @@ func TestParser(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	zones := zone.NewGrid(macroNet.Bound(), 1000)
+	_, err = macroNet.AttachZones(zones)
+	if err != nil {
+		t.Error(err)
+		return
+	}
 	movements, err := macroNet.GenerateMovements()
 	if err != nil {
 		t.Error(err)
@@ -35,7 +46,27 @@ func TestParser(t *testing.T) {
 	_ = movements
 
 	macroNet.ExportToCSV("test_data/test.csv")
+	osmData.ExportPureCyclesToCSV("test_data/test_pure_cycles.csv")
+	zones.ExportToCSV("test_data/test.csv")
+	osmData.POIs().ExportToCSV("test_data/test.csv")
+	trips := demand.GenerateTrips(osmData.POIs(), zones, nil)
+	demand.Gravity(trips, demand.NetworkDistance(macroNet, zones)).ExportToCSV("test_data/test.csv")
 	movements.ExportToCSV("test_data/test_movement.csv")
-	// @todo
-	t.Error("start mesoscopic")
+
+	signalControllers := signal.NewGenerator().Generate(movements)
+	signalControllers.ExportToCSV("test_data/test.csv")
+
+	mesoNet, err := meso.NewNetFromMacroscopic(macroNet, movements)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	mesoNet.ExportToCSV("test_data/test.csv")
+
+	microNet, err := micro.NewNetFromMesoscopic(mesoNet, movements)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	microNet.ExportToCSV("test_data/test.csv")
 }
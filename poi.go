@@ -1,5 +1,15 @@
 package osm2gmns
 
+import (
+	"time"
+
+	"github.com/LdDl/osm2gmns/poi"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/rs/zerolog/log"
+)
+
 type POIType uint16
 
 const (
@@ -8,3 +18,43 @@ const (
 	POI_TYPE_RAILWAY
 	POI_TYPE_AEROWAY
 )
+
+// preparePOIs prepares points of interest from closed ways with `building`, `amenity` or `leisure` tags
+func preparePOIs(ways []*wrappers.WayOSM, nodesSet map[osm.NodeID]*wrappers.NodeOSM) poi.POIs {
+	if VERBOSE {
+		log.Info().Str("scope", "prepare_poi").Int("ways_num", len(ways)).Msg("Preparing POIs")
+	}
+	st := time.Now()
+	pois := poi.POIs{}
+	for _, way := range ways {
+		if !way.Tags.IsPOI() {
+			continue
+		}
+		if len(way.Nodes) < 4 || way.Nodes[0] != way.Nodes[len(way.Nodes)-1] {
+			// Only polygons could be POIs
+			continue
+		}
+		ring := make(orb.Ring, 0, len(way.Nodes))
+		for _, nodeID := range way.Nodes {
+			node, ok := nodesSet[nodeID]
+			if !ok {
+				break
+			}
+			ring = append(ring, node.InnerNode.Point())
+		}
+		if len(ring) != len(way.Nodes) {
+			log.Warn().Str("scope", "prepare_poi").Any("osm_way_id", way.ID).Msg("Can't find some of POI nodes in nodes set")
+			continue
+		}
+		pois = append(pois, poi.NewPOI(len(pois), way.ID, orb.Polygon{ring}, poi.WithName(way.Tags.Name), poi.WithTags(way.Tags.Building(), way.Tags.Amenity(), way.Tags.Leisure())))
+	}
+	if VERBOSE {
+		log.Info().Str("scope", "prepare_poi").Int("pois_num", len(pois)).Float64("elapsed", time.Since(st).Seconds()).Msg("Preparing POIs done!")
+	}
+	return pois
+}
+
+// POIs returns points of interest which have been prepared during macroscopic network generation (see WithPreparePOI)
+func (osmData *OSMWaysNodes) POIs() poi.POIs {
+	return osmData.pois
+}
@@ -0,0 +1,50 @@
+package poi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/pkg/errors"
+)
+
+// ExportToCSV writes POIs (GMNS poi.csv) to the file with `_poi.csv` suffix
+func (pois POIs) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnamePOIs := fmt.Sprintf(fnameParts[0] + "_poi.csv")
+
+	file, err := os.Create(fnamePOIs)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"poi_id", "osm_way_id", "name", "building", "amenity", "leisure", "area", "longitude", "latitude", "geom"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, poi := range pois {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", poi.ID),
+			fmt.Sprintf("%d", poi.osmWayID),
+			poi.name,
+			poi.building,
+			poi.amenity,
+			poi.leisure,
+			fmt.Sprintf("%f", poi.areaSqMeters),
+			fmt.Sprintf("%f", poi.centroid[0]),
+			fmt.Sprintf("%f", poi.centroid[1]),
+			wkt.MarshalString(poi.geom),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write POI")
+		}
+	}
+	return nil
+}
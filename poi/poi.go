@@ -0,0 +1,94 @@
+package poi
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+)
+
+// POI is the point of interest (building, amenity or leisure area)
+type POI struct {
+	name         string
+	building     string
+	amenity      string
+	leisure      string
+	areaSqMeters float64
+	geom         orb.Polygon
+	centroid     orb.Point
+	ID           int
+	osmWayID     osm.WayID
+}
+
+// POIs is the set of points of interest
+type POIs []*POI
+
+// NewPOI constructs new point of interest for given polygon (EPSG:4326). It prepares area and centroid automatically
+func NewPOI(id int, osmWayID osm.WayID, geom orb.Polygon, options ...func(*POI)) *POI {
+	poi := &POI{
+		ID:           id,
+		osmWayID:     osmWayID,
+		geom:         geom,
+		areaSqMeters: geo.Area(geom),
+	}
+	poi.centroid, _ = planar.CentroidArea(geom)
+	for _, option := range options {
+		option(poi)
+	}
+	return poi
+}
+
+// WithName sets name of the POI
+func WithName(name string) func(*POI) {
+	return func(poi *POI) {
+		poi.name = name
+	}
+}
+
+// WithTags sets values of `building`, `amenity` and `leisure` tags
+func WithTags(building, amenity, leisure string) func(*POI) {
+	return func(poi *POI) {
+		poi.building = building
+		poi.amenity = amenity
+		poi.leisure = leisure
+	}
+}
+
+// Category returns the most specific tag value of the POI: `amenity` and `leisure` tags are preferred over generic `building=yes`
+func (poi *POI) Category() string {
+	if poi.building != "" && poi.building != "yes" {
+		return poi.building
+	}
+	if poi.amenity != "" {
+		return poi.amenity
+	}
+	if poi.leisure != "" {
+		return poi.leisure
+	}
+	return poi.building
+}
+
+// GetName returns name of the POI
+func (poi *POI) GetName() string {
+	return poi.name
+}
+
+// GetOSMWayID returns identifier of the source OSM way
+func (poi *POI) GetOSMWayID() osm.WayID {
+	return poi.osmWayID
+}
+
+// GetAreaSqMeters returns area (square meters) of the POI
+func (poi *POI) GetAreaSqMeters() float64 {
+	return poi.areaSqMeters
+}
+
+// GetGeom returns geometry (EPSG:4326) of the POI
+func (poi *POI) GetGeom() orb.Polygon {
+	return poi.geom
+}
+
+// GetCentroid returns centroid (EPSG:4326) of the POI
+func (poi *POI) GetCentroid() orb.Point {
+	return poi.centroid
+}
@@ -1,46 +1,284 @@
 package osm2gmns
 
 import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
 	"time"
 
+	"github.com/LdDl/osm2gmns/types"
 	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geo"
 	"github.com/paulmach/osm"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
+// PureCycleMode defines how closed ways which touch no crossing nodes (pure cycles) are handled
+type PureCycleMode uint16
+
+const (
+	// Closing node of every closed way is a crossing, so pure cycle becomes the self-loop link
+	PURE_CYCLE_MODE_KEEP = PureCycleMode(iota)
+	// Pure cycles are not the part of macroscopic network
+	PURE_CYCLE_MODE_DROP
+	// Pure cycles are splitted into several links at anchor nodes
+	PURE_CYCLE_MODE_SPLIT
+	// Network is the same as for PURE_CYCLE_MODE_KEEP, but pure cycles are collected for QA (see PureCycles and ExportPureCyclesToCSV)
+	PURE_CYCLE_MODE_REPORT
+)
+
+func (iotaIdx PureCycleMode) String() string {
+	return [...]string{"keep", "drop", "split", "report"}[iotaIdx]
+}
+
+// closingNodeIsCrossing checks if the first (and the last) node of closed way is considered as crossing for given mode.
+// Roundabouts are splitted at entries and exits only regardless of the mode
+func (iotaIdx PureCycleMode) closingNodeIsCrossing(way *wrappers.WayOSM) bool {
+	return !way.IsRoundabout && (iotaIdx == PURE_CYCLE_MODE_KEEP || iotaIdx == PURE_CYCLE_MODE_REPORT)
+}
+
+// PureCycleAnchors defines how anchor nodes (where closed way is splitted) are picked
+type PureCycleAnchors uint16
+
+const (
+	// Two nodes which are the farthest from each other
+	PURE_CYCLE_ANCHORS_FARTHEST = PureCycleAnchors(iota)
+	// Nodes which split the ring into parts of (nearly) equal length
+	PURE_CYCLE_ANCHORS_EQUAL_LENGTH
+)
+
+func (iotaIdx PureCycleAnchors) String() string {
+	return [...]string{"farthest", "equal_length"}[iotaIdx]
+}
+
+// PureCycle is the closed way which touches no crossing nodes
+type PureCycle struct {
+	OSMWayID     osm.WayID
+	Name         string
+	Highway      string
+	NodesNum     int
+	LengthMeters float64
+	// Cycle has been splitted at anchor nodes and kept in the network
+	IsSplit bool
+	// Cycle is not the part of the network
+	IsDropped bool
+	Geom      orb.LineString
+}
+
+// pureCyclesConfig is the set of parameters for pure cycles handling
+type pureCyclesConfig struct {
+	mode    PureCycleMode
+	anchors PureCycleAnchors
+	// Number of parts for PURE_CYCLE_ANCHORS_EQUAL_LENGTH
+	parts int
+}
+
 // markPureCycles marks pure cycles for given set of ways and reference info about nodes
-func markPureCycles(nodesSet map[osm.NodeID]*wrappers.NodeOSM, ways []*wrappers.WayOSM) error {
+// For PURE_CYCLE_MODE_KEEP and PURE_CYCLE_MODE_REPORT closed ways are splitted at their closing nodes already (see prepareWays). Pure cycles are collected for PURE_CYCLE_MODE_REPORT only.
+// Otherwise closed ways which touch the single crossing only (e.g. turning loops) are splitted at anchor nodes always to prevent self-loop links.
+// Closed roundabouts are splitted at anchor nodes for any mode. Returns the list of pure cycles met
+func markPureCycles(nodesSet map[osm.NodeID]*wrappers.NodeOSM, ways []*wrappers.WayOSM, cfg pureCyclesConfig) ([]PureCycle, error) {
 	if VERBOSE {
-		log.Info().Str("scope", "ispect_pure_cycles").Int("nodes_num", len(nodesSet)).Int("ways_num", len(ways)).Msg("Marking pure cycles")
+		log.Info().Str("scope", "ispect_pure_cycles").Int("nodes_num", len(nodesSet)).Int("ways_num", len(ways)).Str("mode", cfg.mode.String()).Str("anchors", cfg.anchors.String()).Msg("Marking pure cycles")
 	}
 	st := time.Now()
 	cyclesNum := 0
-	pureCyclesNum := 0
+	loopsNum := 0
+	pureCycles := []PureCycle{}
 	for i := range ways {
 		way := ways[i]
-		// Find and mark pure cycles
-		if way.IsCycle {
-			cyclesNum++
-			// Assume that way has pure cycle
+		if !way.IsCycle {
+			continue
+		}
+		cyclesNum++
+		// Closing node duplicates the first one
+		ringNodes := make([]*wrappers.NodeOSM, 0, len(way.Nodes)-1)
+		crossings := []int{}
+		for idx, nodeID := range way.Nodes[:len(way.Nodes)-1] {
+			existingNode, ok := nodesSet[nodeID]
+			if !ok {
+				log.Warn().Str("scope", "ispect_pure_cycles").Any("osm_way_id", way.ID).Int("node_id", int(nodeID)).Msg("Can't find way node in nodes set")
+				return pureCycles, nil
+			}
+			ringNodes = append(ringNodes, existingNode)
+			if existingNode.IsCrossing {
+				crossings = append(crossings, idx)
+			}
+		}
+		way.IsPureCycle = false
+		if cfg.mode.closingNodeIsCrossing(way) {
+			if cfg.mode == PURE_CYCLE_MODE_REPORT && isIsolatedRing(ringNodes, crossings) {
+				pureCycles = append(pureCycles, newPureCycle(way, ringNodes))
+			}
+			continue
+		}
+		if len(crossings) >= 2 {
+			continue
+		}
+		if len(crossings) == 1 {
+			// Loop which is attached to the network at the single node
+			markAnchors(ringNodes, crossings[0], cfg)
+			loopsNum++
+			continue
+		}
+		pureCycle := newPureCycle(way, ringNodes)
+		if (way.IsRoundabout || cfg.mode == PURE_CYCLE_MODE_SPLIT) && markAnchors(ringNodes, -1, cfg) {
+			pureCycle.IsSplit = true
+		} else {
 			way.IsPureCycle = true
-			for _, nodeID := range way.Nodes {
-				existingNode, ok := nodesSet[nodeID]
-				if !ok {
-					log.Warn().Str("scope", "ispect_pure_cycles").Any("osm_way_id", way.ID).Int("node_id", int(nodeID)).Msg("Can't find way node in nodes set")
-					return nil
+			pureCycle.IsDropped = true
+		}
+		pureCycles = append(pureCycles, pureCycle)
+	}
+	if VERBOSE {
+		log.Info().Str("scope", "ispect_pure_cycles").Int("cycles_num", cyclesNum).Int("loops_num", loopsNum).Int("pure_cycles_num", len(pureCycles)).Float64("elapsed", time.Since(st).Seconds()).Msg("Marking pure cycles done!")
+	}
+	return pureCycles, nil
+}
+
+// isIsolatedRing checks if the ring which closing node is a crossing (see prepareWays) touches no other ways.
+// The closing node is used twice by the ring itself, so any other use makes it the real crossing
+func isIsolatedRing(ringNodes []*wrappers.NodeOSM, crossings []int) bool {
+	if len(crossings) != 1 || crossings[0] != 0 {
+		return false
+	}
+	closingNode := ringNodes[0]
+	return closingNode.UseCount == 2 && closingNode.ControlType != types.CONTROL_TYPE_IS_SIGNAL
+}
+
+func newPureCycle(way *wrappers.WayOSM, ringNodes []*wrappers.NodeOSM) PureCycle {
+	geom := make(orb.LineString, 0, len(ringNodes)+1)
+	for _, node := range ringNodes {
+		geom = append(geom, node.InnerNode.Point())
+	}
+	if len(ringNodes) > 0 {
+		geom = append(geom, ringNodes[0].InnerNode.Point())
+	}
+	return PureCycle{
+		OSMWayID:     way.ID,
+		Name:         way.Tags.Name,
+		Highway:      way.Tags.Highway,
+		NodesNum:     len(ringNodes),
+		LengthMeters: geo.LengthHaversine(geom),
+		Geom:         geom,
+	}
+}
+
+// markAnchors marks anchor nodes of the ring as crossings, so the ring is splitted into two links at least.
+// startIdx is the index of existing crossing (-1 if there is no such)
+// Returns false if the ring is too short to be splitted
+func markAnchors(ringNodes []*wrappers.NodeOSM, startIdx int, cfg pureCyclesConfig) bool {
+	anchors := findAnchors(ringNodes, startIdx, cfg)
+	if len(anchors) < 2 {
+		return false
+	}
+	for _, idx := range anchors {
+		ringNodes[idx].IsCrossing = true
+	}
+	return true
+}
+
+// findAnchors returns indices of anchor nodes of the ring. Existing crossing (if any) is always an anchor
+func findAnchors(ringNodes []*wrappers.NodeOSM, startIdx int, cfg pureCyclesConfig) []int {
+	n := len(ringNodes)
+	if n < 2 {
+		return nil
+	}
+	distance := func(i, j int) float64 {
+		return geo.Distance(ringNodes[i].InnerNode.Point(), ringNodes[j].InnerNode.Point())
+	}
+	switch cfg.anchors {
+	case PURE_CYCLE_ANCHORS_EQUAL_LENGTH:
+		parts := max(cfg.parts, 2)
+		start := max(startIdx, 0)
+		// Cumulative distances along the ring starting from the start node
+		cumulative := make([]float64, n+1)
+		for k := 1; k <= n; k++ {
+			cumulative[k] = cumulative[k-1] + distance((start+k-1)%n, (start+k)%n)
+		}
+		total := cumulative[n]
+		anchors := []int{start}
+		used := map[int]struct{}{start: {}}
+		for part := 1; part < parts; part++ {
+			target := total * float64(part) / float64(parts)
+			best, bestDiff := -1, math.Inf(1)
+			for k := 1; k < n; k++ {
+				if diff := math.Abs(cumulative[k] - target); diff < bestDiff {
+					best, bestDiff = k, diff
 				}
-				if existingNode.IsCrossing {
-					// Way has not pure cycle if child node is cross
-					way.IsPureCycle = false
+			}
+			if best < 0 {
+				continue
+			}
+			idx := (start + best) % n
+			if _, ok := used[idx]; ok {
+				continue
+			}
+			used[idx] = struct{}{}
+			anchors = append(anchors, idx)
+		}
+		return anchors
+	default:
+		if startIdx >= 0 {
+			farthest, farthestDistance := -1, -1.0
+			for idx := range ringNodes {
+				if d := distance(startIdx, idx); idx != startIdx && d > farthestDistance {
+					farthest, farthestDistance = idx, d
 				}
 			}
-			if way.IsPureCycle {
-				pureCyclesNum++
+			return []int{startIdx, farthest}
+		}
+		first, second, maxDistance := 0, 1, -1.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if d := distance(i, j); d > maxDistance {
+					first, second, maxDistance = i, j, d
+				}
 			}
 		}
+		return []int{first, second}
 	}
-	if VERBOSE {
-		log.Info().Str("scope", "ispect_pure_cycles").Int("cycles_num", cyclesNum).Int("pure_cycles_num", pureCyclesNum).Float64("elapsed", time.Since(st).Seconds()).Msg("Marking pure cycles done!")
+}
+
+// PureCycles returns pure cycles which have been met during macroscopic network generation
+func (osmData *OSMWaysNodes) PureCycles() []PureCycle {
+	return osmData.pureCycles
+}
+
+// ExportPureCyclesToCSV writes pure cycles which have been met during macroscopic network generation to the given file
+func (osmData *OSMWaysNodes) ExportPureCyclesToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"osm_way_id", "osm_highway", "name", "nodes_num", "length_meters", "is_split", "is_dropped", "geom"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, pureCycle := range osmData.pureCycles {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", pureCycle.OSMWayID),
+			pureCycle.Highway,
+			pureCycle.Name,
+			fmt.Sprintf("%d", pureCycle.NodesNum),
+			fmt.Sprintf("%f", pureCycle.LengthMeters),
+			fmt.Sprintf("%t", pureCycle.IsSplit),
+			fmt.Sprintf("%t", pureCycle.IsDropped),
+			wkt.MarshalString(pureCycle.Geom),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write pure cycle")
+		}
 	}
 	return nil
 }
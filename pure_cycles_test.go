@@ -0,0 +1,120 @@
+package osm2gmns
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkPureCycles(t *testing.T) {
+	prepareData := func() (map[osm.NodeID]*wrappers.NodeOSM, []*wrappers.WayOSM) {
+		// Rectangular ring 1-2-3-4-5-6 which is stretched along the longitude: 1 and 4 are the farthest nodes
+		points := map[osm.NodeID][2]float64{
+			1: {0, 0}, 2: {0.001, 0.0005}, 3: {0.002, 0.0005}, 4: {0.003, 0}, 5: {0.002, -0.0005}, 6: {0.001, -0.0005},
+		}
+		nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+		for nodeID, pt := range points {
+			nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}, UseCount: 1}
+		}
+		way := &wrappers.WayOSM{ID: 1, Nodes: []osm.NodeID{2, 3, 4, 5, 6, 1, 2}, IsCycle: true}
+		return nodesSet, []*wrappers.WayOSM{way}
+	}
+
+	nodesSet, ways := prepareData()
+	pureCycles, err := markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_DROP})
+	assert.NoError(t, err)
+	assert.Len(t, pureCycles, 1, "Ring should be reported as pure cycle")
+	assert.True(t, ways[0].IsPureCycle, "Pure cycle should be dropped")
+	assert.False(t, pureCycles[0].IsSplit, "Pure cycle should not be splitted")
+	assert.True(t, pureCycles[0].IsDropped, "Pure cycle should be marked as dropped")
+	assert.Equal(t, 6, pureCycles[0].NodesNum, "Wrong number of nodes")
+
+	nodesSet, ways = prepareData()
+	pureCycles, err = markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_SPLIT, anchors: PURE_CYCLE_ANCHORS_FARTHEST})
+	assert.NoError(t, err)
+	assert.False(t, ways[0].IsPureCycle, "Splitted cycle should be kept")
+	assert.True(t, pureCycles[0].IsSplit, "Pure cycle should be splitted")
+	for nodeID, node := range nodesSet {
+		assert.Equal(t, nodeID == 1 || nodeID == 4, node.IsCrossing, "Farthest nodes should be anchors only")
+	}
+
+	nodesSet, ways = prepareData()
+	_, err = markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_SPLIT, anchors: PURE_CYCLE_ANCHORS_EQUAL_LENGTH, parts: 3})
+	assert.NoError(t, err)
+	crossingsNum := 0
+	for _, node := range nodesSet {
+		if node.IsCrossing {
+			crossingsNum++
+		}
+	}
+	assert.Equal(t, 3, crossingsNum, "Ring should be splitted into three parts")
+
+	// Loop attached to the network at the single node is splitted regardless of the mode
+	nodesSet, ways = prepareData()
+	nodesSet[3].IsCrossing = true
+	pureCycles, err = markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_DROP})
+	assert.NoError(t, err)
+	assert.Len(t, pureCycles, 0, "Loop is not a pure cycle")
+	assert.False(t, ways[0].IsPureCycle, "Loop should be kept")
+	assert.True(t, nodesSet[1].IsCrossing, "The farthest node from the crossing should be an anchor")
+}
+
+func TestMarkPureCyclesReport(t *testing.T) {
+	prepareData := func(mode PureCycleMode) (map[osm.NodeID]*wrappers.NodeOSM, []*wrappers.WayOSM) {
+		// Isolated ring 1-2-3-4 and the way 5-6 which is not connected to the ring
+		points := map[osm.NodeID][2]float64{
+			1: {0, 0}, 2: {0.001, 0}, 3: {0.001, 0.001}, 4: {0, 0.001}, 5: {0.01, 0}, 6: {0.011, 0},
+		}
+		nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+		for nodeID, pt := range points {
+			nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}}
+		}
+		ways := []*wrappers.WayOSM{
+			{ID: 1, Nodes: []osm.NodeID{1, 2, 3, 4, 1}, WayType: wrappers.WAY_TYPE_HIGHWAY, Tags: wrappers.WayTags{Highway: "residential"}},
+			{ID: 2, Nodes: []osm.NodeID{5, 6}, WayType: wrappers.WAY_TYPE_HIGHWAY, Tags: wrappers.WayTags{Highway: "residential"}},
+		}
+		preparedWays, err := prepareWays(ways, nodesSet, []types.AgentType{types.AGENT_AUTO}, mode)
+		assert.NoError(t, err)
+		preparedNodes, err := prepareNodes(nodesSet, BARRIER_MODE_RESTRICT_LINK)
+		assert.NoError(t, err)
+		return preparedNodes, preparedWays
+	}
+
+	// Closing node is a crossing by default, so the ring becomes the self-loop link as in the original osm2gmns
+	for _, mode := range []PureCycleMode{PURE_CYCLE_MODE_KEEP, PURE_CYCLE_MODE_REPORT} {
+		nodesSet, ways := prepareData(mode)
+		assert.True(t, nodesSet[1].IsCrossing, "Closing node should be a crossing for mode '%s'", mode)
+		assert.Equal(t, 2, nodesSet[1].UseCount, "Closing node should be used twice for mode '%s'", mode)
+		pureCycles, err := markPureCycles(nodesSet, ways, pureCyclesConfig{mode: mode})
+		assert.NoError(t, err)
+		assert.False(t, ways[0].IsPureCycle, "Ring should be kept for mode '%s'", mode)
+		if mode == PURE_CYCLE_MODE_KEEP {
+			assert.Len(t, pureCycles, 0, "Pure cycles should be collected for report mode only")
+			continue
+		}
+		assert.Len(t, pureCycles, 1, "Ring should be reported as pure cycle")
+		assert.Equal(t, osm.WayID(1), pureCycles[0].OSMWayID, "Wrong reported way")
+		assert.Equal(t, 4, pureCycles[0].NodesNum, "Wrong number of nodes")
+		assert.False(t, pureCycles[0].IsDropped, "Reported pure cycle should be kept")
+		assert.False(t, pureCycles[0].IsSplit, "Reported pure cycle should not be splitted")
+	}
+
+	// Ring which shares the closing node with another way is not reported
+	nodesSet, ways := prepareData(PURE_CYCLE_MODE_REPORT)
+	nodesSet[1].UseCount++
+	pureCycles, err := markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_REPORT})
+	assert.NoError(t, err)
+	assert.Len(t, pureCycles, 0, "Ring attached to the network should not be reported")
+
+	// Closing node is not a crossing when pure cycles are dropped
+	nodesSet, ways = prepareData(PURE_CYCLE_MODE_DROP)
+	assert.False(t, nodesSet[1].IsCrossing, "Closing node should not be a crossing")
+	pureCycles, err = markPureCycles(nodesSet, ways, pureCyclesConfig{mode: PURE_CYCLE_MODE_DROP})
+	assert.NoError(t, err)
+	assert.Len(t, pureCycles, 1, "Ring should be reported as pure cycle")
+	assert.True(t, pureCycles[0].IsDropped, "Pure cycle should be dropped")
+	assert.True(t, ways[0].IsPureCycle, "Pure cycle should be dropped")
+}
@@ -0,0 +1,372 @@
+package routing
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+)
+
+const (
+	// Max number of vertices settled by single witness search. Greater value gives less shortcuts, but slower preprocessing
+	witnessSettledLimit = 200
+	// Max number of vertices settled by single witness search when importance of vertex is estimated
+	estimateSettledLimit = 50
+)
+
+// ContractionHierarchy is preprocessed graph for fast shortest path queries.
+// Vertices of the graph are links of the macroscopic network (edge-based graph), so turn prohibitions are respected.
+// Arcs are allowed turns between links and shortcuts which have been added during contraction
+type ContractionHierarchy struct {
+	costType  CostType
+	agentType types.AgentType
+
+	// Vertices: links of macroscopic network
+	vertices []chVertex
+	// Arcs to vertices with higher rank (forward search) and from vertices with higher rank (backward search)
+	upward   [][]chArc
+	downward [][]chArc
+	// Arcs by pair of vertices. It is used for unpacking shortcuts
+	arcs map[[2]int32]chArc
+	// Vertices which leave and enter nodes
+	starts   map[gmns.NodeID][]int32
+	finishes map[gmns.NodeID][]int32
+}
+
+type chVertex struct {
+	linkID       gmns.LinkID
+	sourceNodeID gmns.NodeID
+	targetNodeID gmns.NodeID
+	// Cost and length (meters) of passing the link
+	cost   float64
+	length float64
+	// Order of contraction
+	rank int32
+}
+
+// chArc is transition from one vertex to another. Cost and length of the arc include the target vertex only
+type chArc struct {
+	from, to int32
+	cost     float64
+	length   float64
+	// Contracted vertex between the ends of shortcut (-1 for original arcs)
+	middle int32
+}
+
+// NewContractionHierarchy prepares contraction hierarchy for the given network.
+// Options are the same as for the router: cost, agent type and movement prohibitions.
+// Notice: custom heuristic is not used
+func NewContractionHierarchy(net *macro.Net, options ...func(*RouterConfig)) *ContractionHierarchy {
+	cfg := NewRouterConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	router := NewRouter(net, options...)
+
+	linksIDs := make([]gmns.LinkID, 0, len(router.weights))
+	for linkID := range router.weights {
+		linksIDs = append(linksIDs, linkID)
+	}
+	sort.Slice(linksIDs, func(i, j int) bool {
+		return linksIDs[i] < linksIDs[j]
+	})
+	ch := &ContractionHierarchy{
+		costType:  cfg.costType,
+		agentType: cfg.agentType,
+		vertices:  make([]chVertex, len(linksIDs)),
+		arcs:      make(map[[2]int32]chArc),
+	}
+	verticesIdx := make(map[gmns.LinkID]int32, len(linksIDs))
+	for idx, linkID := range linksIDs {
+		link := net.Links[linkID]
+		ch.vertices[idx] = chVertex{
+			linkID:       linkID,
+			sourceNodeID: link.GetSourceNodeID(),
+			targetNodeID: link.GetTargetNodeID(),
+			cost:         router.weights[linkID],
+			length:       link.GetLengthMeters(),
+			rank:         -1,
+		}
+		verticesIdx[linkID] = int32(idx)
+	}
+
+	graph := newContractionGraph(len(ch.vertices))
+	for idx, linkID := range linksIDs {
+		for _, next := range router.successors(net.Links[linkID]) {
+			to := verticesIdx[next.ID]
+			arc := chArc{from: int32(idx), to: to, cost: router.transitionCost(linkID, next.ID), length: ch.vertices[to].length, middle: -1}
+			graph.addArc(arc)
+			ch.arcs[[2]int32{arc.from, arc.to}] = arc
+		}
+	}
+	ch.contract(graph)
+	ch.prepareSearchGraph()
+	return ch
+}
+
+// CostType returns type of cost which hierarchy has been prepared for
+func (ch *ContractionHierarchy) CostType() CostType {
+	return ch.costType
+}
+
+// AgentType returns agent type which hierarchy has been prepared for
+func (ch *ContractionHierarchy) AgentType() types.AgentType {
+	return ch.agentType
+}
+
+// contract contracts vertices one by one in order of importance (edge difference and number of contracted neighbours) and adds shortcuts
+func (ch *ContractionHierarchy) contract(graph *contractionGraph) {
+	contractedNeighbours := make([]int, len(ch.vertices))
+	importance := func(v int32, shortcuts []chArc) int {
+		return 2*(len(shortcuts)-len(graph.in[v])-len(graph.out[v])) + contractedNeighbours[v]
+	}
+	queue := &importanceQueue{}
+	for v := range ch.vertices {
+		heap.Push(queue, importanceItem{vertex: int32(v), importance: importance(int32(v), graph.findShortcuts(int32(v), estimateSettledLimit))})
+	}
+
+	rank := int32(0)
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(importanceItem)
+		shortcuts := graph.findShortcuts(item.vertex, estimateSettledLimit)
+		// Lazy update: importance could have been changed since the vertex has been pushed
+		current := importance(item.vertex, shortcuts)
+		if queue.Len() > 0 && current > (*queue)[0].importance {
+			heap.Push(queue, importanceItem{vertex: item.vertex, importance: current})
+			continue
+		}
+		for _, shortcut := range graph.findShortcuts(item.vertex, witnessSettledLimit) {
+			graph.addArc(shortcut)
+			key := [2]int32{shortcut.from, shortcut.to}
+			if existing, ok := ch.arcs[key]; !ok || shortcut.cost < existing.cost {
+				ch.arcs[key] = shortcut
+			}
+		}
+		for _, neighbour := range graph.removeVertex(item.vertex) {
+			contractedNeighbours[neighbour]++
+		}
+		ch.vertices[item.vertex].rank = rank
+		rank++
+	}
+}
+
+// prepareSearchGraph splits arcs into upward and downward ones and groups vertices by nodes
+func (ch *ContractionHierarchy) prepareSearchGraph() {
+	ch.upward = make([][]chArc, len(ch.vertices))
+	ch.downward = make([][]chArc, len(ch.vertices))
+	keys := make([][2]int32, 0, len(ch.arcs))
+	for key := range ch.arcs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] == keys[j][0] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+	for _, key := range keys {
+		arc := ch.arcs[key]
+		if ch.vertices[arc.to].rank > ch.vertices[arc.from].rank {
+			ch.upward[arc.from] = append(ch.upward[arc.from], arc)
+		} else {
+			ch.downward[arc.to] = append(ch.downward[arc.to], arc)
+		}
+	}
+	ch.starts = make(map[gmns.NodeID][]int32)
+	ch.finishes = make(map[gmns.NodeID][]int32)
+	for v, vertex := range ch.vertices {
+		ch.starts[vertex.sourceNodeID] = append(ch.starts[vertex.sourceNodeID], int32(v))
+		ch.finishes[vertex.targetNodeID] = append(ch.finishes[vertex.targetNodeID], int32(v))
+	}
+}
+
+// contractionGraph is the remaining (not contracted yet) part of the graph
+type contractionGraph struct {
+	out [][]chArc
+	in  [][]chArc
+	// Reusable buffers of witness search: tentative costs and the number of the search which has set them
+	witnessCosts  []float64
+	witnessSearch []int32
+	searchesNum   int32
+	witnessQueue  vertexQueue
+}
+
+func newContractionGraph(verticesNum int) *contractionGraph {
+	return &contractionGraph{
+		out:           make([][]chArc, verticesNum),
+		in:            make([][]chArc, verticesNum),
+		witnessCosts:  make([]float64, verticesNum),
+		witnessSearch: make([]int32, verticesNum),
+		witnessQueue:  make(vertexQueue, 0),
+	}
+}
+
+// addArc adds arc to the graph. Only the cheapest arc between the pair of vertices is kept
+func (graph *contractionGraph) addArc(arc chArc) {
+	for i, existing := range graph.out[arc.from] {
+		if existing.to != arc.to {
+			continue
+		}
+		if existing.cost <= arc.cost {
+			return
+		}
+		graph.out[arc.from][i] = arc
+		for j := range graph.in[arc.to] {
+			if graph.in[arc.to][j].from == arc.from {
+				graph.in[arc.to][j] = arc
+			}
+		}
+		return
+	}
+	graph.out[arc.from] = append(graph.out[arc.from], arc)
+	graph.in[arc.to] = append(graph.in[arc.to], arc)
+}
+
+// removeVertex removes vertex and its arcs from the graph. Returns neighbours of the vertex
+func (graph *contractionGraph) removeVertex(v int32) []int32 {
+	neighbours := make([]int32, 0, len(graph.in[v])+len(graph.out[v]))
+	for _, arc := range graph.in[v] {
+		graph.out[arc.from] = removeArc(graph.out[arc.from], func(other chArc) bool { return other.to == v })
+		neighbours = append(neighbours, arc.from)
+	}
+	for _, arc := range graph.out[v] {
+		graph.in[arc.to] = removeArc(graph.in[arc.to], func(other chArc) bool { return other.from == v })
+		neighbours = append(neighbours, arc.to)
+	}
+	graph.in[v] = nil
+	graph.out[v] = nil
+	return neighbours
+}
+
+// removeArc removes arcs which match the condition. Order of arcs is not kept
+func removeArc(arcs []chArc, matches func(arc chArc) bool) []chArc {
+	for i := 0; i < len(arcs); {
+		if matches(arcs[i]) {
+			arcs[i] = arcs[len(arcs)-1]
+			arcs = arcs[:len(arcs)-1]
+			continue
+		}
+		i++
+	}
+	return arcs
+}
+
+// findShortcuts returns shortcuts which are needed to keep distances when given vertex is contracted.
+// Witness searches are limited by given number of settled vertices
+func (graph *contractionGraph) findShortcuts(v int32, settledLimit int) []chArc {
+	shortcuts := make([]chArc, 0)
+	for _, inArc := range graph.in[v] {
+		u := inArc.from
+		if u == v {
+			continue
+		}
+		maxCost := 0.0
+		targetsNum := 0
+		for _, outArc := range graph.out[v] {
+			if outArc.to != u && outArc.to != v {
+				maxCost = max(maxCost, inArc.cost+outArc.cost)
+				targetsNum++
+			}
+		}
+		if targetsNum == 0 {
+			continue
+		}
+		graph.witnessSearchFrom(u, v, maxCost, settledLimit)
+		for _, outArc := range graph.out[v] {
+			w := outArc.to
+			if w == u || w == v {
+				continue
+			}
+			cost := inArc.cost + outArc.cost
+			if graph.witnessSearch[w] == graph.searchesNum && graph.witnessCosts[w] <= cost {
+				continue
+			}
+			shortcuts = append(shortcuts, chArc{from: u, to: w, cost: cost, length: inArc.length + outArc.length, middle: v})
+		}
+	}
+	return shortcuts
+}
+
+// witnessSearchFrom evaluates costs from the source to vertices which are reachable without passing through ignored vertex.
+// Search stops when max cost is exceeded or given number of vertices is settled, so some of costs could be overestimated (it only leads to extra shortcuts).
+// Costs are kept in the reusable buffer and are valid until the next search
+func (graph *contractionGraph) witnessSearchFrom(source, ignored int32, maxCost float64, settledLimit int) {
+	graph.searchesNum++
+	searchID := graph.searchesNum
+	graph.witnessCosts[source] = 0
+	graph.witnessSearch[source] = searchID
+	queue := &graph.witnessQueue
+	*queue = append((*queue)[:0], vertexItem{vertex: source, key: 0})
+	settled := 0
+	for queue.Len() > 0 && settled < settledLimit {
+		item := heap.Pop(queue).(vertexItem)
+		if item.key > graph.witnessCosts[item.vertex] {
+			continue
+		}
+		if item.key > maxCost {
+			break
+		}
+		settled++
+		for _, arc := range graph.out[item.vertex] {
+			if arc.to == ignored {
+				continue
+			}
+			cost := item.key + arc.cost
+			if graph.witnessSearch[arc.to] != searchID || cost < graph.witnessCosts[arc.to] {
+				graph.witnessCosts[arc.to] = cost
+				graph.witnessSearch[arc.to] = searchID
+				heap.Push(queue, vertexItem{vertex: arc.to, key: cost})
+			}
+		}
+	}
+}
+
+type importanceItem struct {
+	vertex     int32
+	importance int
+}
+
+type importanceQueue []importanceItem
+
+func (queue importanceQueue) Len() int { return len(queue) }
+func (queue importanceQueue) Less(i, j int) bool {
+	if queue[i].importance == queue[j].importance {
+		return queue[i].vertex < queue[j].vertex
+	}
+	return queue[i].importance < queue[j].importance
+}
+func (queue importanceQueue) Swap(i, j int) { queue[i], queue[j] = queue[j], queue[i] }
+func (queue *importanceQueue) Push(x any)   { *queue = append(*queue, x.(importanceItem)) }
+func (queue *importanceQueue) Pop() any {
+	old := *queue
+	n := len(old)
+	item := old[n-1]
+	*queue = old[:n-1]
+	return item
+}
+
+type vertexItem struct {
+	vertex int32
+	key    float64
+}
+
+type vertexQueue []vertexItem
+
+func (queue vertexQueue) Len() int { return len(queue) }
+func (queue vertexQueue) Less(i, j int) bool {
+	if queue[i].key == queue[j].key {
+		return queue[i].vertex < queue[j].vertex
+	}
+	return queue[i].key < queue[j].key
+}
+func (queue vertexQueue) Swap(i, j int) { queue[i], queue[j] = queue[j], queue[i] }
+func (queue *vertexQueue) Push(x any)   { *queue = append(*queue, x.(vertexItem)) }
+func (queue *vertexQueue) Pop() any {
+	old := *queue
+	n := len(old)
+	item := old[n-1]
+	*queue = old[:n-1]
+	return item
+}
@@ -0,0 +1,228 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/utils"
+	"github.com/pkg/errors"
+)
+
+// Matrix is the result of many-to-many query. Unreachable pairs have infinite cost and length
+type Matrix struct {
+	Sources []gmns.NodeID
+	Targets []gmns.NodeID
+	// Costs and lengths (meters) of the shortest routes indexed by source and target
+	Costs   [][]float64
+	Lengths [][]float64
+}
+
+// chLabel is the label of vertex settled by upward search
+type chLabel struct {
+	cost   float64
+	length float64
+	// Previous vertex for forward search and next vertex for backward search (-1 for initial vertices)
+	parent int32
+}
+
+type bucketEntry struct {
+	target int
+	cost   float64
+	length float64
+}
+
+// ShortestPath returns the shortest route between given nodes. Route has no geometry and no movements since the network is not kept by the hierarchy
+func (ch *ContractionHierarchy) ShortestPath(sourceNodeID, targetNodeID gmns.NodeID) (*Route, error) {
+	if !ch.hasNode(sourceNodeID) {
+		return nil, errors.Wrapf(ErrNodeNotFound, "Source node %d", sourceNodeID)
+	}
+	if !ch.hasNode(targetNodeID) {
+		return nil, errors.Wrapf(ErrNodeNotFound, "Target node %d", targetNodeID)
+	}
+	route := &Route{
+		Links: []gmns.LinkID{},
+		Nodes: []gmns.NodeID{sourceNodeID},
+	}
+	if sourceNodeID == targetNodeID {
+		return route, nil
+	}
+	forward := ch.upwardSearch(ch.starts[sourceNodeID], true)
+	backward := ch.upwardSearch(ch.finishes[targetNodeID], false)
+	meeting := int32(-1)
+	best := math.Inf(1)
+	for v, forwardLabel := range forward {
+		backwardLabel, ok := backward[v]
+		if !ok {
+			continue
+		}
+		cost := forwardLabel.cost + backwardLabel.cost
+		if cost < best || (cost == best && v < meeting) {
+			best = cost
+			meeting = v
+		}
+	}
+	if meeting < 0 {
+		return nil, ErrNoRoute
+	}
+
+	// Up-down path in the hierarchy
+	path := make([]int32, 0)
+	for v := meeting; v >= 0; v = forward[v].parent {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for v := backward[meeting].parent; v >= 0; v = backward[v].parent {
+		path = append(path, v)
+	}
+	vertices := []int32{path[0]}
+	for i := 1; i < len(path); i++ {
+		vertices = ch.unpack(path[i-1], path[i], vertices)
+	}
+	for _, v := range vertices {
+		route.Links = append(route.Links, ch.vertices[v].linkID)
+		route.Nodes = append(route.Nodes, ch.vertices[v].targetNodeID)
+		route.LengthMeters += ch.vertices[v].length
+	}
+	route.Cost = best
+	return route, nil
+}
+
+// Table evaluates costs and lengths of the shortest routes between every source and every target (bucket-based many-to-many search).
+// Forward searches are distributed among given number of workers. Non-positive number means number of CPUs.
+// Nodes which are unknown to the hierarchy are unreachable
+func (ch *ContractionHierarchy) Table(sources, targets []gmns.NodeID, workers int) *Matrix {
+	matrix := &Matrix{
+		Sources: make([]gmns.NodeID, len(sources)),
+		Targets: make([]gmns.NodeID, len(targets)),
+		Costs:   make([][]float64, len(sources)),
+		Lengths: make([][]float64, len(sources)),
+	}
+	copy(matrix.Sources, sources)
+	copy(matrix.Targets, targets)
+
+	buckets := make(map[int32][]bucketEntry)
+	for targetIdx, targetNodeID := range targets {
+		for v, label := range ch.upwardSearch(ch.finishes[targetNodeID], false) {
+			buckets[v] = append(buckets[v], bucketEntry{target: targetIdx, cost: label.cost, length: label.length})
+		}
+	}
+
+	utils.RunWorkers(len(sources), workers, func(jobs <-chan int) {
+		for sourceIdx := range jobs {
+			costs, lengths := ch.tableRow(sources[sourceIdx], targets, buckets)
+			matrix.Costs[sourceIdx] = costs
+			matrix.Lengths[sourceIdx] = lengths
+		}
+	})
+	return matrix
+}
+
+// tableRow evaluates costs and lengths from the source to every target using buckets of backward searches
+func (ch *ContractionHierarchy) tableRow(sourceNodeID gmns.NodeID, targets []gmns.NodeID, buckets map[int32][]bucketEntry) ([]float64, []float64) {
+	costs := make([]float64, len(targets))
+	lengths := make([]float64, len(targets))
+	for i := range targets {
+		costs[i] = math.Inf(1)
+		lengths[i] = math.Inf(1)
+	}
+	for v, label := range ch.upwardSearch(ch.starts[sourceNodeID], true) {
+		for _, entry := range buckets[v] {
+			if cost := label.cost + entry.cost; cost < costs[entry.target] {
+				costs[entry.target] = cost
+				lengths[entry.target] = label.length + entry.length
+			}
+		}
+	}
+	for i, targetNodeID := range targets {
+		if targetNodeID == sourceNodeID {
+			costs[i] = 0
+			lengths[i] = 0
+		}
+	}
+	return costs, lengths
+}
+
+// upwardSearch settles every vertex which is reachable from initial vertices using arcs to vertices with higher rank only.
+// Forward search starts with the cost of initial links (route leaves the source node), backward search starts with zero cost (route enters the target node).
+// Vertices which could be reached cheaper through vertex with higher rank (stall-on-demand) are not expanded and not returned, since they can't be on the shortest route
+func (ch *ContractionHierarchy) upwardSearch(initial []int32, forward bool) map[int32]chLabel {
+	labels := make(map[int32]chLabel)
+	settled := make(map[int32]chLabel)
+	queue := &vertexQueue{}
+	relax := func(v int32, label chLabel) {
+		if current, ok := labels[v]; ok && label.cost >= current.cost {
+			return
+		}
+		labels[v] = label
+		heap.Push(queue, vertexItem{vertex: v, key: label.cost})
+	}
+	for _, v := range initial {
+		if forward {
+			relax(v, chLabel{cost: ch.vertices[v].cost, length: ch.vertices[v].length, parent: -1})
+		} else {
+			relax(v, chLabel{cost: 0, length: 0, parent: -1})
+		}
+	}
+	upward, downward := ch.upward, ch.downward
+	if !forward {
+		upward, downward = ch.downward, ch.upward
+	}
+	stalled := make(map[int32]struct{})
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(vertexItem)
+		if _, ok := settled[item.vertex]; ok {
+			continue
+		}
+		if _, ok := stalled[item.vertex]; ok {
+			continue
+		}
+		label := labels[item.vertex]
+		if ch.isStalled(label.cost, labels, downward[item.vertex], forward) {
+			stalled[item.vertex] = struct{}{}
+			continue
+		}
+		settled[item.vertex] = label
+		for _, arc := range upward[item.vertex] {
+			next := arc.to
+			if !forward {
+				next = arc.from
+			}
+			relax(next, chLabel{cost: label.cost + arc.cost, length: label.length + arc.length, parent: item.vertex})
+		}
+	}
+	return settled
+}
+
+// isStalled checks if vertex could be reached cheaper through one of vertices with higher rank
+func (ch *ContractionHierarchy) isStalled(cost float64, labels map[int32]chLabel, arcs []chArc, forward bool) bool {
+	for _, arc := range arcs {
+		higher := arc.from
+		if !forward {
+			higher = arc.to
+		}
+		if label, ok := labels[higher]; ok && label.cost+arc.cost < cost {
+			return true
+		}
+	}
+	return false
+}
+
+// unpack appends original vertices of the arc (excluding the first one) to the path
+func (ch *ContractionHierarchy) unpack(from, to int32, path []int32) []int32 {
+	arc := ch.arcs[[2]int32{from, to}]
+	if arc.middle < 0 {
+		return append(path, to)
+	}
+	path = ch.unpack(from, arc.middle, path)
+	return ch.unpack(arc.middle, to, path)
+}
+
+// hasNode checks if any link of the hierarchy is adjacent to the node
+func (ch *ContractionHierarchy) hasNode(nodeID gmns.NodeID) bool {
+	_, isStart := ch.starts[nodeID]
+	_, isFinish := ch.finishes[nodeID]
+	return isStart || isFinish
+}
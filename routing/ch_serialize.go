@@ -0,0 +1,220 @@
+package routing
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/pkg/errors"
+)
+
+/*
+Binary format of the contraction hierarchy (little-endian):
+
+	header:   magic "O2CH" (4 bytes), version (uint16), cost type (uint16), agent type (uint16), number of vertices (uint32)
+	vertices: link_id (int64), source_node_id (int64), target_node_id (int64), cost (float64), length_meters (float64), rank (int32)
+	arcs:     number of arcs (uint32), then for every arc: from (int32), to (int32), middle (int32, -1 for original arcs), cost (float64), length_meters (float64)
+
+Vertices are referenced by their position in the list of vertices
+*/
+
+const (
+	chMagic   = "O2CH"
+	chVersion = uint16(1)
+)
+
+var (
+	ErrBadFormat = fmt.Errorf("Bad format of contraction hierarchy")
+)
+
+type chHeader struct {
+	Magic       [4]byte
+	Version     uint16
+	CostType    uint16
+	AgentType   uint16
+	VerticesNum uint32
+}
+
+type chVertexRecord struct {
+	LinkID       int64
+	SourceNodeID int64
+	TargetNodeID int64
+	Cost         float64
+	Length       float64
+	Rank         int32
+}
+
+type chArcRecord struct {
+	From   int32
+	To     int32
+	Middle int32
+	Cost   float64
+	Length float64
+}
+
+// Serialize writes preprocessed graph to the writer
+func (ch *ContractionHierarchy) Serialize(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	header := chHeader{
+		Version:     chVersion,
+		CostType:    uint16(ch.costType),
+		AgentType:   uint16(ch.agentType),
+		VerticesNum: uint32(len(ch.vertices)),
+	}
+	copy(header.Magic[:], chMagic)
+	err := binary.Write(writer, binary.LittleEndian, header)
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, vertex := range ch.vertices {
+		err = binary.Write(writer, binary.LittleEndian, chVertexRecord{
+			LinkID:       int64(vertex.linkID),
+			SourceNodeID: int64(vertex.sourceNodeID),
+			TargetNodeID: int64(vertex.targetNodeID),
+			Cost:         vertex.cost,
+			Length:       vertex.length,
+			Rank:         vertex.rank,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write vertex")
+		}
+	}
+	err = binary.Write(writer, binary.LittleEndian, uint32(len(ch.arcs)))
+	if err != nil {
+		return errors.Wrap(err, "Can't write number of arcs")
+	}
+	// Upward and downward arcs together are the whole set of arcs in deterministic order
+	for _, arcs := range [][][]chArc{ch.upward, ch.downward} {
+		for _, vertexArcs := range arcs {
+			for _, arc := range vertexArcs {
+				err = binary.Write(writer, binary.LittleEndian, chArcRecord{From: arc.from, To: arc.to, Middle: arc.middle, Cost: arc.cost, Length: arc.length})
+				if err != nil {
+					return errors.Wrap(err, "Can't write arc")
+				}
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// DeserializeContractionHierarchy reads preprocessed graph from the reader
+func DeserializeContractionHierarchy(r io.Reader) (*ContractionHierarchy, error) {
+	reader := bufio.NewReader(r)
+	header := chHeader{}
+	err := binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read header")
+	}
+	if string(header.Magic[:]) != chMagic || header.Version != chVersion {
+		return nil, errors.Wrapf(ErrBadFormat, "Magic '%s', version %d", string(header.Magic[:]), header.Version)
+	}
+	ch := &ContractionHierarchy{
+		costType:  CostType(header.CostType),
+		agentType: types.AgentType(header.AgentType),
+		vertices:  make([]chVertex, header.VerticesNum),
+		arcs:      make(map[[2]int32]chArc),
+	}
+	for i := range ch.vertices {
+		record := chVertexRecord{}
+		err = binary.Read(reader, binary.LittleEndian, &record)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't read vertex")
+		}
+		ch.vertices[i] = chVertex{
+			linkID:       gmns.LinkID(record.LinkID),
+			sourceNodeID: gmns.NodeID(record.SourceNodeID),
+			targetNodeID: gmns.NodeID(record.TargetNodeID),
+			cost:         record.Cost,
+			length:       record.Length,
+			rank:         record.Rank,
+		}
+	}
+	arcsNum := uint32(0)
+	err = binary.Read(reader, binary.LittleEndian, &arcsNum)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read number of arcs")
+	}
+	for i := uint32(0); i < arcsNum; i++ {
+		record := chArcRecord{}
+		err = binary.Read(reader, binary.LittleEndian, &record)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't read arc")
+		}
+		if record.From < 0 || record.To < 0 || record.Middle < -1 || int(record.From) >= len(ch.vertices) || int(record.To) >= len(ch.vertices) || int(record.Middle) >= len(ch.vertices) {
+			return nil, errors.Wrapf(ErrBadFormat, "Arc %d references unknown vertex", i)
+		}
+		ch.arcs[[2]int32{record.From, record.To}] = chArc{from: record.From, to: record.To, middle: record.Middle, cost: record.Cost, length: record.Length}
+	}
+	err = ch.checkShortcuts()
+	if err != nil {
+		return nil, err
+	}
+	ch.prepareSearchGraph()
+	return ch, nil
+}
+
+// checkShortcuts checks if every shortcut could be unpacked: both halves of the shortcut exist and
+// unpacking never comes back to the shortcut which is being unpacked (otherwise unpacking would never stop)
+func (ch *ContractionHierarchy) checkShortcuts() error {
+	const (
+		unpacking = iota + 1
+		unpacked
+	)
+	states := make(map[[2]int32]int, len(ch.arcs))
+	var check func(key [2]int32) error
+	check = func(key [2]int32) error {
+		arc, ok := ch.arcs[key]
+		if !ok {
+			return errors.Wrapf(ErrBadFormat, "Shortcut references unknown arc %d->%d", key[0], key[1])
+		}
+		if arc.middle < 0 {
+			return nil
+		}
+		switch states[key] {
+		case unpacked:
+			return nil
+		case unpacking:
+			return errors.Wrapf(ErrBadFormat, "Shortcut %d->%d is cyclic", key[0], key[1])
+		}
+		states[key] = unpacking
+		for _, half := range [][2]int32{{arc.from, arc.middle}, {arc.middle, arc.to}} {
+			err := check(half)
+			if err != nil {
+				return err
+			}
+		}
+		states[key] = unpacked
+		return nil
+	}
+	for key := range ch.arcs {
+		err := check(key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportToFile writes preprocessed graph to the file
+func (ch *ContractionHierarchy) ExportToFile(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+	return ch.Serialize(file)
+}
+
+// ReadContractionHierarchyFile reads preprocessed graph from the file
+func ReadContractionHierarchyFile(fname string) (*ContractionHierarchy, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't open file")
+	}
+	defer file.Close()
+	return DeserializeContractionHierarchy(file)
+}
@@ -0,0 +1,150 @@
+package routing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareGrid returns the grid network of two-way roads with different speeds (~111 meters between neighbouring nodes)
+func prepareGrid(t *testing.T, size int) *macro.Net {
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+	nodeID := func(row, column int) osm.NodeID {
+		return osm.NodeID(row*size + column + 1)
+	}
+	for row := 0; row < size; row++ {
+		for column := 0; column < size; column++ {
+			id := nodeID(row, column)
+			nodesSet[id] = &wrappers.NodeOSM{ID: id, InnerNode: osm.Node{ID: id, Lon: float64(column) * 0.001, Lat: float64(row) * 0.001}, IsCrossing: true}
+		}
+	}
+	ways := make([]*wrappers.WayOSM, 0, 2*size)
+	for i := 0; i < size; i++ {
+		rowNodes, columnNodes := make([]osm.NodeID, size), make([]osm.NodeID, size)
+		for j := 0; j < size; j++ {
+			rowNodes[j], columnNodes[j] = nodeID(i, j), nodeID(j, i)
+		}
+		for _, nodes := range [][]osm.NodeID{rowNodes, columnNodes} {
+			ways = append(ways, &wrappers.WayOSM{
+				ID:                osm.WayID(len(ways) + 1),
+				Nodes:             nodes,
+				Tags:              wrappers.WayTags{MaxSpeed: float64(20 + 10*(len(ways)%5))},
+				FreeSpeed:         -1,
+				LinkType:          types.LINK_PRIMARY,
+				LinkClass:         types.LINK_CLASS_HIGHWAY,
+				AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO},
+			})
+		}
+	}
+	net, err := macro.NewNetFromOSM(ways, nodesSet)
+	assert.NoError(t, err)
+	return net
+}
+
+func TestContractionHierarchy(t *testing.T) {
+	net := prepareGrid(t, 6)
+	movements, err := net.GenerateMovements()
+	assert.NoError(t, err)
+	// Prohibit every third turn
+	prohibited := movement.NewMovementsStorage()
+	kept := make([]movement.Movement, 0, movements.Len())
+	for i, mvmt := range movements.List() {
+		if i%3 != 0 {
+			kept = append(kept, *mvmt)
+		}
+	}
+	prohibited.AddMovements(kept)
+
+	nodesIDs := make([]gmns.NodeID, 0, len(net.Nodes))
+	for nodeID := range net.Nodes {
+		nodesIDs = append(nodesIDs, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+	options := []func(*RouterConfig){WithCostType(COST_FREE_FLOW_TIME), WithMovements(prohibited)}
+	router := NewRouter(net, options...)
+	ch := NewContractionHierarchy(net, options...)
+	matrix := ch.Table(nodesIDs, nodesIDs, 2)
+	for sourceIdx, sourceNodeID := range nodesIDs {
+		costs, err := router.ShortestCosts(sourceNodeID)
+		assert.NoError(t, err)
+		for targetIdx, targetNodeID := range nodesIDs {
+			expected, ok := costs[targetNodeID]
+			if !ok {
+				assert.True(t, math.IsInf(matrix.Costs[sourceIdx][targetIdx], 1), "Pair %d-%d should be unreachable", sourceNodeID, targetNodeID)
+				continue
+			}
+			assert.InDelta(t, expected, matrix.Costs[sourceIdx][targetIdx], 1e-6, "Wrong cost for pair %d-%d", sourceNodeID, targetNodeID)
+		}
+	}
+
+	// The farthest reachable node
+	source, target, farthest := nodesIDs[0], nodesIDs[0], 0.0
+	for targetIdx, targetNodeID := range nodesIDs {
+		if cost := matrix.Costs[0][targetIdx]; !math.IsInf(cost, 1) && cost > farthest {
+			target, farthest = targetNodeID, cost
+		}
+	}
+	expected, err := router.Dijkstra(source, target)
+	assert.NoError(t, err)
+	route, err := ch.ShortestPath(source, target)
+	assert.NoError(t, err)
+	assert.InDelta(t, expected.Cost, route.Cost, 1e-6, "Wrong cost of the route")
+	assert.InDelta(t, expected.LengthMeters, route.LengthMeters, 1e-6, "Wrong length of the route")
+	for i := 1; i < len(route.Links); i++ {
+		previous, next := net.Links[route.Links[i-1]], net.Links[route.Links[i]]
+		assert.Equal(t, previous.GetTargetNodeID(), next.GetSourceNodeID(), "Unpacked links should be consecutive")
+		assert.True(t, router.turnAllowed(next.GetSourceNodeID(), previous.ID, next.ID), "Unpacked route should not use prohibited turns")
+	}
+
+	buffer := bytes.Buffer{}
+	assert.NoError(t, ch.Serialize(&buffer))
+	restored, err := DeserializeContractionHierarchy(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, COST_FREE_FLOW_TIME, restored.CostType())
+	assert.Equal(t, matrix.Costs, restored.Table(nodesIDs, nodesIDs, 1).Costs, "Restored hierarchy should give the same results")
+
+	_, err = DeserializeContractionHierarchy(bytes.NewReader([]byte("not a hierarchy at all")))
+	assert.ErrorIs(t, err, ErrBadFormat)
+}
+
+func TestDeserializeContractionHierarchyInvalid(t *testing.T) {
+	encode := func(arcs []chArcRecord) *bytes.Buffer {
+		buffer := &bytes.Buffer{}
+		header := chHeader{Version: chVersion, VerticesNum: 3}
+		copy(header.Magic[:], chMagic)
+		assert.NoError(t, binary.Write(buffer, binary.LittleEndian, header))
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, binary.Write(buffer, binary.LittleEndian, chVertexRecord{LinkID: int64(i), Rank: int32(i)}))
+		}
+		assert.NoError(t, binary.Write(buffer, binary.LittleEndian, uint32(len(arcs))))
+		for _, arc := range arcs {
+			assert.NoError(t, binary.Write(buffer, binary.LittleEndian, arc))
+		}
+		return buffer
+	}
+
+	_, err := DeserializeContractionHierarchy(encode([]chArcRecord{{From: 0, To: 1, Middle: -1}, {From: 1, To: 2, Middle: -1}, {From: 0, To: 2, Middle: 1}}))
+	assert.NoError(t, err, "Valid shortcut should be accepted")
+
+	_, err = DeserializeContractionHierarchy(encode([]chArcRecord{{From: 0, To: 1, Middle: -2}}))
+	assert.ErrorIs(t, err, ErrBadFormat, "Negative middle vertex other than -1 should be rejected")
+
+	_, err = DeserializeContractionHierarchy(encode([]chArcRecord{{From: 0, To: 2, Middle: 1}, {From: 0, To: 1, Middle: -1}}))
+	assert.ErrorIs(t, err, ErrBadFormat, "Shortcut without the second half should be rejected")
+
+	// Unpacking 0->1 needs 0->2 which needs 0->1 again
+	_, err = DeserializeContractionHierarchy(encode([]chArcRecord{{From: 0, To: 1, Middle: 2}, {From: 0, To: 2, Middle: 1}, {From: 2, To: 1, Middle: -1}}))
+	assert.ErrorIs(t, err, ErrBadFormat, "Cyclic shortcuts should be rejected")
+}
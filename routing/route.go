@@ -0,0 +1,56 @@
+package routing
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/paulmach/orb"
+)
+
+// Route is the sequence of links between two nodes
+type Route struct {
+	Links []gmns.LinkID
+	Nodes []gmns.NodeID
+	// Movements between consecutive links. It is filled only when movement prohibitions are enabled (-1 for nodes without movements)
+	Movements    []movement.MovementID
+	Cost         float64
+	LengthMeters float64
+	// Geometry (EPSG:4326) of the whole route
+	Geom orb.LineString
+}
+
+// newRoute prepares route for given sequence of links
+func (router *Router) newRoute(sourceNodeID gmns.NodeID, linksIDs []gmns.LinkID) *Route {
+	route := &Route{
+		Links: linksIDs,
+		Nodes: []gmns.NodeID{sourceNodeID},
+		Geom:  orb.LineString{},
+	}
+	if router.restrictedNodes != nil {
+		route.Movements = make([]movement.MovementID, 0, max(len(linksIDs)-1, 0))
+	}
+	for i, linkID := range linksIDs {
+		link := router.net.Links[linkID]
+		route.Nodes = append(route.Nodes, link.GetTargetNodeID())
+		route.Cost += router.weights[linkID]
+		if i > 0 {
+			route.Cost += router.turnCosts[[2]gmns.LinkID{linksIDs[i-1], linkID}]
+		}
+		route.LengthMeters += link.GetLengthMeters()
+		geom := link.GetGeom()
+		if len(route.Geom) > 0 && len(geom) > 0 && route.Geom[len(route.Geom)-1].Equal(geom[0]) {
+			geom = geom[1:]
+		}
+		route.Geom = append(route.Geom, geom...)
+		if i > 0 && route.Movements != nil {
+			mvmtID, ok := router.turns[[2]gmns.LinkID{linksIDs[i-1], linkID}]
+			if !ok {
+				mvmtID = -1
+			}
+			route.Movements = append(route.Movements, mvmtID)
+		}
+	}
+	if len(route.Geom) == 0 {
+		route.Geom = append(route.Geom, router.net.Nodes[sourceNodeID].GetGeom())
+	}
+	return route
+}
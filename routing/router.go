@@ -0,0 +1,340 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+var (
+	ErrNodeNotFound = fmt.Errorf("Node not found")
+	ErrNoRoute      = fmt.Errorf("No route between nodes")
+)
+
+// CostType defines what is minimized by the router
+type CostType uint16
+
+const (
+	// Length (meters) of links
+	COST_LENGTH = CostType(iota)
+	// Free-flow travel time (seconds) of links
+	COST_FREE_FLOW_TIME
+	// User-defined cost of links
+	COST_CUSTOM
+)
+
+func (iotaIdx CostType) String() string {
+	return [...]string{"length", "free_flow_time", "custom"}[iotaIdx]
+}
+
+// WeightFunc returns cost of passing the link. Negative or infinite value means that the link is impassable
+type WeightFunc func(link *macro.Link) float64
+
+// TurnCostFunc returns extra cost of passing the movement (e.g. turn penalty). Negative or infinite value means that the turn is prohibited
+type TurnCostFunc func(mvmt *movement.Movement) float64
+
+// HeuristicFunc returns lower bound of cost between two points (EPSG:4326). It is used by A* search only
+type HeuristicFunc func(from, to orb.Point) float64
+
+// RouterConfig is the set of parameters for the router
+type RouterConfig struct {
+	costType  CostType
+	weight    WeightFunc
+	heuristic HeuristicFunc
+	agentType types.AgentType
+	// Movements which are allowed at nodes. Nil value means that every turn is allowed
+	movements *movement.MovementsStorage
+	// Extra cost of movements. Nil value means that turns are free
+	turnCost TurnCostFunc
+}
+
+// NewRouterConfigDefault returns default parameters for the router: length cost, AGENT_AUTO, no movement prohibitions
+func NewRouterConfigDefault() *RouterConfig {
+	return &RouterConfig{
+		costType:  COST_LENGTH,
+		agentType: types.AGENT_AUTO,
+	}
+}
+
+// WithCostType sets one of predefined costs: COST_LENGTH or COST_FREE_FLOW_TIME
+func WithCostType(costType CostType) func(*RouterConfig) {
+	return func(cfg *RouterConfig) {
+		cfg.costType = costType
+	}
+}
+
+// WithCustomCost sets user-defined cost of links. Heuristic could be nil (A* becomes plain Dijkstra then)
+// Notice: heuristic should never overestimate cost, otherwise A* search returns suboptimal routes
+func WithCustomCost(weight WeightFunc, heuristic HeuristicFunc) func(*RouterConfig) {
+	return func(cfg *RouterConfig) {
+		cfg.costType = COST_CUSTOM
+		cfg.weight = weight
+		cfg.heuristic = heuristic
+	}
+}
+
+// WithAgentType sets agent type which routes are searched for. Links which do not allow given agent type are ignored
+func WithAgentType(agentType types.AgentType) func(*RouterConfig) {
+	return func(cfg *RouterConfig) {
+		cfg.agentType = agentType
+	}
+}
+
+// WithMovements enables movement prohibitions: at nodes which have movements, only turns between the incoming and the outcoming links
+// of existing movements (allowed for the agent type) are possible. Nodes without movements allow every turn
+func WithMovements(movements *movement.MovementsStorage) func(*RouterConfig) {
+	return func(cfg *RouterConfig) {
+		cfg.movements = movements
+	}
+}
+
+// WithTurnCost sets extra cost of movements. It takes effect only when movement prohibitions are enabled (see WithMovements).
+// Notice: heuristic of A* search does not account turn costs, so it is still admissible when turn costs are non-negative
+func WithTurnCost(turnCost TurnCostFunc) func(*RouterConfig) {
+	return func(cfg *RouterConfig) {
+		cfg.turnCost = turnCost
+	}
+}
+
+// Router searches for the shortest routes on the macroscopic network.
+// Search is performed on links (edge-based), so turn prohibitions are respected. Routes never pass through zones' centroids.
+// Notice: router takes snapshot of the network costs; it should be created after the network has been finalized
+type Router struct {
+	net       *macro.Net
+	costType  CostType
+	heuristic HeuristicFunc
+	// Costs of passable links
+	weights map[gmns.LinkID]float64
+	// Nodes which have movements and allowed turns there (pair of incoming and outcoming links). Nil when prohibitions are disabled
+	restrictedNodes map[gmns.NodeID]struct{}
+	turns           map[[2]gmns.LinkID]movement.MovementID
+	// Extra costs of turns (pair of incoming and outcoming links). Only non-zero costs are stored
+	turnCosts map[[2]gmns.LinkID]float64
+}
+
+// NewRouter prepares router for the given network
+func NewRouter(net *macro.Net, options ...func(*RouterConfig)) *Router {
+	cfg := NewRouterConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	router := &Router{
+		net:      net,
+		costType: cfg.costType,
+		weights:  make(map[gmns.LinkID]float64, len(net.Links)),
+	}
+
+	weight := cfg.weight
+	switch cfg.costType {
+	case COST_FREE_FLOW_TIME:
+		weight = FreeFlowTime
+	case COST_LENGTH:
+		weight = Length
+	}
+	if weight == nil {
+		weight = Length
+	}
+	maxSpeed := 0.0
+	for linkID, link := range net.Links {
+		if !allowsAgent(link.GetAllowedAgentTypes(), cfg.agentType) {
+			continue
+		}
+		cost := weight(link)
+		if cost < 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+			continue
+		}
+		router.weights[linkID] = cost
+		maxSpeed = max(maxSpeed, freeSpeed(link))
+	}
+
+	switch cfg.costType {
+	case COST_FREE_FLOW_TIME:
+		// Straight line at the highest speed of the network is never slower than any route
+		metersPerSecond := maxSpeed / 3.6
+		router.heuristic = func(from, to orb.Point) float64 {
+			if metersPerSecond <= 0 {
+				return 0
+			}
+			return geo.Distance(from, to) / metersPerSecond
+		}
+	case COST_LENGTH:
+		router.heuristic = geo.Distance
+	default:
+		router.heuristic = cfg.heuristic
+	}
+	if router.heuristic == nil {
+		router.heuristic = func(from, to orb.Point) float64 { return 0 }
+	}
+
+	if cfg.movements != nil {
+		router.restrictedNodes = make(map[gmns.NodeID]struct{})
+		router.turns = make(map[[2]gmns.LinkID]movement.MovementID)
+		router.turnCosts = make(map[[2]gmns.LinkID]float64)
+		for _, mvmt := range cfg.movements.List() {
+			// Node is restricted even if the turn exists for other agent types only
+			router.restrictedNodes[mvmt.MacroNodeID] = struct{}{}
+			if !allowsAgent(mvmt.AllowedAgentTypes(), cfg.agentType) {
+				continue
+			}
+			turn := [2]gmns.LinkID{mvmt.IncomeMacroLinkID, mvmt.OutcomeMacroLinkID}
+			if cfg.turnCost != nil {
+				cost := cfg.turnCost(mvmt)
+				if cost < 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+					continue
+				}
+				if cost != 0 {
+					router.turnCosts[turn] = cost
+				}
+			}
+			router.turns[turn] = mvmt.ID
+		}
+	}
+	return router
+}
+
+// UpdateCosts re-evaluates costs of passable links with given function, so the router could be reused when costs change
+// (e.g. congested travel times between iterations of the traffic assignment). Links which become impassable are dropped.
+// Heuristic is kept as is, so it should not overestimate new costs. Notice: it should not be called during searches
+func (router *Router) UpdateCosts(weight WeightFunc) {
+	for linkID := range router.weights {
+		cost := weight(router.net.Links[linkID])
+		if cost < 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+			delete(router.weights, linkID)
+			continue
+		}
+		router.weights[linkID] = cost
+	}
+}
+
+// CostType returns type of cost which is minimized by the router
+func (router *Router) CostType() CostType {
+	return router.costType
+}
+
+// Length returns length (meters) of the link
+func Length(link *macro.Link) float64 {
+	return link.GetLengthMeters()
+}
+
+// FreeFlowTime returns free-flow travel time (seconds) of the link. Links without known free speed are impassable
+func FreeFlowTime(link *macro.Link) float64 {
+	speed := freeSpeed(link)
+	if speed <= 0 {
+		return -1
+	}
+	return link.GetLengthMeters() / (speed / 3.6)
+}
+
+// AgentFreeFlowTime returns free-flow travel time (seconds) of links for the agent with given max speed (km/h).
+// Free speed of the link is used when it is lower. Non-positive agent speed means no limit
+func AgentFreeFlowTime(agentSpeed float64) WeightFunc {
+	return func(link *macro.Link) float64 {
+		speed := freeSpeed(link)
+		if agentSpeed > 0 && (speed <= 0 || agentSpeed < speed) {
+			speed = agentSpeed
+		}
+		if speed <= 0 {
+			return -1
+		}
+		return link.GetLengthMeters() / (speed / 3.6)
+	}
+}
+
+// freeSpeed returns free speed (km/h) of the link. Default speed of the link type is used when it is unknown
+func freeSpeed(link *macro.Link) float64 {
+	if link.GetFreeSpeed() > 0 {
+		return link.GetFreeSpeed()
+	}
+	return types.NewSpeedDefault(link.GetLinkType())
+}
+
+// allowsAgent checks if agent type is in the list. Empty list allows nothing
+func allowsAgent(agentTypes []types.AgentType, agentType types.AgentType) bool {
+	for _, allowed := range agentTypes {
+		if allowed == agentType {
+			return true
+		}
+	}
+	return false
+}
+
+// turnAllowed checks if turn from the incoming link to the outcoming one is possible at the node
+func (router *Router) turnAllowed(nodeID gmns.NodeID, incomingLinkID, outcomingLinkID gmns.LinkID) bool {
+	if _, ok := router.restrictedNodes[nodeID]; !ok {
+		return true
+	}
+	_, ok := router.turns[[2]gmns.LinkID{incomingLinkID, outcomingLinkID}]
+	return ok
+}
+
+// transitionCost returns cost of passing the next link after the previous one (including extra cost of the turn)
+func (router *Router) transitionCost(previous, next gmns.LinkID) float64 {
+	return router.weights[next] + router.turnCosts[[2]gmns.LinkID{previous, next}]
+}
+
+// Movement returns identifier of the movement between given links. False is returned when there is no such movement
+// or movement prohibitions are disabled
+func (router *Router) Movement(incomingLinkID, outcomingLinkID gmns.LinkID) (movement.MovementID, bool) {
+	mvmtID, ok := router.turns[[2]gmns.LinkID{incomingLinkID, outcomingLinkID}]
+	return mvmtID, ok
+}
+
+// successors returns passable links which could follow given link
+func (router *Router) successors(link *macro.Link) []*macro.Link {
+	node, ok := router.net.Nodes[link.GetTargetNodeID()]
+	if !ok || node.IsCentroid() {
+		return nil
+	}
+	successors := make([]*macro.Link, 0, len(node.GetOutcomingLinks()))
+	for _, linkID := range node.GetOutcomingLinks() {
+		if _, ok := router.weights[linkID]; !ok || !router.turnAllowed(node.ID, link.ID, linkID) {
+			continue
+		}
+		successors = append(successors, router.net.Links[linkID])
+	}
+	return successors
+}
+
+// predecessors returns passable links which could precede given link
+func (router *Router) predecessors(link *macro.Link) []*macro.Link {
+	node, ok := router.net.Nodes[link.GetSourceNodeID()]
+	if !ok || node.IsCentroid() {
+		return nil
+	}
+	predecessors := make([]*macro.Link, 0, len(node.GetIncomingLinks()))
+	for _, linkID := range node.GetIncomingLinks() {
+		if _, ok := router.weights[linkID]; !ok || !router.turnAllowed(node.ID, linkID, link.ID) {
+			continue
+		}
+		predecessors = append(predecessors, router.net.Links[linkID])
+	}
+	return predecessors
+}
+
+// startLinks returns passable links which leave given node
+func (router *Router) startLinks(nodeID gmns.NodeID) []*macro.Link {
+	links := make([]*macro.Link, 0)
+	for _, linkID := range router.net.Nodes[nodeID].GetOutcomingLinks() {
+		if _, ok := router.weights[linkID]; ok {
+			links = append(links, router.net.Links[linkID])
+		}
+	}
+	return links
+}
+
+// finishLinks returns passable links which enter given node
+func (router *Router) finishLinks(nodeID gmns.NodeID) []*macro.Link {
+	links := make([]*macro.Link, 0)
+	for _, linkID := range router.net.Nodes[nodeID].GetIncomingLinks() {
+		if _, ok := router.weights[linkID]; ok {
+			links = append(links, router.net.Links[linkID])
+		}
+	}
+	return links
+}
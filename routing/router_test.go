@@ -0,0 +1,132 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareNet returns the network of two two-way roads between nodes 1 and 3:
+// short and slow one through node 2 (~223 meters at 20 km/h) and long and fast one through node 4 (~315 meters at 100 km/h)
+func prepareNet(t *testing.T) *macro.Net {
+	points := map[osm.NodeID]orb.Point{1: {0, 0}, 2: {0.001, 0}, 3: {0.002, 0}, 4: {0.001, 0.001}}
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM, len(points))
+	for nodeID, pt := range points {
+		nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: pt[0], Lat: pt[1]}, IsCrossing: true}
+	}
+	newWay := func(id osm.WayID, nodes []osm.NodeID, speed float64) *wrappers.WayOSM {
+		return &wrappers.WayOSM{
+			ID:                id,
+			Nodes:             nodes,
+			Tags:              wrappers.WayTags{MaxSpeed: speed},
+			FreeSpeed:         -1,
+			LinkType:          types.LINK_PRIMARY,
+			LinkClass:         types.LINK_CLASS_HIGHWAY,
+			AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO},
+		}
+	}
+	ways := []*wrappers.WayOSM{newWay(1, []osm.NodeID{1, 2, 3}, 20), newWay(2, []osm.NodeID{1, 4, 3}, 100)}
+	net, err := macro.NewNetFromOSM(ways, nodesSet)
+	assert.NoError(t, err)
+	return net
+}
+
+func findNode(net *macro.Net, pt orb.Point) gmns.NodeID {
+	for nodeID, node := range net.Nodes {
+		if node.GetGeom().Equal(pt) {
+			return nodeID
+		}
+	}
+	return -1
+}
+
+func findLink(net *macro.Net, source, target gmns.NodeID) gmns.LinkID {
+	for linkID, link := range net.Links {
+		if link.GetSourceNodeID() == source && link.GetTargetNodeID() == target {
+			return linkID
+		}
+	}
+	return -1
+}
+
+func searches(router *Router) map[string]func(gmns.NodeID, gmns.NodeID) (*Route, error) {
+	return map[string]func(gmns.NodeID, gmns.NodeID) (*Route, error){"dijkstra": router.Dijkstra, "astar": router.AStar, "bidirectional": router.Bidirectional}
+}
+
+func TestRouter(t *testing.T) {
+	net := prepareNet(t)
+	source, slow, target, fast := findNode(net, orb.Point{0, 0}), findNode(net, orb.Point{0.001, 0}), findNode(net, orb.Point{0.002, 0}), findNode(net, orb.Point{0.001, 0.001})
+
+	router := NewRouter(net)
+	for name, search := range searches(router) {
+		route, err := search(source, target)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []gmns.NodeID{source, slow, target}, route.Nodes, "Shortest route should pass through the slow road: %s", name)
+		assert.InDelta(t, 222.6, route.Cost, 0.1, "Wrong cost of the shortest route: %s", name)
+		assert.Equal(t, orb.LineString{{0, 0}, {0.001, 0}, {0.002, 0}}, route.Geom, "Wrong geometry of the route: %s", name)
+	}
+
+	router = NewRouter(net, WithCostType(COST_FREE_FLOW_TIME))
+	for name, search := range searches(router) {
+		route, err := search(source, target)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []gmns.NodeID{source, fast, target}, route.Nodes, "Fastest route should pass through the fast road: %s", name)
+		assert.InDelta(t, 314.8/(100/3.6), route.Cost, 0.1, "Wrong cost of the fastest route: %s", name)
+	}
+
+	// Same router with updated costs
+	router = NewRouter(net, WithCustomCost(Length, nil))
+	router.UpdateCosts(FreeFlowTime)
+	route, err := router.Dijkstra(source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, []gmns.NodeID{source, fast, target}, route.Nodes, "Route should follow updated costs")
+
+	// The only movement at the middle node of the slow road is U-turn
+	storage := movement.NewMovementsStorage()
+	incomingLinkID, uTurnLinkID := findLink(net, source, slow), findLink(net, slow, source)
+	storage.AddMovements([]movement.Movement{
+		movement.NewMovement(slow, incomingLinkID, uTurnLinkID, movement.MOVEMENT_EBU, movement.MOVEMENT_TYPE_U_TURN, orb.LineString{}, movement.WithAllowedAgentTypes([]types.AgentType{types.AGENT_AUTO})),
+	})
+	router = NewRouter(net, WithMovements(storage))
+	for name, search := range searches(router) {
+		route, err := search(source, target)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []gmns.NodeID{source, fast, target}, route.Nodes, "Prohibited turn should not be used: %s", name)
+		assert.Equal(t, []movement.MovementID{-1}, route.Movements, "Node without movements should not reference any: %s", name)
+	}
+
+	// Straight movement at the middle node of the slow road costs more than the detour
+	storage = movement.NewMovementsStorage()
+	storage.AddMovements([]movement.Movement{
+		movement.NewMovement(slow, incomingLinkID, findLink(net, slow, target), movement.MOVEMENT_EBT, movement.MOVEMENT_TYPE_THRU, orb.LineString{}, movement.WithAllowedAgentTypes([]types.AgentType{types.AGENT_AUTO})),
+	})
+	router = NewRouter(net, WithMovements(storage), WithTurnCost(func(mvmt *movement.Movement) float64 { return 100 }))
+	for name, search := range searches(router) {
+		route, err := search(source, target)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []gmns.NodeID{source, fast, target}, route.Nodes, "Turn cost should be taken into account: %s", name)
+	}
+	tree, err := router.ShortestTree(source)
+	assert.NoError(t, err)
+	linksIDs, ok := tree.Links(target)
+	assert.True(t, ok)
+	assert.Equal(t, []gmns.LinkID{findLink(net, source, fast), findLink(net, fast, target)}, linksIDs, "Wrong links of the shortest route")
+
+	router = NewRouter(net, WithAgentType(types.AGENT_WALK))
+	_, err = router.Dijkstra(source, target)
+	assert.ErrorIs(t, err, ErrNoRoute, "Links do not allow walking")
+	_, err = router.Bidirectional(source, 100)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+
+	costs, err := NewRouter(net).ShortestCosts(source)
+	assert.NoError(t, err)
+	assert.Len(t, costs, 4, "Every node should be reachable")
+	assert.InDelta(t, 222.6, costs[target], 0.1, "Wrong cost of the shortest route")
+}
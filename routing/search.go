@@ -0,0 +1,204 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/pkg/errors"
+)
+
+// Dijkstra returns the shortest route between given nodes using plain Dijkstra's search
+func (router *Router) Dijkstra(sourceNodeID, targetNodeID gmns.NodeID) (*Route, error) {
+	return router.unidirectional(sourceNodeID, targetNodeID, false)
+}
+
+// AStar returns the shortest route between given nodes using A* search.
+// Great-circle distance is used as heuristic for predefined costs; custom costs use heuristic provided by the user
+func (router *Router) AStar(sourceNodeID, targetNodeID gmns.NodeID) (*Route, error) {
+	return router.unidirectional(sourceNodeID, targetNodeID, true)
+}
+
+// Bidirectional returns the shortest route between given nodes using bidirectional Dijkstra's search
+func (router *Router) Bidirectional(sourceNodeID, targetNodeID gmns.NodeID) (*Route, error) {
+	err := router.checkNodes(sourceNodeID, targetNodeID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceNodeID == targetNodeID {
+		return router.newRoute(sourceNodeID, []gmns.LinkID{}), nil
+	}
+	// Forward labels are costs from the source to the end of the link; backward labels are costs from the end of the link to the target
+	forward := make(map[gmns.LinkID]float64)
+	forwardPrevious := make(map[gmns.LinkID]gmns.LinkID)
+	backward := make(map[gmns.LinkID]float64)
+	backwardNext := make(map[gmns.LinkID]gmns.LinkID)
+	forwardQueue := &searchQueue{}
+	backwardQueue := &searchQueue{}
+
+	best := math.Inf(1)
+	meetingLinkID := gmns.LinkID(-1)
+	relax := func(labels map[gmns.LinkID]float64, parents map[gmns.LinkID]gmns.LinkID, queue *searchQueue, opposite map[gmns.LinkID]float64, linkID, parentID gmns.LinkID, cost float64) {
+		if current, ok := labels[linkID]; ok && cost >= current {
+			return
+		}
+		labels[linkID] = cost
+		parents[linkID] = parentID
+		heap.Push(queue, searchItem{linkID: linkID, key: cost})
+		if oppositeCost, ok := opposite[linkID]; ok && cost+oppositeCost < best {
+			best = cost + oppositeCost
+			meetingLinkID = linkID
+		}
+	}
+	for _, link := range router.startLinks(sourceNodeID) {
+		relax(forward, forwardPrevious, forwardQueue, backward, link.ID, -1, router.weights[link.ID])
+	}
+	for _, link := range router.finishLinks(targetNodeID) {
+		relax(backward, backwardNext, backwardQueue, forward, link.ID, -1, 0)
+	}
+
+	for forwardQueue.Len() > 0 || backwardQueue.Len() > 0 {
+		if forwardQueue.top()+backwardQueue.top() >= best {
+			break
+		}
+		if forwardQueue.top() <= backwardQueue.top() {
+			item := heap.Pop(forwardQueue).(searchItem)
+			if item.key > forward[item.linkID] {
+				continue
+			}
+			for _, next := range router.successors(router.net.Links[item.linkID]) {
+				relax(forward, forwardPrevious, forwardQueue, backward, next.ID, item.linkID, item.key+router.transitionCost(item.linkID, next.ID))
+			}
+			continue
+		}
+		item := heap.Pop(backwardQueue).(searchItem)
+		if item.key > backward[item.linkID] {
+			continue
+		}
+		for _, previous := range router.predecessors(router.net.Links[item.linkID]) {
+			relax(backward, backwardNext, backwardQueue, forward, previous.ID, item.linkID, item.key+router.transitionCost(previous.ID, item.linkID))
+		}
+	}
+	if meetingLinkID < 0 {
+		return nil, ErrNoRoute
+	}
+	linksIDs := unwind(forwardPrevious, meetingLinkID)
+	for linkID := backwardNext[meetingLinkID]; linkID >= 0; linkID = backwardNext[linkID] {
+		linksIDs = append(linksIDs, linkID)
+	}
+	return router.newRoute(sourceNodeID, linksIDs), nil
+}
+
+// ShortestCosts returns costs of the shortest routes from given node to every reachable node
+func (router *Router) ShortestCosts(sourceNodeID gmns.NodeID) (map[gmns.NodeID]float64, error) {
+	tree, err := router.ShortestTree(sourceNodeID)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Costs, nil
+}
+
+// unidirectional searches for the shortest route from the source. Search is guided by heuristic when it is needed (A*)
+func (router *Router) unidirectional(sourceNodeID, targetNodeID gmns.NodeID, guided bool) (*Route, error) {
+	err := router.checkNodes(sourceNodeID, targetNodeID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceNodeID == targetNodeID {
+		return router.newRoute(sourceNodeID, []gmns.LinkID{}), nil
+	}
+	targetGeom := router.net.Nodes[targetNodeID].GetGeom()
+	estimate := func(link *macro.Link) float64 {
+		if !guided {
+			return 0
+		}
+		return router.heuristic(router.net.Nodes[link.GetTargetNodeID()].GetGeom(), targetGeom)
+	}
+
+	// Labels are costs from the source to the end of the link
+	labels := make(map[gmns.LinkID]float64)
+	previous := make(map[gmns.LinkID]gmns.LinkID)
+	queue := &searchQueue{}
+	push := func(link *macro.Link, previousID gmns.LinkID, cost float64) {
+		if current, ok := labels[link.ID]; ok && cost >= current {
+			return
+		}
+		labels[link.ID] = cost
+		previous[link.ID] = previousID
+		heap.Push(queue, searchItem{linkID: link.ID, key: cost + estimate(link)})
+	}
+	for _, link := range router.startLinks(sourceNodeID) {
+		push(link, -1, router.weights[link.ID])
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(searchItem)
+		link := router.net.Links[item.linkID]
+		cost := labels[item.linkID]
+		if item.key > cost+estimate(link) {
+			continue
+		}
+		if link.GetTargetNodeID() == targetNodeID {
+			return router.newRoute(sourceNodeID, unwind(previous, link.ID)), nil
+		}
+		for _, next := range router.successors(link) {
+			push(next, link.ID, cost+router.transitionCost(link.ID, next.ID))
+		}
+	}
+	return nil, ErrNoRoute
+}
+
+// checkNodes checks if both nodes exist in the network
+func (router *Router) checkNodes(sourceNodeID, targetNodeID gmns.NodeID) error {
+	if _, ok := router.net.Nodes[sourceNodeID]; !ok {
+		return errors.Wrapf(ErrNodeNotFound, "Source node %d", sourceNodeID)
+	}
+	if _, ok := router.net.Nodes[targetNodeID]; !ok {
+		return errors.Wrapf(ErrNodeNotFound, "Target node %d", targetNodeID)
+	}
+	return nil
+}
+
+// unwind restores sequence of links which ends with given link
+func unwind(previous map[gmns.LinkID]gmns.LinkID, lastLinkID gmns.LinkID) []gmns.LinkID {
+	linksIDs := make([]gmns.LinkID, 0)
+	for linkID := lastLinkID; linkID >= 0; linkID = previous[linkID] {
+		linksIDs = append(linksIDs, linkID)
+	}
+	for i, j := 0, len(linksIDs)-1; i < j; i, j = i+1, j-1 {
+		linksIDs[i], linksIDs[j] = linksIDs[j], linksIDs[i]
+	}
+	return linksIDs
+}
+
+type searchItem struct {
+	linkID gmns.LinkID
+	key    float64
+}
+
+type searchQueue []searchItem
+
+func (queue searchQueue) Len() int { return len(queue) }
+func (queue searchQueue) Less(i, j int) bool {
+	if queue[i].key == queue[j].key {
+		return queue[i].linkID < queue[j].linkID
+	}
+	return queue[i].key < queue[j].key
+}
+func (queue searchQueue) Swap(i, j int) { queue[i], queue[j] = queue[j], queue[i] }
+func (queue *searchQueue) Push(x any)   { *queue = append(*queue, x.(searchItem)) }
+func (queue *searchQueue) Pop() any {
+	old := *queue
+	n := len(old)
+	item := old[n-1]
+	*queue = old[:n-1]
+	return item
+}
+
+// top returns the smallest key in the queue (+Inf for empty queue)
+func (queue searchQueue) top() float64 {
+	if len(queue) == 0 {
+		return math.Inf(1)
+	}
+	return queue[0].key
+}
@@ -0,0 +1,103 @@
+package routing
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/pkg/errors"
+)
+
+// Tree is the set of the shortest routes from the single source node to every reachable node
+type Tree struct {
+	Source gmns.NodeID
+	// Costs of the shortest routes indexed by target node (zero for the source itself)
+	Costs map[gmns.NodeID]float64
+	// Costs of the shortest routes which end with given link. Limited tree also contains links which cross the limit
+	LinkCosts map[gmns.LinkID]float64
+	// Last link of the shortest route to every reachable node (except the source)
+	lastLinks map[gmns.NodeID]gmns.LinkID
+	previous  map[gmns.LinkID]gmns.LinkID
+}
+
+// ShortestTree returns the shortest routes from given node to every reachable node (single Dijkstra's search)
+func (router *Router) ShortestTree(sourceNodeID gmns.NodeID) (*Tree, error) {
+	return router.shortestTree(sourceNodeID, math.Inf(1))
+}
+
+// ShortestTreeWithin returns the shortest routes from given node to every node which is reachable within given cost.
+// Search stops as soon as the limit is exceeded, so it is much cheaper than the full tree for small limits
+func (router *Router) ShortestTreeWithin(sourceNodeID gmns.NodeID, maxCost float64) (*Tree, error) {
+	return router.shortestTree(sourceNodeID, maxCost)
+}
+
+// shortestTree searches for the shortest routes from given node. Links which end beyond the limit are labeled but not expanded
+func (router *Router) shortestTree(sourceNodeID gmns.NodeID, maxCost float64) (*Tree, error) {
+	if _, ok := router.net.Nodes[sourceNodeID]; !ok {
+		return nil, errors.Wrapf(ErrNodeNotFound, "Source node %d", sourceNodeID)
+	}
+	tree := &Tree{
+		Source:    sourceNodeID,
+		Costs:     map[gmns.NodeID]float64{sourceNodeID: 0},
+		LinkCosts: make(map[gmns.LinkID]float64),
+		lastLinks: make(map[gmns.NodeID]gmns.LinkID),
+		previous:  make(map[gmns.LinkID]gmns.LinkID),
+	}
+	queue := &searchQueue{}
+	push := func(link *macro.Link, previousID gmns.LinkID, cost float64) {
+		if current, ok := tree.LinkCosts[link.ID]; ok && cost >= current {
+			return
+		}
+		tree.LinkCosts[link.ID] = cost
+		tree.previous[link.ID] = previousID
+		heap.Push(queue, searchItem{linkID: link.ID, key: cost})
+	}
+	for _, link := range router.startLinks(sourceNodeID) {
+		push(link, -1, router.weights[link.ID])
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(searchItem)
+		if item.key > tree.LinkCosts[item.linkID] {
+			continue
+		}
+		if item.key > maxCost {
+			break
+		}
+		link := router.net.Links[item.linkID]
+		if _, ok := tree.Costs[link.GetTargetNodeID()]; !ok {
+			// Links are settled in order of cost, so the first settled link is the best one for its target node
+			tree.Costs[link.GetTargetNodeID()] = item.key
+			tree.lastLinks[link.GetTargetNodeID()] = link.ID
+		}
+		for _, next := range router.successors(link) {
+			push(next, link.ID, item.key+router.transitionCost(link.ID, next.ID))
+		}
+	}
+	return tree, nil
+}
+
+// Links returns sequence of links of the shortest route to given node. False is returned when the node is unreachable
+func (tree *Tree) Links(targetNodeID gmns.NodeID) ([]gmns.LinkID, bool) {
+	if targetNodeID == tree.Source {
+		return []gmns.LinkID{}, true
+	}
+	lastLinkID, ok := tree.lastLinks[targetNodeID]
+	if !ok {
+		return nil, false
+	}
+	return unwind(tree.previous, lastLinkID), true
+}
+
+// LinkEntryCost returns cost of the shortest route to the start of the link (before the turn into the link).
+// False is returned when the link has not been reached
+func (tree *Tree) LinkEntryCost(linkID gmns.LinkID) (float64, bool) {
+	previousID, ok := tree.previous[linkID]
+	if !ok {
+		return 0, false
+	}
+	if previousID < 0 {
+		return 0, true
+	}
+	return tree.LinkCosts[previousID], true
+}
@@ -0,0 +1,177 @@
+package signal
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func (controllers Controllers) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameControllers := fmt.Sprintf(fnameParts[0] + "_signal_controller.csv")
+	fnamePlans := fmt.Sprintf(fnameParts[0] + "_signal_timing_plan.csv")
+	fnamePhases := fmt.Sprintf(fnameParts[0] + "_signal_timing_phase.csv")
+	fnamePhasesMovements := fmt.Sprintf(fnameParts[0] + "_signal_phase_mvmt.csv")
+
+	err := controllers.exportControllersToCSV(fnameControllers)
+	if err != nil {
+		return errors.Wrap(err, "Can't export signal controllers")
+	}
+
+	err = controllers.exportPlansToCSV(fnamePlans)
+	if err != nil {
+		return errors.Wrap(err, "Can't export signal timing plans")
+	}
+
+	err = controllers.exportPhasesToCSV(fnamePhases)
+	if err != nil {
+		return errors.Wrap(err, "Can't export signal timing phases")
+	}
+
+	err = controllers.exportPhasesMovementsToCSV(fnamePhasesMovements)
+	if err != nil {
+		return errors.Wrap(err, "Can't export signal phases movements")
+	}
+	return nil
+}
+
+func (controllers Controllers) exportControllersToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"controller_id", "node_id"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	for _, controller := range controllers {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", controller.ID),
+			fmt.Sprintf("%d", controller.NodeID),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write controller")
+		}
+	}
+	return nil
+}
+
+func (controllers Controllers) exportPlansToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"timing_plan_id", "controller_id", "cycle_length", "offset", "phases_num"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	for _, controller := range controllers {
+		plan := controller.Plan
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", plan.ID),
+			fmt.Sprintf("%d", controller.ID),
+			fmt.Sprintf("%f", plan.CycleLength),
+			fmt.Sprintf("%f", plan.Offset),
+			fmt.Sprintf("%d", len(plan.Phases)),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write timing plan")
+		}
+	}
+	return nil
+}
+
+func (controllers Controllers) exportPhasesToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"timing_phase_id", "timing_plan_id", "controller_id", "signal_phase_num", "ring", "barrier", "position", "min_green", "green", "yellow", "red_clearance", "split", "flow_ratio"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	for _, controller := range controllers {
+		for _, phase := range controller.Plan.Phases {
+			err = writer.Write([]string{
+				fmt.Sprintf("%d", phase.ID),
+				fmt.Sprintf("%d", controller.Plan.ID),
+				fmt.Sprintf("%d", controller.ID),
+				fmt.Sprintf("%d", phase.PhaseNum),
+				fmt.Sprintf("%d", phase.Ring),
+				fmt.Sprintf("%d", phase.Barrier),
+				fmt.Sprintf("%d", phase.Position),
+				fmt.Sprintf("%f", phase.MinGreen),
+				fmt.Sprintf("%f", phase.Green),
+				fmt.Sprintf("%f", phase.Yellow),
+				fmt.Sprintf("%f", phase.AllRed),
+				fmt.Sprintf("%f", phase.Split()),
+				fmt.Sprintf("%f", phase.FlowRatio),
+			})
+			if err != nil {
+				return errors.Wrap(err, "Can't write timing phase")
+			}
+		}
+	}
+	return nil
+}
+
+func (controllers Controllers) exportPhasesMovementsToCSV(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"phase_mvmt_id", "timing_phase_id", "controller_id", "signal_phase_num", "mvmt_id", "link_id", "protection"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+
+	for _, controller := range controllers {
+		for _, phase := range controller.Plan.Phases {
+			for _, phaseMvmt := range phase.Movements {
+				err = writer.Write([]string{
+					fmt.Sprintf("%d", phaseMvmt.ID),
+					fmt.Sprintf("%d", phase.ID),
+					fmt.Sprintf("%d", controller.ID),
+					fmt.Sprintf("%d", phase.PhaseNum),
+					fmt.Sprintf("%d", phaseMvmt.MovementID),
+					fmt.Sprintf("%d", phaseMvmt.LinkID),
+					phaseMvmt.Protection.String(),
+				})
+				if err != nil {
+					return errors.Wrap(err, "Can't write phase movement")
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+package signal
+
+import (
+	"math"
+	"sort"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+)
+
+const (
+	// Saturation flow rate (veh/h/lane)
+	saturationFlowDefault = 1800.0
+	// Assumed demand per approach lane (veh/h/lane) since there are no volumes
+	flowPerLaneDefault = 600.0
+	// Lost time per phase (start-up and clearance lost times), seconds
+	lostTimePerPhaseDefault = 4.0
+	yellowDefault           = 3.0
+	allRedDefault           = 2.0
+	minGreenDefault         = 7.0
+	minCycleDefault         = 40.0
+	maxCycleDefault         = 150.0
+	// Critical flow ratio sum which is considered as oversaturation
+	maxFlowRatioSum = 0.95
+	// Saturation flow reduction for left turns which are permitted only (filtering through the opposing flow)
+	permittedLeftFactor = 0.5
+)
+
+var (
+	turnSharesDefault = map[movement.MovementType]float64{
+		movement.MOVEMENT_TYPE_THRU:   0.6,
+		movement.MOVEMENT_TYPE_RIGHT:  0.2,
+		movement.MOVEMENT_TYPE_LEFT:   0.2,
+		movement.MOVEMENT_TYPE_U_TURN: 0.02,
+	}
+	turnSaturationFactorsDefault = map[movement.MovementType]float64{
+		movement.MOVEMENT_TYPE_THRU:   1.0,
+		movement.MOVEMENT_TYPE_RIGHT:  0.85,
+		movement.MOVEMENT_TYPE_LEFT:   0.95,
+		movement.MOVEMENT_TYPE_U_TURN: 0.8,
+	}
+	opposingDirections = map[movement.DirectionType]movement.DirectionType{
+		movement.DIRECTION_TYPE_NB: movement.DIRECTION_TYPE_SB,
+		movement.DIRECTION_TYPE_SB: movement.DIRECTION_TYPE_NB,
+		movement.DIRECTION_TYPE_EB: movement.DIRECTION_TYPE_WB,
+		movement.DIRECTION_TYPE_WB: movement.DIRECTION_TYPE_EB,
+	}
+)
+
+// phaseSlot holds NEMA phase numbers for the approach: through (with right turns) and protected left phases
+type phaseSlot struct {
+	thru int
+	left int
+}
+
+// Generator prepares default fixed-time signal timing plans for signalized nodes
+type Generator struct {
+	turnShares            map[movement.MovementType]float64
+	turnSaturationFactors map[movement.MovementType]float64
+	saturationFlow        float64
+	flowPerLane           float64
+	lostTimePerPhase      float64
+	yellow                float64
+	allRed                float64
+	minGreen              float64
+	minCycle              float64
+	maxCycle              float64
+}
+
+// NewGenerator constructs new signal timing plans generator
+func NewGenerator(options ...func(*Generator)) *Generator {
+	gen := &Generator{
+		turnShares:            make(map[movement.MovementType]float64, len(turnSharesDefault)),
+		turnSaturationFactors: make(map[movement.MovementType]float64, len(turnSaturationFactorsDefault)),
+		saturationFlow:        saturationFlowDefault,
+		flowPerLane:           flowPerLaneDefault,
+		lostTimePerPhase:      lostTimePerPhaseDefault,
+		yellow:                yellowDefault,
+		allRed:                allRedDefault,
+		minGreen:              minGreenDefault,
+		minCycle:              minCycleDefault,
+		maxCycle:              maxCycleDefault,
+	}
+	for k, v := range turnSharesDefault {
+		gen.turnShares[k] = v
+	}
+	for k, v := range turnSaturationFactorsDefault {
+		gen.turnSaturationFactors[k] = v
+	}
+	for _, option := range options {
+		option(gen)
+	}
+	return gen
+}
+
+// WithSaturationFlow sets saturation flow rate (veh/h/lane)
+func WithSaturationFlow(saturationFlow float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.saturationFlow = saturationFlow
+	}
+}
+
+// WithFlowPerLane sets assumed demand per approach lane (veh/h/lane)
+func WithFlowPerLane(flowPerLane float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.flowPerLane = flowPerLane
+	}
+}
+
+// WithTurnShares sets shares of approach demand for every movement type
+// Notice: shares are normalized for the movements which are present on the approach
+func WithTurnShares(turnShares map[movement.MovementType]float64) func(*Generator) {
+	return func(gen *Generator) {
+		for k, v := range turnShares {
+			gen.turnShares[k] = v
+		}
+	}
+}
+
+// WithLostTimePerPhase sets lost time per phase (seconds)
+func WithLostTimePerPhase(lostTime float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.lostTimePerPhase = lostTime
+	}
+}
+
+// WithClearance sets yellow and all-red intervals (seconds)
+func WithClearance(yellow, allRed float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.yellow = yellow
+		gen.allRed = allRed
+	}
+}
+
+// WithMinGreen sets minimum green time (seconds)
+func WithMinGreen(minGreen float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.minGreen = minGreen
+	}
+}
+
+// WithCycleBounds sets minimum and maximum cycle length (seconds)
+func WithCycleBounds(minCycle, maxCycle float64) func(*Generator) {
+	return func(gen *Generator) {
+		gen.minCycle = minCycle
+		gen.maxCycle = maxCycle
+	}
+}
+
+// Generate prepares controllers with timing plans for every node which movements are under signal control
+func (gen *Generator) Generate(mvmtStorage *movement.MovementsStorage) Controllers {
+	nodesMovements := make(map[gmns.NodeID][]*movement.Movement)
+	for _, mvmt := range mvmtStorage.List() {
+		if mvmt.ControlType() != types.CONTROL_TYPE_IS_SIGNAL {
+			continue
+		}
+		nodesMovements[mvmt.MacroNodeID] = append(nodesMovements[mvmt.MacroNodeID], mvmt)
+	}
+	nodesIDs := make([]gmns.NodeID, 0, len(nodesMovements))
+	for nodeID := range nodesMovements {
+		nodesIDs = append(nodesIDs, nodeID)
+	}
+	sort.Slice(nodesIDs, func(i, j int) bool {
+		return nodesIDs[i] < nodesIDs[j]
+	})
+
+	controllers := make(Controllers, 0, len(nodesIDs))
+	lastPhaseID := TimingPhaseID(0)
+	lastPhaseMovementID := PhaseMovementID(0)
+	for _, nodeID := range nodesIDs {
+		mvmts := nodesMovements[nodeID]
+		plan := gen.generatePlan(mvmts)
+		if plan == nil {
+			continue
+		}
+		controller := &Controller{
+			ID:     ControllerID(len(controllers)),
+			NodeID: nodeID,
+			Plan:   plan,
+		}
+		plan.ID = TimingPlanID(controller.ID)
+		for _, phase := range plan.Phases {
+			phase.ID = lastPhaseID
+			lastPhaseID++
+			for _, phaseMvmt := range phase.Movements {
+				phaseMvmt.ID = lastPhaseMovementID
+				lastPhaseMovementID++
+			}
+		}
+		controllers = append(controllers, controller)
+	}
+	return controllers
+}
+
+// generatePlan groups movements of the single node into dual-ring NEMA phases and evaluates timings with Webster's formula
+// Returns nil if there are no movements which could be assigned to phases
+func (gen *Generator) generatePlan(mvmts []*movement.Movement) *TimingPlan {
+	// Approach lanes and bounds
+	approachLanes := make(map[gmns.LinkID]int)
+	approachTypes := make(map[gmns.LinkID]map[movement.MovementType]int)
+	boundsMovements := make(map[movement.DirectionType][]*movement.Movement)
+	boundsLanes := make(map[movement.DirectionType]int)
+	for _, mvmt := range mvmts {
+		// Phases are built for main bounds only
+		direction := mvmt.MTextID.Direction().Cardinal()
+		if direction == movement.DIRECTION_TYPE_UNDEFINED {
+			continue
+		}
+		_, end := mvmt.IncomeLaneSequence()
+		if _, ok := approachLanes[mvmt.IncomeMacroLinkID]; !ok {
+			approachTypes[mvmt.IncomeMacroLinkID] = make(map[movement.MovementType]int)
+		}
+		approachLanes[mvmt.IncomeMacroLinkID] = max(approachLanes[mvmt.IncomeMacroLinkID], end+1)
+		// Far side and near side turns are swapped for left-hand traffic, so right-hand equivalents are used everywhere
+		approachTypes[mvmt.IncomeMacroLinkID][mvmt.MType.RightHandEquivalent(mvmt.DrivingSide())]++
+		boundsMovements[direction] = append(boundsMovements[direction], mvmt)
+	}
+	if len(boundsMovements) == 0 {
+		return nil
+	}
+	for direction, boundMvmts := range boundsMovements {
+		seen := make(map[gmns.LinkID]struct{})
+		for _, mvmt := range boundMvmts {
+			if _, ok := seen[mvmt.IncomeMacroLinkID]; ok {
+				continue
+			}
+			seen[mvmt.IncomeMacroLinkID] = struct{}{}
+			boundsLanes[direction] += approachLanes[mvmt.IncomeMacroLinkID]
+		}
+	}
+
+	// Major street gets phases 1, 2, 5, 6. Minor street gets phases 3, 4, 7, 8
+	var slots map[movement.DirectionType]phaseSlot
+	if boundsLanes[movement.DIRECTION_TYPE_NB]+boundsLanes[movement.DIRECTION_TYPE_SB] > boundsLanes[movement.DIRECTION_TYPE_EB]+boundsLanes[movement.DIRECTION_TYPE_WB] {
+		slots = map[movement.DirectionType]phaseSlot{
+			movement.DIRECTION_TYPE_NB: {2, 5},
+			movement.DIRECTION_TYPE_SB: {6, 1},
+			movement.DIRECTION_TYPE_EB: {4, 7},
+			movement.DIRECTION_TYPE_WB: {8, 3},
+		}
+	} else {
+		slots = map[movement.DirectionType]phaseSlot{
+			movement.DIRECTION_TYPE_EB: {2, 5},
+			movement.DIRECTION_TYPE_WB: {6, 1},
+			movement.DIRECTION_TYPE_NB: {4, 7},
+			movement.DIRECTION_TYPE_SB: {8, 3},
+		}
+	}
+
+	phases := make(map[int]*TimingPhase)
+	addToPhase := func(phaseNum int, mvmt *movement.Movement, protection ProtectionType, flowRatio float64) {
+		phase, ok := phases[phaseNum]
+		if !ok {
+			phase = newTimingPhase(phaseNum)
+			phases[phaseNum] = phase
+		}
+		phase.Movements = append(phase.Movements, &PhaseMovement{
+			MovementID: mvmt.ID,
+			LinkID:     mvmt.IncomeMacroLinkID,
+			Protection: protection,
+		})
+		phase.FlowRatio = max(phase.FlowRatio, flowRatio)
+	}
+	for _, direction := range []movement.DirectionType{movement.DIRECTION_TYPE_NB, movement.DIRECTION_TYPE_SB, movement.DIRECTION_TYPE_EB, movement.DIRECTION_TYPE_WB} {
+		boundMvmts, ok := boundsMovements[direction]
+		if !ok {
+			continue
+		}
+		slot := slots[direction]
+		thruMvmts := make([]*movement.Movement, 0, len(boundMvmts))
+		leftMvmts := make([]*movement.Movement, 0, len(boundMvmts))
+		for _, mvmt := range boundMvmts {
+			switch mvmt.MType.RightHandEquivalent(mvmt.DrivingSide()) {
+			case movement.MOVEMENT_TYPE_LEFT, movement.MOVEMENT_TYPE_U_TURN:
+				leftMvmts = append(leftMvmts, mvmt)
+			default:
+				thruMvmts = append(thruMvmts, mvmt)
+			}
+		}
+		opposingHasThru := false
+		for _, mvmt := range boundsMovements[opposingDirections[direction]] {
+			if mvmt.MType == movement.MOVEMENT_TYPE_THRU {
+				opposingHasThru = true
+				break
+			}
+		}
+		for _, mvmt := range thruMvmts {
+			protection := PROTECTION_PROTECTED
+			if mvmt.MType.RightHandEquivalent(mvmt.DrivingSide()) == movement.MOVEMENT_TYPE_RIGHT {
+				protection = PROTECTION_PERMITTED
+			}
+			addToPhase(slot.thru, mvmt, protection, gen.flowRatio(mvmt, approachLanes, approachTypes, false))
+		}
+		protectedLeft := len(thruMvmts) > 0 && hasDedicatedLanes(leftMvmts, thruMvmts)
+		for _, mvmt := range leftMvmts {
+			if protectedLeft {
+				addToPhase(slot.left, mvmt, PROTECTION_PROTECTED, gen.flowRatio(mvmt, approachLanes, approachTypes, false))
+				continue
+			}
+			if opposingHasThru {
+				addToPhase(slot.thru, mvmt, PROTECTION_PERMITTED, gen.flowRatio(mvmt, approachLanes, approachTypes, true))
+				continue
+			}
+			addToPhase(slot.thru, mvmt, PROTECTION_PROTECTED, gen.flowRatio(mvmt, approachLanes, approachTypes, false))
+		}
+	}
+	if len(phases) == 0 {
+		return nil
+	}
+
+	plan := &TimingPlan{
+		Phases: make([]*TimingPhase, 0, len(phases)),
+	}
+	for _, phase := range phases {
+		plan.Phases = append(plan.Phases, phase)
+	}
+	sort.Slice(plan.Phases, func(i, j int) bool {
+		return plan.Phases[i].PhaseNum < plan.Phases[j].PhaseNum
+	})
+	gen.evaluateTimings(plan)
+	return plan
+}
+
+// evaluateTimings estimates cycle length with Webster's formula and splits effective green between phases proportionally to critical flow ratios
+func (gen *Generator) evaluateTimings(plan *TimingPlan) {
+	// [ring][barrier]
+	var groups [2][2][]*TimingPhase
+	var groupsFlowRatio [2][2]float64
+	for _, phase := range plan.Phases {
+		phase.MinGreen = gen.minGreen
+		phase.Yellow = gen.yellow
+		phase.AllRed = gen.allRed
+		groups[phase.Ring-1][phase.Barrier-1] = append(groups[phase.Ring-1][phase.Barrier-1], phase)
+		groupsFlowRatio[phase.Ring-1][phase.Barrier-1] += phase.FlowRatio
+	}
+
+	// Critical path: for every barrier pick the ring with the highest flow ratios sum
+	criticalFlowRatio := [2]float64{}
+	criticalPhasesNum := 0
+	barriersNum := 0
+	for barrier := 0; barrier < 2; barrier++ {
+		criticalRing := -1
+		for ring := 0; ring < 2; ring++ {
+			if len(groups[ring][barrier]) == 0 {
+				continue
+			}
+			if criticalRing < 0 || groupsFlowRatio[ring][barrier] > groupsFlowRatio[criticalRing][barrier] || (groupsFlowRatio[ring][barrier] == groupsFlowRatio[criticalRing][barrier] && len(groups[ring][barrier]) > len(groups[criticalRing][barrier])) {
+				criticalRing = ring
+			}
+		}
+		if criticalRing < 0 {
+			continue
+		}
+		barriersNum++
+		criticalFlowRatio[barrier] = groupsFlowRatio[criticalRing][barrier]
+		criticalPhasesNum += len(groups[criticalRing][barrier])
+	}
+	flowRatioSum := criticalFlowRatio[0] + criticalFlowRatio[1]
+	lostTime := float64(criticalPhasesNum) * gen.lostTimePerPhase
+
+	// Webster's optimal cycle length
+	cycleLength := gen.maxCycle
+	if flowRatioSum < maxFlowRatioSum {
+		cycleLength = (1.5*lostTime + 5.0) / (1.0 - flowRatioSum)
+	}
+	cycleLength = math.Min(math.Max(cycleLength, gen.minCycle), gen.maxCycle)
+	effectiveGreen := math.Max(cycleLength-lostTime, 0)
+
+	plan.CycleLength = 0
+	for barrier := 0; barrier < 2; barrier++ {
+		barrierGreen := effectiveGreen / float64(max(barriersNum, 1))
+		if flowRatioSum > 0 {
+			barrierGreen = effectiveGreen * criticalFlowRatio[barrier] / flowRatioSum
+		}
+		barrierDuration := 0.0
+		for ring := 0; ring < 2; ring++ {
+			group := groups[ring][barrier]
+			if len(group) == 0 {
+				continue
+			}
+			ringDuration := 0.0
+			for _, phase := range group {
+				phaseGreen := barrierGreen / float64(len(group))
+				if groupsFlowRatio[ring][barrier] > 0 {
+					phaseGreen = barrierGreen * phase.FlowRatio / groupsFlowRatio[ring][barrier]
+				}
+				// Displayed green: effective green plus lost time minus clearance intervals
+				phase.Green = math.Max(math.Ceil(phaseGreen+gen.lostTimePerPhase-gen.yellow-gen.allRed), gen.minGreen)
+				ringDuration += phase.Split()
+			}
+			barrierDuration = math.Max(barrierDuration, ringDuration)
+		}
+		// Both rings should cross the barrier at the same time: extend the last phase of the shorter ring
+		for ring := 0; ring < 2; ring++ {
+			group := groups[ring][barrier]
+			if len(group) == 0 {
+				continue
+			}
+			ringDuration := 0.0
+			for _, phase := range group {
+				ringDuration += phase.Split()
+			}
+			group[len(group)-1].Green += barrierDuration - ringDuration
+		}
+		plan.CycleLength += barrierDuration
+	}
+}
+
+// flowRatio evaluates volume to saturation flow ratio for the movement
+// Volume is estimated as a share of approach demand, saturation flow is estimated from movement lanes
+func (gen *Generator) flowRatio(mvmt *movement.Movement, approachLanes map[gmns.LinkID]int, approachTypes map[gmns.LinkID]map[movement.MovementType]int, permitted bool) float64 {
+	sharesSum := 0.0
+	for mvmtType, count := range approachTypes[mvmt.IncomeMacroLinkID] {
+		sharesSum += gen.turnShares[mvmtType] * float64(count)
+	}
+	if sharesSum <= 0 {
+		return 0
+	}
+	mvmtType := mvmt.MType.RightHandEquivalent(mvmt.DrivingSide())
+	volume := float64(approachLanes[mvmt.IncomeMacroLinkID]) * gen.flowPerLane * gen.turnShares[mvmtType] / sharesSum
+	saturationFlow := gen.saturationFlow * float64(max(mvmt.LanesNum(), 1)) * gen.turnSaturationFactors[mvmtType]
+	if permitted {
+		saturationFlow *= permittedLeftFactor
+	}
+	if saturationFlow <= 0 {
+		return 0
+	}
+	return volume / saturationFlow
+}
+
+// hasDedicatedLanes checks if left turns use lanes which are not shared with through and right turns of the same approach
+func hasDedicatedLanes(leftMvmts []*movement.Movement, thruMvmts []*movement.Movement) bool {
+	if len(leftMvmts) == 0 {
+		return false
+	}
+	for _, left := range leftMvmts {
+		leftStart, leftEnd := left.IncomeLaneSequence()
+		for _, thru := range thruMvmts {
+			if thru.IncomeMacroLinkID != left.IncomeMacroLinkID {
+				continue
+			}
+			thruStart, thruEnd := thru.IncomeLaneSequence()
+			if leftStart <= thruEnd && thruStart <= leftEnd {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// newTimingPhase prepares phase with ring, barrier and position for the given NEMA phase number
+func newTimingPhase(phaseNum int) *TimingPhase {
+	phase := &TimingPhase{
+		PhaseNum:  phaseNum,
+		Ring:      1,
+		Barrier:   1,
+		Position:  2,
+		Movements: make([]*PhaseMovement, 0),
+	}
+	if phaseNum > 4 {
+		phase.Ring = 2
+	}
+	if phaseNum == 3 || phaseNum == 4 || phaseNum == 7 || phaseNum == 8 {
+		phase.Barrier = 2
+	}
+	// Left turns (odd phases) go first
+	if phaseNum%2 == 1 {
+		phase.Position = 1
+	}
+	return phase
+}
@@ -0,0 +1,64 @@
+package signal
+
+import (
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	mvmts := []movement.Movement{}
+	add := func(ib gmns.LinkID, mvmtTxtID movement.MovementCompositeType, mvmtType movement.MovementType, laneStart, laneEnd int) {
+		mvmt := movement.NewMovement(1, ib, 100+ib, mvmtTxtID, mvmtType, nil,
+			movement.WithControlType(types.CONTROL_TYPE_IS_SIGNAL),
+			movement.WithLanesNum(laneEnd-laneStart+1),
+			movement.WithIncomeLaneSequence(laneStart, laneEnd),
+		)
+		mvmts = append(mvmts, mvmt)
+	}
+	// Major street (east-west): three lanes with dedicated left turn lane
+	add(1, movement.MOVEMENT_EBL, movement.MOVEMENT_TYPE_LEFT, 0, 0)
+	add(1, movement.MOVEMENT_EBT, movement.MOVEMENT_TYPE_THRU, 1, 2)
+	add(1, movement.MOVEMENT_EBR, movement.MOVEMENT_TYPE_RIGHT, 2, 2)
+	add(2, movement.MOVEMENT_WBL, movement.MOVEMENT_TYPE_LEFT, 0, 0)
+	add(2, movement.MOVEMENT_WBT, movement.MOVEMENT_TYPE_THRU, 1, 2)
+	add(2, movement.MOVEMENT_WBR, movement.MOVEMENT_TYPE_RIGHT, 2, 2)
+	// Minor street (north-south): single shared lane
+	add(3, movement.MOVEMENT_NBL, movement.MOVEMENT_TYPE_LEFT, 0, 0)
+	add(3, movement.MOVEMENT_NBT, movement.MOVEMENT_TYPE_THRU, 0, 0)
+	add(3, movement.MOVEMENT_NBR, movement.MOVEMENT_TYPE_RIGHT, 0, 0)
+	add(4, movement.MOVEMENT_SBL, movement.MOVEMENT_TYPE_LEFT, 0, 0)
+	add(4, movement.MOVEMENT_SBT, movement.MOVEMENT_TYPE_THRU, 0, 0)
+	add(4, movement.MOVEMENT_SBR, movement.MOVEMENT_TYPE_RIGHT, 0, 0)
+	// Not signalized node should be ignored
+	mvmts = append(mvmts, movement.NewMovement(2, 5, 6, movement.MOVEMENT_NBT, movement.MOVEMENT_TYPE_THRU, nil))
+	storage := movement.NewMovementsStorage()
+	storage.AddMovements(mvmts)
+
+	controllers := NewGenerator().Generate(storage)
+	assert.Len(t, controllers, 1, "Wrong number of controllers")
+	controller := controllers[0]
+	assert.Equal(t, gmns.NodeID(1), controller.NodeID, "Wrong controller node")
+
+	plan := controller.Plan
+	phasesNums := make([]int, 0, len(plan.Phases))
+	mvmtsNum := 0
+	barriersDuration := [2][2]float64{}
+	for _, phase := range plan.Phases {
+		phasesNums = append(phasesNums, phase.PhaseNum)
+		mvmtsNum += len(phase.Movements)
+		barriersDuration[phase.Ring-1][phase.Barrier-1] += phase.Split()
+		assert.GreaterOrEqual(t, phase.Green, minGreenDefault, "Green should not be less than minimum green")
+	}
+	// Protected lefts for the major street only. Minor street lefts are permitted in through phases
+	assert.Equal(t, []int{1, 2, 4, 5, 6, 8}, phasesNums, "Wrong phases")
+	assert.Equal(t, 12, mvmtsNum, "Every signalized movement should be served")
+	assert.Equal(t, barriersDuration[0][0], barriersDuration[1][0], "Rings should cross the first barrier simultaneously")
+	assert.Equal(t, barriersDuration[0][1], barriersDuration[1][1], "Rings should cross the second barrier simultaneously")
+	assert.Equal(t, barriersDuration[0][0]+barriersDuration[0][1], plan.CycleLength, "Cycle length should be sum of barriers durations")
+	assert.GreaterOrEqual(t, plan.CycleLength, minCycleDefault, "Cycle length is too short")
+	assert.LessOrEqual(t, plan.CycleLength, maxCycleDefault+float64(len(plan.Phases))*minGreenDefault, "Cycle length is too long")
+}
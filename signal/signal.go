@@ -0,0 +1,70 @@
+package signal
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/movement"
+)
+
+type ControllerID int
+type TimingPlanID int
+type TimingPhaseID int
+type PhaseMovementID int
+
+type ProtectionType uint16
+
+const (
+	PROTECTION_UNDEFINED = ProtectionType(iota)
+	PROTECTION_PROTECTED
+	PROTECTION_PERMITTED
+)
+
+func (iotaIdx ProtectionType) String() string {
+	return [...]string{"undefined", "protected", "permitted"}[iotaIdx]
+}
+
+// Controller is the signal controller for the single macroscopic node
+type Controller struct {
+	ID     ControllerID
+	NodeID gmns.NodeID
+	Plan   *TimingPlan
+}
+
+// TimingPlan is the fixed-time plan of the controller
+type TimingPlan struct {
+	ID          TimingPlanID
+	CycleLength float64
+	Offset      float64
+	Phases      []*TimingPhase
+}
+
+// TimingPhase is the NEMA phase of the timing plan
+type TimingPhase struct {
+	ID       TimingPhaseID
+	PhaseNum int
+	Ring     int
+	Barrier  int
+	Position int
+	MinGreen float64
+	Green    float64
+	Yellow   float64
+	AllRed   float64
+	// Critical flow ratio (volume to saturation flow) of the phase
+	FlowRatio float64
+	Movements []*PhaseMovement
+}
+
+// Split returns total duration of the phase: green, yellow and all-red intervals
+func (phase *TimingPhase) Split() float64 {
+	return phase.Green + phase.Yellow + phase.AllRed
+}
+
+// PhaseMovement is the movement served by the phase
+type PhaseMovement struct {
+	ID         PhaseMovementID
+	MovementID movement.MovementID
+	LinkID     gmns.LinkID
+	Protection ProtectionType
+}
+
+// Controllers is just an alias to list of controllers
+type Controllers []*Controller
@@ -0,0 +1,208 @@
+package skim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/pkg/errors"
+)
+
+/*
+Binary format of skims (little-endian):
+
+	header: magic "O2SK" (4 bytes), version (uint16), number of skims (uint16), number of zones (uint32)
+	zones:  zone_id (int64) for every zone
+	skims:  for every skim: agent type (uint16), travel time matrix (float32 seconds), distance matrix (float32 meters)
+
+Matrices are stored row by row (origin zone, then destination zone) in order of zones. Unreachable pairs are +Inf
+*/
+
+const (
+	skimMagic   = "O2SK"
+	skimVersion = uint16(1)
+)
+
+var (
+	ErrZonesMismatch = fmt.Errorf("Skims have different zones")
+	ErrBadFormat     = fmt.Errorf("Bad format of skims")
+)
+
+type skimHeader struct {
+	Magic    [4]byte
+	Version  uint16
+	SkimsNum uint16
+	ZonesNum uint32
+}
+
+// ExportToCSV writes skims in long format (one row per origin, destination and agent type) to the file with `_skim.csv` suffix.
+// Travel time is in seconds, distance is in meters. Unreachable pairs are omitted
+func (skims Skims) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameSkims := fmt.Sprintf(fnameParts[0] + "_skim.csv")
+
+	file, err := os.Create(fnameSkims)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"o_zone_id", "d_zone_id", "agent_type", "travel_time", "distance"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, skim := range skims {
+		for i, origin := range skim.Zones {
+			for j, destination := range skim.Zones {
+				if math.IsInf(skim.Time[i][j], 1) {
+					continue
+				}
+				err = writer.Write([]string{
+					fmt.Sprintf("%d", origin),
+					fmt.Sprintf("%d", destination),
+					skim.AgentType.String(),
+					fmt.Sprintf("%f", skim.Time[i][j]),
+					fmt.Sprintf("%f", skim.Distance[i][j]),
+				})
+				if err != nil {
+					return errors.Wrap(err, "Can't write skim")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ExportToBinary writes skims to the file in compact binary format (see the description of the format above).
+// Every skim should have the same zones
+func (skims Skims) ExportToBinary(fname string) error {
+	zonesIDs := []gmns.ZoneID{}
+	if len(skims) > 0 {
+		zonesIDs = skims[0].Zones
+	}
+	for _, skim := range skims {
+		if !slices.Equal(skim.Zones, zonesIDs) {
+			return ErrZonesMismatch
+		}
+	}
+
+	file, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+
+	header := skimHeader{Version: skimVersion, SkimsNum: uint16(len(skims)), ZonesNum: uint32(len(zonesIDs))}
+	copy(header.Magic[:], skimMagic)
+	err = binary.Write(writer, binary.LittleEndian, header)
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	zones := make([]int64, len(zonesIDs))
+	for i, zoneID := range zonesIDs {
+		zones[i] = int64(zoneID)
+	}
+	err = binary.Write(writer, binary.LittleEndian, zones)
+	if err != nil {
+		return errors.Wrap(err, "Can't write zones")
+	}
+	row := make([]float32, len(zonesIDs))
+	for _, skim := range skims {
+		err = binary.Write(writer, binary.LittleEndian, uint16(skim.AgentType))
+		if err != nil {
+			return errors.Wrap(err, "Can't write agent type")
+		}
+		for _, matrix := range [][][]float64{skim.Time, skim.Distance} {
+			for _, values := range matrix {
+				for j, value := range values {
+					row[j] = float32(value)
+				}
+				err = binary.Write(writer, binary.LittleEndian, row)
+				if err != nil {
+					return errors.Wrap(err, "Can't write matrix")
+				}
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadBinary reads skims from the file in compact binary format
+func ReadBinary(fname string) (Skims, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't open file")
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	header := skimHeader{}
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read header")
+	}
+	if string(header.Magic[:]) != skimMagic || header.Version != skimVersion {
+		return nil, errors.Wrapf(ErrBadFormat, "Magic '%s', version %d", string(header.Magic[:]), header.Version)
+	}
+	// Check size before allocating matrices: number of zones is squared, so broken header could exhaust memory
+	info, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't get file info")
+	}
+	zonesNum := uint64(header.ZonesNum)
+	expectedSize := uint64(binary.Size(header)) + 8*zonesNum + uint64(header.SkimsNum)*(2+2*4*zonesNum*zonesNum)
+	if uint64(info.Size()) != expectedSize {
+		return nil, errors.Wrapf(ErrBadFormat, "File size %d, expected %d for %d zones and %d skims", info.Size(), expectedSize, header.ZonesNum, header.SkimsNum)
+	}
+	zones := make([]int64, header.ZonesNum)
+	err = binary.Read(reader, binary.LittleEndian, zones)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read zones")
+	}
+	zonesIDs := make([]gmns.ZoneID, len(zones))
+	for i, zoneID := range zones {
+		zonesIDs[i] = gmns.ZoneID(zoneID)
+	}
+	row := make([]float32, len(zones))
+	skims := make(Skims, 0, header.SkimsNum)
+	for k := 0; k < int(header.SkimsNum); k++ {
+		agentType := uint16(0)
+		err = binary.Read(reader, binary.LittleEndian, &agentType)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't read agent type")
+		}
+		skim := &Skim{
+			AgentType: types.AgentType(agentType),
+			Zones:     make([]gmns.ZoneID, len(zonesIDs)),
+			Time:      make([][]float64, len(zones)),
+			Distance:  make([][]float64, len(zones)),
+		}
+		copy(skim.Zones, zonesIDs)
+		for _, matrix := range [][][]float64{skim.Time, skim.Distance} {
+			for i := range matrix {
+				err = binary.Read(reader, binary.LittleEndian, row)
+				if err != nil {
+					return nil, errors.Wrap(err, "Can't read matrix")
+				}
+				matrix[i] = make([]float64, len(row))
+				for j, value := range row {
+					matrix[i][j] = float64(value)
+				}
+			}
+		}
+		skims = append(skims, skim)
+	}
+	return skims, nil
+}
@@ -0,0 +1,120 @@
+package skim
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/movement"
+	"github.com/LdDl/osm2gmns/routing"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/zone"
+)
+
+// Skim is zone-to-zone matrices of the fastest routes for the single agent type
+type Skim struct {
+	AgentType types.AgentType
+	Zones     []gmns.ZoneID
+	// Free-flow travel time (seconds) and distance (meters) of the fastest routes indexed by origin and destination zones.
+	// Unreachable pairs have infinite values
+	Time     [][]float64
+	Distance [][]float64
+}
+
+// Skims is the set of skims for different agent types
+type Skims []*Skim
+
+// SkimConfig is the set of parameters for skims evaluation
+type SkimConfig struct {
+	agentTypes []types.AgentType
+	// Max speed (km/h) of the agent type. Free speed of the link is used when it is lower. Default speeds of agent types are used for missing ones
+	agentSpeeds map[types.AgentType]float64
+	// Movements which are allowed at nodes. Nil value means that every turn is allowed
+	movements *movement.MovementsStorage
+	// Number of parallel workers. Non-positive value means number of CPUs
+	workers int
+}
+
+// NewSkimConfigDefault returns default parameters for skims: AGENT_AUTO, default speeds of agent types (see types.NewAgentSpeedDefault), no movement prohibitions, all CPUs
+func NewSkimConfigDefault() *SkimConfig {
+	return &SkimConfig{
+		agentTypes:  types.AGENT_TYPES_DEFAULT,
+		agentSpeeds: make(map[types.AgentType]float64),
+		workers:     0,
+	}
+}
+
+// WithAgentTypes sets agent types which skims are evaluated for
+// Notice: it copies given agent types slice
+func WithAgentTypes(agentTypes []types.AgentType) func(*SkimConfig) {
+	return func(cfg *SkimConfig) {
+		cfg.agentTypes = make([]types.AgentType, len(agentTypes))
+		copy(cfg.agentTypes, agentTypes)
+	}
+}
+
+// WithAgentSpeed sets max speed (km/h) of the agent type. Non-positive value means no limit
+func WithAgentSpeed(agentType types.AgentType, speed float64) func(*SkimConfig) {
+	return func(cfg *SkimConfig) {
+		cfg.agentSpeeds[agentType] = speed
+	}
+}
+
+// WithMovements sets movements which are allowed at nodes: contraction hierarchies are prepared without prohibited turns (see routing.WithMovements)
+func WithMovements(movements *movement.MovementsStorage) func(*SkimConfig) {
+	return func(cfg *SkimConfig) {
+		cfg.movements = movements
+	}
+}
+
+// WithWorkers sets number of workers which evaluate rows of matrices in parallel (see routing.ContractionHierarchy.Table). Non-positive value means number of CPUs
+func WithWorkers(workers int) func(*SkimConfig) {
+	return func(cfg *SkimConfig) {
+		cfg.workers = workers
+	}
+}
+
+// Evaluate evaluates skims between centroids of zones for every agent type.
+// Rows and columns of matrices are the zones which are selected by macro.Net.AttachedZones.
+// Contraction hierarchy is prepared for every agent type and the fastest routes are searched in parallel
+func Evaluate(net *macro.Net, zones zone.Zones, options ...func(*SkimConfig)) (Skims, error) {
+	cfg := NewSkimConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+
+	attached, err := net.AttachedZones(zones)
+	if err != nil {
+		return nil, err
+	}
+	zonesIDs := make([]gmns.ZoneID, len(attached))
+	centroids := make([]gmns.NodeID, len(attached))
+	for i, zn := range attached {
+		zonesIDs[i] = zn.ID
+		centroids[i] = zn.GetCentroidNodeID()
+	}
+
+	skims := make(Skims, 0, len(cfg.agentTypes))
+	for _, agentType := range cfg.agentTypes {
+		agentSpeed, ok := cfg.agentSpeeds[agentType]
+		if !ok {
+			agentSpeed = types.NewAgentSpeedDefault(agentType)
+		}
+		routerOptions := []func(*routing.RouterConfig){
+			routing.WithAgentType(agentType),
+			routing.WithCustomCost(routing.AgentFreeFlowTime(agentSpeed), nil),
+		}
+		if cfg.movements != nil {
+			routerOptions = append(routerOptions, routing.WithMovements(cfg.movements))
+		}
+		ch := routing.NewContractionHierarchy(net, routerOptions...)
+		matrix := ch.Table(centroids, centroids, cfg.workers)
+		skim := &Skim{
+			AgentType: agentType,
+			Zones:     make([]gmns.ZoneID, len(zonesIDs)),
+			Time:      matrix.Costs,
+			Distance:  matrix.Lengths,
+		}
+		copy(skim.Zones, zonesIDs)
+		skims = append(skims, skim)
+	}
+	return skims, nil
+}
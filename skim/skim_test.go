@@ -0,0 +1,88 @@
+package skim
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/macro"
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/LdDl/osm2gmns/wrappers"
+	"github.com/LdDl/osm2gmns/zone"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	// Two-way road along the equator (50 km/h, ~1.1 km) and three zones: two at the ends of the road and one far away (its centroid gets no connectors)
+	nodesSet := make(map[osm.NodeID]*wrappers.NodeOSM)
+	wayNodes := make([]osm.NodeID, 0)
+	for i := 0; i <= 10; i++ {
+		nodeID := osm.NodeID(i + 1)
+		nodesSet[nodeID] = &wrappers.NodeOSM{ID: nodeID, InnerNode: osm.Node{ID: nodeID, Lon: float64(i) * 0.001, Lat: 0.0005}, IsCrossing: true}
+		wayNodes = append(wayNodes, nodeID)
+	}
+	way := &wrappers.WayOSM{
+		ID:                1,
+		Nodes:             wayNodes,
+		Tags:              wrappers.WayTags{MaxSpeed: 50},
+		FreeSpeed:         -1,
+		LinkType:          types.LINK_PRIMARY,
+		LinkClass:         types.LINK_CLASS_HIGHWAY,
+		AllowedAgentTypes: []types.AgentType{types.AGENT_AUTO, types.AGENT_WALK},
+	}
+	net, err := macro.NewNetFromOSM([]*wrappers.WayOSM{way}, nodesSet)
+	assert.NoError(t, err)
+
+	square := func(minLon float64) orb.MultiPolygon {
+		return orb.MultiPolygon{orb.Bound{Min: orb.Point{minLon, 0}, Max: orb.Point{minLon + 0.002, 0.001}}.ToPolygon()}
+	}
+	zones := zone.Zones{zone.NewZone(0, square(0)), zone.NewZone(1, square(0.009)), zone.NewZone(2, square(1))}
+	_, err = Evaluate(net, zones)
+	assert.ErrorIs(t, err, macro.ErrNoCentroids, "Zones should be attached first")
+	_, err = net.AttachZones(zones, macro.WithConnectorsNum(1), macro.WithMaxConnectorLength(1000))
+	assert.NoError(t, err)
+
+	skims, err := Evaluate(net, zones, WithAgentTypes([]types.AgentType{types.AGENT_AUTO, types.AGENT_WALK}), WithWorkers(2))
+	assert.NoError(t, err)
+	assert.Len(t, skims, 2, "Every agent type should have its own skim")
+	auto, walk := skims[0], skims[1]
+	assert.Len(t, auto.Zones, 3, "Wrong number of zones")
+	assert.True(t, math.IsInf(auto.Time[0][2], 1), "Isolated zone should be unreachable")
+	assert.Equal(t, 0.0, auto.Time[0][0], "Intrazonal travel time should be zero")
+	assert.InDelta(t, 1000.0, auto.Distance[0][1], 150.0, "Wrong distance between zones")
+	assert.InDelta(t, auto.Distance[0][1], auto.Distance[1][0], 1e-6, "Distances should be symmetric for two-way road")
+	assert.InDelta(t, auto.Distance[0][1]/(50/3.6), auto.Time[0][1], 1.0, "Auto should drive with free speed")
+	assert.InDelta(t, walk.Distance[0][1]/(5/3.6), walk.Time[0][1], 1.0, "Pedestrian should walk with default speed")
+
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "skim.bin")
+	assert.NoError(t, skims.ExportToBinary(fname))
+	restored, err := ReadBinary(fname)
+	assert.NoError(t, err)
+	assert.Len(t, restored, 2)
+	assert.Equal(t, types.AGENT_WALK, restored[1].AgentType)
+	assert.Equal(t, auto.Zones, restored[0].Zones)
+	assert.InDelta(t, auto.Time[0][1], restored[0].Time[0][1], 1e-3, "Wrong restored travel time")
+
+	// Truncated file and header which declares huge number of zones
+	data, err := os.ReadFile(fname)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(fname, data[:len(data)-1], 0644))
+	_, err = ReadBinary(fname)
+	assert.ErrorIs(t, err, ErrBadFormat, "Truncated file should be rejected")
+	binary.LittleEndian.PutUint32(data[8:12], math.MaxUint32)
+	assert.NoError(t, os.WriteFile(fname, data, 0644))
+	_, err = ReadBinary(fname)
+	assert.ErrorIs(t, err, ErrBadFormat, "Wrong number of zones should be rejected")
+
+	assert.NoError(t, skims.ExportToCSV(filepath.Join(dir, "test.csv")))
+	content, err := os.ReadFile(filepath.Join(dir, "test_skim.csv"))
+	assert.NoError(t, err)
+	// Header and 5 reachable pairs for both agent types
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 11, "Unreachable pairs should be omitted")
+}
@@ -22,6 +22,12 @@ var (
 
 	AGENT_TYPES_DEFAULT = []AgentType{AGENT_AUTO}
 
+	// Max speed (km/h) of agents which are slower than vehicles
+	defaultSpeedByAgentType = map[AgentType]float64{
+		AGENT_BIKE: 15,
+		AGENT_WALK: 5,
+	}
+
 	agentsAccessIncludeValues = map[AgentType]map[AccessType]map[string]struct{}{
 		AGENT_AUTO: {
 			ACCESS_MOTOR_VEHICLE: {
@@ -115,6 +121,14 @@ var (
 	}
 )
 
+// NewAgentSpeedDefault returns default max speed (km/h) of the agent type. It is -1 when agent type is limited by speed of links only
+func NewAgentSpeedDefault(agentType AgentType) float64 {
+	if defaultSpeed, ok := defaultSpeedByAgentType[agentType]; ok {
+		return defaultSpeed
+	}
+	return -1
+}
+
 func agentsIntersects(left []AgentType, right []AgentType) bool {
 	for _, l := range left {
 		for _, r := range right {
@@ -252,3 +266,22 @@ func findExcludedAgent(motorVehicle, motorcar, bicycle, foot, highway, access, s
 
 	return true
 }
+
+// AgentsExclude returns agents from the left slice which are not present in the excluded slice
+// Notice: order of the left slice is preserved
+func AgentsExclude(left []AgentType, excluded []AgentType) []AgentType {
+	ans := make([]AgentType, 0, len(left))
+	for _, l := range left {
+		found := false
+		for _, e := range excluded {
+			if l == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ans = append(ans, l)
+		}
+	}
+	return ans
+}
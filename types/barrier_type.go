@@ -0,0 +1,128 @@
+package types
+
+import "strings"
+
+type BarrierType uint16
+
+const (
+	BARRIER_NONE = BarrierType(iota)
+	BARRIER_GATE
+	BARRIER_LIFT_GATE
+	BARRIER_SWING_GATE
+	BARRIER_BOLLARD
+	BARRIER_KERB
+	BARRIER_CYCLE_BARRIER
+	BARRIER_BLOCK
+	BARRIER_CHAIN
+	BARRIER_TURNSTILE
+	BARRIER_STILE
+	BARRIER_KISSING_GATE
+	BARRIER_TOLL_BOOTH
+	BARRIER_BORDER_CONTROL
+	BARRIER_OTHER
+)
+
+func (iotaIdx BarrierType) String() string {
+	return [...]string{"none", "gate", "lift_gate", "swing_gate", "bollard", "kerb", "cycle_barrier", "block", "chain", "turnstile", "stile", "kissing_gate", "toll_booth", "border_control", "other"}[iotaIdx]
+}
+
+func NewBarrierTypeFrom(str string) BarrierType {
+	if str == "" || str == "no" {
+		return BARRIER_NONE
+	}
+	if found, ok := barrierTypes[strings.ToLower(str)]; ok {
+		return found
+	}
+	return BARRIER_OTHER
+}
+
+var (
+	barrierTypes = map[string]BarrierType{
+		"gate":           BARRIER_GATE,
+		"lift_gate":      BARRIER_LIFT_GATE,
+		"swing_gate":     BARRIER_SWING_GATE,
+		"bollard":        BARRIER_BOLLARD,
+		"kerb":           BARRIER_KERB,
+		"cycle_barrier":  BARRIER_CYCLE_BARRIER,
+		"block":          BARRIER_BLOCK,
+		"chain":          BARRIER_CHAIN,
+		"turnstile":      BARRIER_TURNSTILE,
+		"stile":          BARRIER_STILE,
+		"kissing_gate":   BARRIER_KISSING_GATE,
+		"toll_booth":     BARRIER_TOLL_BOOTH,
+		"border_control": BARRIER_BORDER_CONTROL,
+	}
+
+	// Agents which can't pass barrier when there are no access tags on the barrier node
+	// See ref.: https://wiki.openstreetmap.org/wiki/Key:barrier
+	barrierBlockedAgentsDefault = map[BarrierType][]AgentType{
+		BARRIER_BOLLARD:       {AGENT_AUTO},
+		BARRIER_KERB:          {AGENT_AUTO},
+		BARRIER_CYCLE_BARRIER: {AGENT_AUTO},
+		BARRIER_BLOCK:         {AGENT_AUTO},
+		BARRIER_CHAIN:         {AGENT_AUTO},
+		BARRIER_TURNSTILE:     {AGENT_AUTO, AGENT_BIKE},
+		BARRIER_STILE:         {AGENT_AUTO, AGENT_BIKE},
+		BARRIER_KISSING_GATE:  {AGENT_AUTO, AGENT_BIKE},
+	}
+
+	barrierAccessAllowValues = map[string]struct{}{
+		"yes":         {},
+		"permissive":  {},
+		"designated":  {},
+		"destination": {},
+	}
+
+	barrierAccessDenyValues = map[string]struct{}{
+		"no":      {},
+		"private": {},
+	}
+)
+
+// NewBarrierBlockedAgentTypes returns agent types which can't pass barrier of given type
+// Access tags of the barrier node override default behaviour: specific tags (`motorcar`, `motor_vehicle`, `bicycle`, `foot`) have higher priority than `access`.
+// `motorcar` is more specific than `motor_vehicle`, so it is checked first
+func NewBarrierBlockedAgentTypes(barrier BarrierType, motorVehicle, motorcar, bicycle, foot, access string) (blockedAgents []AgentType) {
+	if barrier == BARRIER_NONE {
+		return blockedAgents
+	}
+	blockedDefault := make(map[AgentType]struct{})
+	for _, agentType := range barrierBlockedAgentsDefault[barrier] {
+		blockedDefault[agentType] = struct{}{}
+	}
+	for _, agentType := range []AgentType{AGENT_AUTO, AGENT_BIKE, AGENT_WALK} {
+		var specific []string
+		switch agentType {
+		case AGENT_AUTO:
+			specific = []string{motorcar, motorVehicle}
+		case AGENT_BIKE:
+			specific = []string{bicycle}
+		case AGENT_WALK:
+			specific = []string{foot}
+		}
+		if blocked, ok := barrierAccessDecision(append(specific, access)...); ok {
+			if blocked {
+				blockedAgents = append(blockedAgents, agentType)
+			}
+			continue
+		}
+		if _, ok := blockedDefault[agentType]; ok {
+			blockedAgents = append(blockedAgents, agentType)
+		}
+	}
+	return blockedAgents
+}
+
+// barrierAccessDecision returns decision for the first access value which is known
+// Second returned value is false when there is no decision
+func barrierAccessDecision(values ...string) (blocked bool, decided bool) {
+	for _, value := range values {
+		if _, ok := barrierAccessAllowValues[value]; ok {
+			return false, true
+		}
+		if _, ok := barrierAccessDenyValues[value]; ok {
+			return true, true
+		}
+	}
+	return false, false
+}
@@ -5,8 +5,84 @@ type ControlType uint16
 const (
 	CONTROL_TYPE_NOT_SIGNAL = ControlType(iota)
 	CONTROL_TYPE_IS_SIGNAL
+	CONTROL_TYPE_STOP
+	CONTROL_TYPE_ALL_WAY_STOP
+	CONTROL_TYPE_GIVE_WAY
+	CONTROL_TYPE_LEVEL_CROSSING
 )
 
 func (iotaIdx ControlType) String() string {
-	return [...]string{"common", "signal"}[iotaIdx]
+	return [...]string{"common", "signal", "stop", "all_way_stop", "give_way", "level_crossing"}[iotaIdx]
+}
+
+// IsApproachControl returns true for control types which are related to the specific approach rather than to the whole intersection
+func (iotaIdx ControlType) IsApproachControl() bool {
+	return iotaIdx == CONTROL_TYPE_STOP || iotaIdx == CONTROL_TYPE_GIVE_WAY || iotaIdx == CONTROL_TYPE_ALL_WAY_STOP
+}
+
+// ControlDirection is the direction (relative to OSM way) which control is applied to
+type ControlDirection uint16
+
+const (
+	CONTROL_DIRECTION_BOTH = ControlDirection(iota)
+	CONTROL_DIRECTION_FORWARD
+	CONTROL_DIRECTION_BACKWARD
+)
+
+func (iotaIdx ControlDirection) String() string {
+	return [...]string{"both", "forward", "backward"}[iotaIdx]
+}
+
+var (
+	// The more value is, the more priority control type has
+	controlTypePriority = map[ControlType]int{
+		CONTROL_TYPE_NOT_SIGNAL:     0,
+		CONTROL_TYPE_GIVE_WAY:       1,
+		CONTROL_TYPE_STOP:           2,
+		CONTROL_TYPE_LEVEL_CROSSING: 3,
+		CONTROL_TYPE_ALL_WAY_STOP:   4,
+		CONTROL_TYPE_IS_SIGNAL:      5,
+	}
+)
+
+// NewControlTypeFrom extracts control type from node tags
+// See ref.: https://wiki.openstreetmap.org/wiki/Tag:highway%3Dstop
+func NewControlTypeFrom(highway, stop, railway string) ControlType {
+	switch highway {
+	case "traffic_signals":
+		return CONTROL_TYPE_IS_SIGNAL
+	case "stop":
+		if stop == "all" {
+			return CONTROL_TYPE_ALL_WAY_STOP
+		}
+		return CONTROL_TYPE_STOP
+	case "give_way":
+		return CONTROL_TYPE_GIVE_WAY
+	default:
+		// Continue
+	}
+	if railway == "level_crossing" {
+		return CONTROL_TYPE_LEVEL_CROSSING
+	}
+	return CONTROL_TYPE_NOT_SIGNAL
+}
+
+// NewControlDirectionFrom extracts control direction from `direction` (or `traffic_signals:direction`) tag value
+func NewControlDirectionFrom(direction string) ControlDirection {
+	switch direction {
+	case "forward":
+		return CONTROL_DIRECTION_FORWARD
+	case "backward":
+		return CONTROL_DIRECTION_BACKWARD
+	default:
+		return CONTROL_DIRECTION_BOTH
+	}
+}
+
+// PriorControlType returns control type with the highest priority
+func PriorControlType(left, right ControlType) ControlType {
+	if controlTypePriority[right] > controlTypePriority[left] {
+		return right
+	}
+	return left
 }
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"strings"
+)
+
+// DrivingSide is the side of the road which is used by traffic
+type DrivingSide uint16
+
+const (
+	DRIVING_SIDE_RIGHT = DrivingSide(iota)
+	DRIVING_SIDE_LEFT
+)
+
+func (iotaIdx DrivingSide) String() string {
+	return [...]string{"right", "left"}[iotaIdx]
+}
+
+var (
+	// ISO 3166-1 alpha-2 codes of countries and territories with left-hand traffic
+	leftHandTrafficCountries = map[string]struct{}{
+		"AG": {}, "AI": {}, "AU": {}, "BB": {}, "BD": {}, "BM": {}, "BN": {}, "BS": {}, "BT": {}, "BW": {},
+		"CC": {}, "CK": {}, "CX": {}, "CY": {}, "DM": {}, "FJ": {}, "FK": {}, "GB": {}, "GD": {}, "GG": {},
+		"GY": {}, "HK": {}, "ID": {}, "IE": {}, "IM": {}, "IN": {}, "JE": {}, "JM": {}, "JP": {}, "KE": {},
+		"KI": {}, "KN": {}, "KY": {}, "LC": {}, "LK": {}, "LS": {}, "MO": {}, "MS": {}, "MT": {}, "MU": {},
+		"MV": {}, "MW": {}, "MY": {}, "MZ": {}, "NA": {}, "NF": {}, "NP": {}, "NR": {}, "NU": {}, "NZ": {},
+		"PG": {}, "PK": {}, "PN": {}, "SB": {}, "SC": {}, "SG": {}, "SH": {}, "SR": {}, "SZ": {}, "TC": {},
+		"TH": {}, "TK": {}, "TL": {}, "TO": {}, "TT": {}, "TV": {}, "TZ": {}, "UG": {}, "VC": {}, "VG": {},
+		"VI": {}, "WS": {}, "ZA": {}, "ZM": {}, "ZW": {},
+	}
+)
+
+// DrivingSideByCountry returns driving side for the country with given ISO 3166-1 alpha-2 code (e.g. "GB", "JP", "AU").
+// Right-hand traffic is returned for unknown codes
+func DrivingSideByCountry(countryCode string) DrivingSide {
+	if _, ok := leftHandTrafficCountries[strings.ToUpper(strings.TrimSpace(countryCode))]; ok {
+		return DRIVING_SIDE_LEFT
+	}
+	return DRIVING_SIDE_RIGHT
+}
@@ -0,0 +1,48 @@
+package types
+
+// VehicleRestrictions holds physical limits for vehicles which are allowed to pass.
+// Lengths are in meters, weights are in tonnes. Negative value means "no restriction".
+type VehicleRestrictions struct {
+	MaxHeight   float64
+	MaxWidth    float64
+	MaxLength   float64
+	MaxWeight   float64
+	MaxAxleLoad float64
+}
+
+// NewVehicleRestrictionsDefault returns restrictions without any limits
+func NewVehicleRestrictionsDefault() VehicleRestrictions {
+	return VehicleRestrictions{
+		MaxHeight:   -1,
+		MaxWidth:    -1,
+		MaxLength:   -1,
+		MaxWeight:   -1,
+		MaxAxleLoad: -1,
+	}
+}
+
+// IsRestricted returns true if at least one limit is set
+func (vr VehicleRestrictions) IsRestricted() bool {
+	return vr.MaxHeight >= 0 || vr.MaxWidth >= 0 || vr.MaxLength >= 0 || vr.MaxWeight >= 0 || vr.MaxAxleLoad >= 0
+}
+
+// Tightest combines two sets of restrictions picking the lowest limit for every dimension
+func (vr VehicleRestrictions) Tightest(other VehicleRestrictions) VehicleRestrictions {
+	return VehicleRestrictions{
+		MaxHeight:   tightestLimit(vr.MaxHeight, other.MaxHeight),
+		MaxWidth:    tightestLimit(vr.MaxWidth, other.MaxWidth),
+		MaxLength:   tightestLimit(vr.MaxLength, other.MaxLength),
+		MaxWeight:   tightestLimit(vr.MaxWeight, other.MaxWeight),
+		MaxAxleLoad: tightestLimit(vr.MaxAxleLoad, other.MaxAxleLoad),
+	}
+}
+
+func tightestLimit(left, right float64) float64 {
+	if left < 0 {
+		return right
+	}
+	if right < 0 {
+		return left
+	}
+	return min(left, right)
+}
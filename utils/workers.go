@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"runtime"
+	"sync"
+)
+
+// RunWorkers distributes indices of jobs [0, jobsNum) among workers and waits until every job is done.
+// Non-positive number of workers means number of CPUs. Every worker reads indices from the shared channel until it is closed,
+// so it could accumulate local state and merge it at the end
+func RunWorkers(jobsNum, workers int, worker func(jobs <-chan int)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan int, jobsNum)
+	for idx := 0; idx < jobsNum; idx++ {
+		jobs <- idx
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, jobsNum); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(jobs)
+		}()
+	}
+	wg.Wait()
+}
@@ -12,7 +12,16 @@ type NodeOSM struct {
 	ID          osm.NodeID
 	UseCount    int
 	ControlType types.ControlType
-	IsCrossing  bool
+	// Direction (relative to the way) which control type is applied to
+	ControlDirection types.ControlDirection
+	IsCrossing       bool
+	// Vehicle restrictions for barriers, bridges and etc.
+	VehicleRestrictions types.VehicleRestrictions
+	// Barrier information (if node is a barrier)
+	Barrier                  types.BarrierType
+	BarrierBlockedAgentTypes []types.AgentType
+	// Node has been marked as crossing because of barrier only
+	IsBarrierSplit bool
 }
 
 type NodeOSMInfo struct {
@@ -22,17 +31,36 @@ type NodeOSMInfo struct {
 func NewNodeOSMFrom(node *osm.Node) *NodeOSM {
 	nameText := node.Tags.Find("name")
 	highwayText := node.Tags.Find("highway")
-	controlType := types.CONTROL_TYPE_NOT_SIGNAL
-	if highwayText == "traffic_signals" {
-		controlType = types.CONTROL_TYPE_IS_SIGNAL
+	controlType := types.NewControlTypeFrom(highwayText, node.Tags.Find("stop"), node.Tags.Find("railway"))
+	controlDirection := types.CONTROL_DIRECTION_BOTH
+	switch controlType {
+	case types.CONTROL_TYPE_IS_SIGNAL:
+		controlDirection = types.NewControlDirectionFrom(node.Tags.Find("traffic_signals:direction"))
+	case types.CONTROL_TYPE_STOP, types.CONTROL_TYPE_GIVE_WAY, types.CONTROL_TYPE_ALL_WAY_STOP:
+		controlDirection = types.NewControlDirectionFrom(node.Tags.Find("direction"))
+	default:
+		// Level crossings and uncontrolled nodes have no direction
 	}
+	barrier := types.NewBarrierTypeFrom(node.Tags.Find("barrier"))
+	barrierBlockedAgentTypes := types.NewBarrierBlockedAgentTypes(
+		barrier,
+		node.Tags.Find("motor_vehicle"),
+		node.Tags.Find("motorcar"),
+		node.Tags.Find("bicycle"),
+		node.Tags.Find("foot"),
+		node.Tags.Find("access"),
+	)
 	preparedNode := NodeOSM{
-		Name:        nameText,
-		InnerNode:   *node,
-		ID:          node.ID,
-		UseCount:    0,
-		IsCrossing:  false,
-		ControlType: controlType,
+		Name:                     nameText,
+		InnerNode:                *node,
+		ID:                       node.ID,
+		UseCount:                 0,
+		IsCrossing:               false,
+		ControlType:              controlType,
+		ControlDirection:         controlDirection,
+		VehicleRestrictions:      extractVehicleRestrictions(node.Tags, "extract_node_tags", "osm_node_id", int64(node.ID)),
+		Barrier:                  barrier,
+		BarrierBlockedAgentTypes: barrierBlockedAgentTypes,
 		OsmData: NodeOSMInfo{
 			Highway: highwayText,
 		},
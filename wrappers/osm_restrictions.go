@@ -0,0 +1,154 @@
+package wrappers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/LdDl/osm2gmns/types"
+	"github.com/paulmach/osm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	feetToMeters   = 0.3048
+	inchesToMeters = 0.0254
+	kgToTonnes     = 0.001
+	lbsToTonnes    = 0.00045359237
+	// Short ton (US)
+	stToTonnes = 0.90718474
+)
+
+var (
+	// Values which are used by mappers to say "there is no numeric limit here"
+	// See ref.: https://wiki.openstreetmap.org/wiki/Key:maxheight
+	restrictionNoLimitValues = map[string]struct{}{
+		"none":           {},
+		"default":        {},
+		"below_default":  {},
+		"unsigned":       {},
+		"no_sign":        {},
+		"no_indications": {},
+	}
+
+	feetInchesRegExp = regexp.MustCompile(`^(\d+\.?\d*)\s*(?:'|ft|feet)\s*(?:(\d+\.?\d*)\s*(?:"|in|inch|inches)?)?$`)
+	inchesRegExp     = regexp.MustCompile(`^(\d+\.?\d*)\s*(?:"|in|inch|inches)$`)
+	metersRegExp     = regexp.MustCompile(`^(\d+\.?\d*)\s*(?:m)?$`)
+	weightRegExp     = regexp.MustCompile(`^(\d+\.?\d*)\s*(t|kg|lbs|lb|st)?$`)
+)
+
+// extractVehicleRestrictions parses `maxheight`, `maxwidth`, `maxlength`, `maxweight` and `maxaxleload` tags
+// scope, idKey and id are used for logging only
+func extractVehicleRestrictions(tags osm.Tags, scope string, idKey string, id int64) types.VehicleRestrictions {
+	restrictions := types.NewVehicleRestrictionsDefault()
+	lengthTags := []struct {
+		key    string
+		target *float64
+	}{
+		{"maxheight", &restrictions.MaxHeight},
+		{"maxwidth", &restrictions.MaxWidth},
+		{"maxlength", &restrictions.MaxLength},
+	}
+	for _, lt := range lengthTags {
+		source := tags.Find(lt.key)
+		if source == "" {
+			continue
+		}
+		value, err := parseLengthMeters(source)
+		if err != nil {
+			log.Warn().Str("scope", scope).Int64(idKey, id).Str(lt.key, source).Msg("Provided tag value should be a length (m, ft, in)")
+			continue
+		}
+		*lt.target = value
+	}
+	weightTags := []struct {
+		key    string
+		target *float64
+	}{
+		{"maxweight", &restrictions.MaxWeight},
+		{"maxaxleload", &restrictions.MaxAxleLoad},
+	}
+	for _, wt := range weightTags {
+		source := tags.Find(wt.key)
+		if source == "" {
+			continue
+		}
+		value, err := parseWeightTonnes(source)
+		if err != nil {
+			log.Warn().Str("scope", scope).Int64(idKey, id).Str(wt.key, source).Msg("Provided tag value should be a weight (t, kg, lbs, st)")
+			continue
+		}
+		*wt.target = value
+	}
+	return restrictions
+}
+
+// parseLengthMeters converts length tag value into meters
+// Returns -1 for values which mean "no limit"
+func parseLengthMeters(source string) (float64, error) {
+	value := normalizeRestrictionValue(source)
+	if _, ok := restrictionNoLimitValues[value]; ok {
+		return -1, nil
+	}
+	if matches := feetInchesRegExp.FindStringSubmatch(value); matches != nil {
+		feet, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return -1, err
+		}
+		inches := 0.0
+		if matches[2] != "" {
+			inches, err = strconv.ParseFloat(matches[2], 64)
+			if err != nil {
+				return -1, err
+			}
+		}
+		return feet*feetToMeters + inches*inchesToMeters, nil
+	}
+	if matches := inchesRegExp.FindStringSubmatch(value); matches != nil {
+		inches, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return -1, err
+		}
+		return inches * inchesToMeters, nil
+	}
+	if matches := metersRegExp.FindStringSubmatch(value); matches != nil {
+		return strconv.ParseFloat(matches[1], 64)
+	}
+	return -1, fmt.Errorf("unknown length format: '%s'", source)
+}
+
+// parseWeightTonnes converts weight tag value into tonnes
+// Returns -1 for values which mean "no limit"
+func parseWeightTonnes(source string) (float64, error) {
+	value := normalizeRestrictionValue(source)
+	if _, ok := restrictionNoLimitValues[value]; ok {
+		return -1, nil
+	}
+	matches := weightRegExp.FindStringSubmatch(value)
+	if matches == nil {
+		return -1, fmt.Errorf("unknown weight format: '%s'", source)
+	}
+	weight, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return -1, err
+	}
+	switch matches[2] {
+	case "kg":
+		weight *= kgToTonnes
+	case "lbs", "lb":
+		weight *= lbsToTonnes
+	case "st":
+		weight *= stToTonnes
+	default:
+		// Tonnes by default
+	}
+	return weight, nil
+}
+
+func normalizeRestrictionValue(source string) string {
+	value := strings.ToLower(strings.TrimSpace(source))
+	// Some mappers use comma as decimal separator
+	value = strings.ReplaceAll(value, ",", ".")
+	return value
+}
@@ -0,0 +1,61 @@
+package wrappers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLengthMeters(t *testing.T) {
+	precision := 10e-6
+	cases := []struct {
+		source   string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"3.5 m", 3.5},
+		{"3,8m", 3.8},
+		{"12'6\"", 12*0.3048 + 6*0.0254},
+		{"14 ft", 14 * 0.3048},
+		{"13'", 13 * 0.3048},
+		{"80 in", 80 * 0.0254},
+		{"none", -1},
+		{"default", -1},
+	}
+	for _, c := range cases {
+		ans, err := parseLengthMeters(c.source)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		assert.InDelta(t, c.expected, ans, precision, "Wrong length for '%s'", c.source)
+	}
+	_, err := parseLengthMeters("low")
+	assert.Error(t, err, "Unknown length format should produce error")
+}
+
+func TestParseWeightTonnes(t *testing.T) {
+	precision := 10e-6
+	cases := []struct {
+		source   string
+		expected float64
+	}{
+		{"7.5", 7.5},
+		{"3.5 t", 3.5},
+		{"12t", 12},
+		{"7500 kg", 7.5},
+		{"10000 lbs", 4.5359237},
+		{"5 st", 5 * 0.90718474},
+		{"none", -1},
+	}
+	for _, c := range cases {
+		ans, err := parseWeightTonnes(c.source)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		assert.InDelta(t, c.expected, ans, precision, "Wrong weight for '%s'", c.source)
+	}
+	_, err := parseWeightTonnes("heavy")
+	assert.Error(t, err, "Unknown weight format should produce error")
+}
@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/LdDl/osm2gmns/types"
 	"github.com/paulmach/osm"
 	"github.com/rs/zerolog/log"
 )
@@ -84,9 +85,12 @@ type WayTags struct {
 	amenity      string
 	leisure      string
 	junction     string
+	PriorityRoad string
 
 	MaxSpeed float64
 
+	VehicleRestrictions types.VehicleRestrictions
+
 	Lanes         int
 	LanesForward  int
 	LanesBackward int
@@ -103,6 +107,21 @@ func (wt *WayTags) IsPOI() bool {
 	return false
 }
 
+// Building returns value of `building` tag
+func (wt *WayTags) Building() string {
+	return wt.building
+}
+
+// Amenity returns value of `amenity` tag
+func (wt *WayTags) Amenity() string {
+	return wt.amenity
+}
+
+// Leisure returns value of `leisure` tag
+func (wt *WayTags) Leisure() string {
+	return wt.leisure
+}
+
 func (way *WayTags) IsHighway() bool {
 	return way.Highway != ""
 }
@@ -136,6 +155,35 @@ func (wt *WayTags) IsAerowayPOI() bool {
 	return false
 }
 
+// IsPriorityRoad checks if way is marked as priority road
+// See ref.: https://wiki.openstreetmap.org/wiki/Key:priority_road
+func (wt *WayTags) IsPriorityRoad() bool {
+	return wt.PriorityRoad == "designated" || wt.PriorityRoad == "yes_unposted" || wt.PriorityRoad == "yes"
+}
+
+// TurnLanes returns raw value of `turn:lanes` tag
+// See ref.: https://wiki.openstreetmap.org/wiki/Key:turn
+func (wt *WayTags) TurnLanes() string {
+	return wt.turnLanes
+}
+
+// TurnLanesForward returns raw value of `turn:lanes:forward` tag
+func (wt *WayTags) TurnLanesForward() string {
+	return wt.turnLanesForward
+}
+
+// TurnLanesBackward returns raw value of `turn:lanes:backward` tag
+func (wt *WayTags) TurnLanesBackward() string {
+	return wt.turnLanesBackward
+}
+
+// IsRoundabout checks if way is a part of roundabout (or other kind of circular junction)
+// See ref.: https://wiki.openstreetmap.org/wiki/Tag:junction%3Droundabout
+func (wt *WayTags) IsRoundabout() bool {
+	_, ok := junctionTypes[wt.junction]
+	return ok
+}
+
 func (wt *WayTags) IsHighwayNegligible() bool {
 	_, ok := negligibleHighwayTags[wt.Highway]
 	return ok
@@ -165,6 +213,7 @@ func NewWayTagsFrom(way *osm.Way) WayTags {
 	leisure := tags.Find("leisure")
 
 	junction := tags.Find("junction")
+	priorityRoad := tags.Find("priority_road")
 
 	var err error
 
@@ -192,6 +241,8 @@ func NewWayTagsFrom(way *osm.Way) WayTags {
 		maxSpeed = maxSpeedValue
 	}
 
+	vehicleRestrictions := extractVehicleRestrictions(tags, "extract_way_tags", "osm_way_id", int64(way.ID))
+
 	lanesSource := tags.Find("lanes")
 	lanes := -1
 	if lanesSource != "" {
@@ -257,30 +308,32 @@ func NewWayTagsFrom(way *osm.Way) WayTags {
 	}
 
 	return WayTags{
-		Name:              name,
-		Highway:           highway,
-		Railway:           railway,
-		Aeroway:           aeroway,
-		turnLanes:         turnLanes,
-		turnLanesForward:  turnLanesForward,
-		turnLanesBackward: turnLanesBackward,
-		junction:          junction,
-		Area:              area,
-		MotorVehicle:      motorVehicle,
-		Access:            access,
-		Motorcar:          motorcar,
-		Service:           service,
-		Foot:              foot,
-		Bicycle:           bicycle,
-		building:          building,
-		amenity:           amenity,
-		leisure:           leisure,
-		MaxSpeed:          maxSpeed,
-		Lanes:             lanes,
-		LanesForward:      lanesForward,
-		LanesBackward:     lanesBackward,
-		Oneway:            oneway,
-		OnewayDefault:     onewayDefault,
-		isReversed:        isReversed,
+		Name:                name,
+		Highway:             highway,
+		Railway:             railway,
+		Aeroway:             aeroway,
+		turnLanes:           turnLanes,
+		turnLanesForward:    turnLanesForward,
+		turnLanesBackward:   turnLanesBackward,
+		junction:            junction,
+		PriorityRoad:        priorityRoad,
+		Area:                area,
+		MotorVehicle:        motorVehicle,
+		Access:              access,
+		Motorcar:            motorcar,
+		Service:             service,
+		Foot:                foot,
+		Bicycle:             bicycle,
+		building:            building,
+		amenity:             amenity,
+		leisure:             leisure,
+		MaxSpeed:            maxSpeed,
+		VehicleRestrictions: vehicleRestrictions,
+		Lanes:               lanes,
+		LanesForward:        lanesForward,
+		LanesBackward:       lanesBackward,
+		Oneway:              oneway,
+		OnewayDefault:       onewayDefault,
+		isReversed:          isReversed,
 	}
 }
@@ -39,6 +39,7 @@ type WayOSM struct {
 	LinkClass           types.LinkClass
 	IsPureCycle         bool
 	IsCycle             bool
+	IsRoundabout        bool
 	// POI information (optional)
 	WayPOI *WayPOIProps
 	// The rest of params
@@ -83,6 +84,7 @@ func NewWayOSMFrom(way *osm.Way) *WayOSM {
 		Capacity:            -1.0,
 		IsArea:              tags.Area != "" && tags.Area != "no",
 		IsOneWay:            tags.Oneway,
+		IsRoundabout:        tags.IsRoundabout(),
 	}
 	if poiName != "" {
 		preparedWay.WayPOI = &WayPOIProps{
@@ -0,0 +1,46 @@
+package zone
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/pkg/errors"
+)
+
+// ExportToCSV writes zones (GMNS zone.csv) to the file with `_zone.csv` suffix
+func (zones Zones) ExportToCSV(fname string) error {
+	fnameParts := strings.Split(fname, ".csv")
+	fnameZones := fmt.Sprintf(fnameParts[0] + "_zone.csv")
+
+	file, err := os.Create(fnameZones)
+	if err != nil {
+		return errors.Wrap(err, "Can't create file")
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+	writer.Comma = ';'
+
+	err = writer.Write([]string{"zone_id", "name", "centroid_node_id", "longitude", "latitude", "geom"})
+	if err != nil {
+		return errors.Wrap(err, "Can't write header")
+	}
+	for _, zone := range zones {
+		err = writer.Write([]string{
+			fmt.Sprintf("%d", zone.ID),
+			zone.name,
+			fmt.Sprintf("%d", zone.centroidNodeID),
+			fmt.Sprintf("%f", zone.centroid[0]),
+			fmt.Sprintf("%f", zone.centroid[1]),
+			wkt.MarshalString(zone.geom),
+		})
+		if err != nil {
+			return errors.Wrap(err, "Can't write zone")
+		}
+	}
+	return nil
+}
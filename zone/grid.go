@@ -0,0 +1,36 @@
+package zone
+
+import (
+	"math"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+)
+
+const (
+	metersPerDegree = 111320.0
+)
+
+// NewGrid splits given bound (EPSG:4326) into square zones with given side (meters). Zones are numbered row by row starting from the south-west corner
+func NewGrid(bound orb.Bound, cellSize float64) Zones {
+	zones := Zones{}
+	if cellSize <= 0 || bound.IsEmpty() {
+		return zones
+	}
+	latCenter := (bound.Min.Lat() + bound.Max.Lat()) / 2.0
+	stepLat := cellSize / metersPerDegree
+	stepLon := cellSize / (metersPerDegree * math.Cos(latCenter*math.Pi/180.0))
+	rows := max(int(math.Ceil((bound.Max.Lat()-bound.Min.Lat())/stepLat)), 1)
+	columns := max(int(math.Ceil((bound.Max.Lon()-bound.Min.Lon())/stepLon)), 1)
+	zoneID := gmns.ZoneID(0)
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			minLon := bound.Min.Lon() + float64(column)*stepLon
+			minLat := bound.Min.Lat() + float64(row)*stepLat
+			cell := orb.Bound{Min: orb.Point{minLon, minLat}, Max: orb.Point{minLon + stepLon, minLat + stepLat}}
+			zones = append(zones, NewZone(zoneID, orb.MultiPolygon{cell.ToPolygon()}))
+			zoneID++
+		}
+	}
+	return zones
+}
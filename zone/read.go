@@ -0,0 +1,210 @@
+package zone
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrGeomNotSupported = fmt.Errorf("Geometry is not supported (polygon or multipolygon expected)")
+	ErrZoneIDNotInteger = fmt.Errorf("Zone identifier is not an integer")
+	ErrZoneIDDuplicate  = fmt.Errorf("Zone identifier is not unique")
+	ErrNoGeomColumn     = fmt.Errorf("There is no geometry column in the header")
+)
+
+// ReaderConfig is the set of parameters for reading zones layer
+type ReaderConfig struct {
+	// Name of the property (column) with zone identifier. Zones are numbered sequentially if there is no such property
+	idField string
+	// Name of the property (column) with zone name
+	nameField string
+	// Name of the column with WKT geometry (CSV only)
+	geomField string
+	// Separator (CSV only)
+	comma rune
+}
+
+// NewReaderConfigDefault returns default parameters for reading zones layer: `zone_id`, `name` and `geom` fields, ';' separator
+func NewReaderConfigDefault() *ReaderConfig {
+	return &ReaderConfig{
+		idField:   "zone_id",
+		nameField: "name",
+		geomField: "geom",
+		comma:     ';',
+	}
+}
+
+// WithIDField sets name of the property (column) with zone identifier
+func WithIDField(idField string) func(*ReaderConfig) {
+	return func(cfg *ReaderConfig) {
+		cfg.idField = idField
+	}
+}
+
+// WithNameField sets name of the property (column) with zone name
+func WithNameField(nameField string) func(*ReaderConfig) {
+	return func(cfg *ReaderConfig) {
+		cfg.nameField = nameField
+	}
+}
+
+// WithGeomField sets name of the column with WKT geometry (CSV only)
+func WithGeomField(geomField string) func(*ReaderConfig) {
+	return func(cfg *ReaderConfig) {
+		cfg.geomField = geomField
+	}
+}
+
+// WithComma sets separator (CSV only)
+func WithComma(comma rune) func(*ReaderConfig) {
+	return func(cfg *ReaderConfig) {
+		cfg.comma = comma
+	}
+}
+
+// ReadGeoJSON reads zones from GeoJSON feature collection (EPSG:4326). Features with geometry other than polygon or multipolygon (or without geometry) are skipped
+func ReadGeoJSON(fname string, options ...func(*ReaderConfig)) (Zones, error) {
+	cfg := NewReaderConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read file")
+	}
+	collection, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't unmarshal feature collection")
+	}
+	zones := Zones{}
+	zonesIDs := make(map[gmns.ZoneID]struct{}, len(collection.Features))
+	for idx, feature := range collection.Features {
+		if feature.Geometry == nil {
+			log.Warn().Str("scope", "read_zones").Int("feature_idx", idx).Msg("Feature has no geometry. Feature is skipped")
+			continue
+		}
+		geom, err := toMultiPolygon(feature.Geometry)
+		if err != nil {
+			log.Warn().Str("scope", "read_zones").Int("feature_idx", idx).Str("geom_type", feature.Geometry.GeoJSONType()).Msg("Feature is skipped")
+			continue
+		}
+		zoneID := gmns.ZoneID(idx)
+		if value, ok := feature.Properties[cfg.idField]; ok {
+			valueStr := fmt.Sprintf("%v", value)
+			if valueFloat, ok := value.(float64); ok {
+				// Default formatting uses exponent for large numbers
+				valueStr = strconv.FormatFloat(valueFloat, 'f', -1, 64)
+			}
+			zoneID, err = parseZoneID(valueStr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't parse zone identifier. Feature idx: %d", idx)
+			}
+		}
+		if _, ok := zonesIDs[zoneID]; ok {
+			return nil, errors.Wrapf(ErrZoneIDDuplicate, "Feature idx: %d. Zone ID: %d", idx, zoneID)
+		}
+		zonesIDs[zoneID] = struct{}{}
+		zones = append(zones, NewZone(zoneID, geom, WithName(feature.Properties.MustString(cfg.nameField, ""))))
+	}
+	return zones, nil
+}
+
+// ReadWKTCSV reads zones from CSV file with WKT geometry (EPSG:4326) column
+func ReadWKTCSV(fname string, options ...func(*ReaderConfig)) (Zones, error) {
+	cfg := NewReaderConfigDefault()
+	for _, option := range options {
+		option(cfg)
+	}
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't open file")
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = cfg.comma
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't read records")
+	}
+	if len(records) == 0 {
+		return Zones{}, nil
+	}
+	idIdx, nameIdx, geomIdx := -1, -1, -1
+	for idx, field := range records[0] {
+		switch field {
+		case cfg.idField:
+			idIdx = idx
+		case cfg.nameField:
+			nameIdx = idx
+		case cfg.geomField:
+			geomIdx = idx
+		}
+	}
+	if geomIdx < 0 {
+		return nil, errors.Wrapf(ErrNoGeomColumn, "Column: '%s'", cfg.geomField)
+	}
+	zones := make(Zones, 0, len(records)-1)
+	zonesIDs := make(map[gmns.ZoneID]struct{}, len(records)-1)
+	for idx, record := range records[1:] {
+		geomSource, err := wkt.Unmarshal(record[geomIdx])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can't unmarshal WKT. Row: %d", idx+1)
+		}
+		geom, err := toMultiPolygon(geomSource)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Row: %d", idx+1)
+		}
+		zoneID := gmns.ZoneID(idx)
+		if idIdx >= 0 {
+			zoneID, err = parseZoneID(record[idIdx])
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't parse zone identifier. Row: %d", idx+1)
+			}
+		}
+		if _, ok := zonesIDs[zoneID]; ok {
+			return nil, errors.Wrapf(ErrZoneIDDuplicate, "Row: %d. Zone ID: %d", idx+1, zoneID)
+		}
+		zonesIDs[zoneID] = struct{}{}
+		name := ""
+		if nameIdx >= 0 {
+			name = record[nameIdx]
+		}
+		zones = append(zones, NewZone(zoneID, geom, WithName(name)))
+	}
+	return zones, nil
+}
+
+func toMultiPolygon(geom orb.Geometry) (orb.MultiPolygon, error) {
+	switch g := geom.(type) {
+	case orb.Polygon:
+		return orb.MultiPolygon{g}, nil
+	case orb.MultiPolygon:
+		return g, nil
+	default:
+		return nil, ErrGeomNotSupported
+	}
+}
+
+// parseZoneID parses zone identifier. Integral floats (e.g. "7.0") are accepted, fractional ones are rejected
+func parseZoneID(value string) (gmns.ZoneID, error) {
+	zoneID, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return -1, err
+	}
+	if math.IsInf(zoneID, 0) || zoneID != math.Trunc(zoneID) {
+		return -1, errors.Wrapf(ErrZoneIDNotInteger, "Value: '%s'", value)
+	}
+	return gmns.ZoneID(zoneID), nil
+}
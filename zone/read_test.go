@@ -0,0 +1,91 @@
+package zone
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadZones(t *testing.T) {
+	dir := t.TempDir()
+
+	csvName := filepath.Join(dir, "zones.csv")
+	err := os.WriteFile(csvName, []byte("zone_id;name;geom\n7;Downtown;POLYGON((0 0,1 0,1 1,0 1,0 0))\n"), 0644)
+	assert.NoError(t, err)
+	zones, err := ReadWKTCSV(csvName)
+	assert.NoError(t, err)
+	assert.Len(t, zones, 1, "Wrong number of zones")
+	assert.Equal(t, gmns.ZoneID(7), zones[0].ID, "Wrong zone identifier")
+	assert.Equal(t, "Downtown", zones[0].GetName(), "Wrong zone name")
+	assert.Equal(t, orb.Point{0.5, 0.5}, zones[0].GetCentroid(), "Wrong zone centroid")
+	assert.True(t, zones[0].Contains(orb.Point{0.2, 0.7}), "Point should be inside the zone")
+	assert.False(t, zones[0].Contains(orb.Point{1.2, 0.7}), "Point should be outside of the zone")
+
+	geojsonName := filepath.Join(dir, "zones.geojson")
+	err = os.WriteFile(geojsonName, []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"zone_id":3,"name":"North"},"geometry":{"type":"Polygon","coordinates":[[[0,1],[1,1],[1,2],[0,2],[0,1]]]}},
+		{"type":"Feature","properties":{"zone_id":4},"geometry":{"type":"Point","coordinates":[0,0]}}
+	]}`), 0644)
+	assert.NoError(t, err)
+	zones, err = ReadGeoJSON(geojsonName)
+	assert.NoError(t, err)
+	assert.Len(t, zones, 1, "Non-polygonal features should be skipped")
+	assert.Equal(t, gmns.ZoneID(3), zones[0].ID, "Wrong zone identifier")
+	assert.Equal(t, "North", zones[0].GetName(), "Wrong zone name")
+	assert.Equal(t, zones[0], zones.Find(orb.Point{0.5, 1.5}), "Zone should be found by point")
+}
+
+func TestReadZonesInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	csvName := filepath.Join(dir, "zones.csv")
+	err := os.WriteFile(csvName, []byte("zone_id;geom\n7.5;POLYGON((0 0,1 0,1 1,0 1,0 0))\n"), 0644)
+	assert.NoError(t, err)
+	_, err = ReadWKTCSV(csvName)
+	assert.ErrorIs(t, err, ErrZoneIDNotInteger, "Fractional zone identifier should be rejected")
+
+	err = os.WriteFile(csvName, []byte("zone_id;geom\n7;POLYGON((0 0,1 0,1 1,0 1,0 0))\n7.0;POLYGON((0 1,1 1,1 2,0 2,0 1))\n"), 0644)
+	assert.NoError(t, err)
+	_, err = ReadWKTCSV(csvName)
+	assert.ErrorIs(t, err, ErrZoneIDDuplicate, "Duplicate zone identifier should be rejected")
+
+	err = os.WriteFile(csvName, []byte("zone_id;wkt\n7;POLYGON((0 0,1 0,1 1,0 1,0 0))\n"), 0644)
+	assert.NoError(t, err)
+	_, err = ReadWKTCSV(csvName)
+	assert.ErrorIs(t, err, ErrNoGeomColumn, "File without geometry column should be rejected")
+
+	geojsonName := filepath.Join(dir, "zones.geojson")
+	err = os.WriteFile(geojsonName, []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"zone_id":1},"geometry":null},
+		{"type":"Feature","properties":{"zone_id":2000000},"geometry":{"type":"Polygon","coordinates":[[[0,1],[1,1],[1,2],[0,2],[0,1]]]}}
+	]}`), 0644)
+	assert.NoError(t, err)
+	zones, err := ReadGeoJSON(geojsonName)
+	assert.NoError(t, err)
+	assert.Len(t, zones, 1, "Feature without geometry should be skipped")
+	assert.Equal(t, gmns.ZoneID(2000000), zones[0].ID, "Wrong zone identifier")
+
+	err = os.WriteFile(geojsonName, []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"zone_id":3},"geometry":{"type":"Polygon","coordinates":[[[0,1],[1,1],[1,2],[0,2],[0,1]]]}},
+		{"type":"Feature","properties":{"zone_id":3},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}}
+	]}`), 0644)
+	assert.NoError(t, err)
+	_, err = ReadGeoJSON(geojsonName)
+	assert.ErrorIs(t, err, ErrZoneIDDuplicate, "Duplicate zone identifier should be rejected")
+
+	err = os.WriteFile(csvName, []byte("zone_id;wkt\n7;POLYGON((0 0,1 0,1 1,0 1,0 0))\n"), 0644)
+	assert.NoError(t, err)
+	_, err = ReadWKTCSV(csvName)
+	assert.ErrorIs(t, err, ErrNoGeomColumn, "File without geometry column should be rejected")
+
+	err = os.WriteFile(geojsonName, []byte(`{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"zone_id":"3.25"},"geometry":{"type":"Polygon","coordinates":[[[0,1],[1,1],[1,2],[0,2],[0,1]]]}}
+	]}`), 0644)
+	assert.NoError(t, err)
+	_, err = ReadGeoJSON(geojsonName)
+	assert.ErrorIs(t, err, ErrZoneIDNotInteger, "Fractional zone identifier should be rejected")
+}
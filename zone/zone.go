@@ -0,0 +1,92 @@
+package zone
+
+import (
+	"github.com/LdDl/osm2gmns/gmns"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Zone is the traffic analysis zone
+type Zone struct {
+	name           string
+	geom           orb.MultiPolygon
+	bound          orb.Bound
+	centroid       orb.Point
+	centroidNodeID gmns.NodeID
+	ID             gmns.ZoneID
+}
+
+// Zones is the set of zones
+type Zones []*Zone
+
+// NewZone constructs new zone for given boundary (EPSG:4326). Centroid of the polygon is used as zone's centroid by default
+func NewZone(id gmns.ZoneID, geom orb.MultiPolygon, options ...func(*Zone)) *Zone {
+	zone := &Zone{
+		ID:             id,
+		geom:           geom,
+		bound:          geom.Bound(),
+		centroidNodeID: -1,
+	}
+	zone.centroid, _ = planar.CentroidArea(geom)
+	for _, option := range options {
+		option(zone)
+	}
+	return zone
+}
+
+// WithName sets name of the zone
+func WithName(name string) func(*Zone) {
+	return func(zone *Zone) {
+		zone.name = name
+	}
+}
+
+// WithCentroid sets centroid (EPSG:4326) of the zone
+func WithCentroid(centroid orb.Point) func(*Zone) {
+	return func(zone *Zone) {
+		zone.centroid = centroid
+	}
+}
+
+// Contains checks if given point (EPSG:4326) is inside the zone
+func (zone *Zone) Contains(pt orb.Point) bool {
+	if !zone.bound.Contains(pt) {
+		return false
+	}
+	return planar.MultiPolygonContains(zone.geom, pt)
+}
+
+// GetName returns name of the zone
+func (zone *Zone) GetName() string {
+	return zone.name
+}
+
+// GetGeom returns boundary (EPSG:4326) of the zone
+func (zone *Zone) GetGeom() orb.MultiPolygon {
+	return zone.geom
+}
+
+// GetCentroid returns centroid (EPSG:4326) of the zone
+func (zone *Zone) GetCentroid() orb.Point {
+	return zone.centroid
+}
+
+// GetCentroidNodeID returns identifier of the macroscopic node which represents the zone's centroid (-1 if there is no such)
+func (zone *Zone) GetCentroidNodeID() gmns.NodeID {
+	return zone.centroidNodeID
+}
+
+// SetCentroidNodeID sets identifier of the macroscopic node which represents the zone's centroid
+func (zone *Zone) SetCentroidNodeID(nodeID gmns.NodeID) {
+	zone.centroidNodeID = nodeID
+}
+
+// Find returns the first zone which contains given point (EPSG:4326). Returns nil if there is no such
+func (zones Zones) Find(pt orb.Point) *Zone {
+	for _, zone := range zones {
+		if zone.Contains(pt) {
+			return zone
+		}
+	}
+	return nil
+}